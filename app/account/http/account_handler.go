@@ -0,0 +1,86 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/account"
+	"github.com/fajardm/ewallet-example/app/account/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type accountHandler struct {
+	accountUsecase account.Usecase
+}
+
+func NewAccountHandler(app *bootstrap.Bootstrap, accountUsecase account.Usecase) {
+	handler := accountHandler{accountUsecase: accountUsecase}
+	app.Admin.Put("/users/:id/status", middleware.Require(_roleModel.UsersManage), handler.ChangeUserStatus)
+	app.Admin.Get("/users/:id/status", middleware.Require(_roleModel.UsersRead), handler.FetchUserStatusChanges)
+	app.Admin.Put("/balances/:id/status", middleware.Require(_roleModel.BalancesManage), handler.ChangeBalanceStatus)
+	app.Admin.Get("/balances/:id/status", middleware.Require(_roleModel.UsersRead), handler.FetchBalanceStatusChanges)
+}
+
+func (a accountHandler) ChangeUserStatus(ctx *fiber.Ctx) {
+	a.changeStatus(ctx, model.UserTarget)
+}
+
+func (a accountHandler) ChangeBalanceStatus(ctx *fiber.Ctx) {
+	a.changeStatus(ctx, model.BalanceTarget)
+}
+
+func (a accountHandler) FetchUserStatusChanges(ctx *fiber.Ctx) {
+	a.fetchStatusChanges(ctx, model.UserTarget)
+}
+
+func (a accountHandler) FetchBalanceStatusChanges(ctx *fiber.Ctx) {
+	a.fetchStatusChanges(ctx, model.BalanceTarget)
+}
+
+func (a accountHandler) changeStatus(ctx *fiber.Ctx, targetType model.TargetType) {
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	targetID, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.Input)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	data, err := a.accountUsecase.ChangeStatus(ctx.Context(), *input.NewStatusChange(targetType, targetID, *actorID))
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (a accountHandler) fetchStatusChanges(ctx *fiber.Ctx, targetType model.TargetType) {
+	targetID, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := a.accountUsecase.FetchStatusChanges(ctx.Context(), targetType, targetID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
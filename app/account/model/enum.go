@@ -0,0 +1,70 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTargetType represent error when invalid TargetType
+var ErrInvalidTargetType = errors.New("InvalidTargetType")
+
+type TargetType int
+
+const (
+	// UserTarget represent status change of a user
+	UserTarget TargetType = 1 + iota
+	// BalanceTarget represent status change of a balance
+	BalanceTarget
+)
+
+// TargetTypeFromString will converts a string to a TargetType, will return TargetType if string is
+// valid representation of TargetType, or error otherwise
+func TargetTypeFromString(s string) (res TargetType, err error) {
+	switch s {
+	case "user":
+		res = UserTarget
+	case "balance":
+		res = BalanceTarget
+	default:
+		err = errors.WithMessagef(ErrInvalidTargetType, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for TargetType. With this when marshalling to json
+// TargetType will be shown as its string representation instead of int
+func (t TargetType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// String returns the string representation of TargetType
+func (t TargetType) String() string {
+	var s string
+	switch t {
+	case UserTarget:
+		s = "user"
+	case BalanceTarget:
+		s = "balance"
+	}
+	return s
+}
+
+// Value transforms TargetType to its value for its column in database (MySQL)
+func (t TargetType) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan transforms MySQL enum column value for target_type column to TargetType
+func (t *TargetType) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	st, err := TargetTypeFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*t = st
+	return nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/validator"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type Input struct {
+	Status base.AccountStatus `json:"status" validate:"required"`
+	Reason string             `json:"reason" validate:"required,max=256"`
+}
+
+func (i Input) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+func (i Input) NewStatusChange(targetType TargetType, targetID, actorID uuid.UUID) *StatusChange {
+	return &StatusChange{
+		ID:         uuid.NewV4(),
+		TargetType: targetType,
+		TargetID:   targetID,
+		StatusTo:   i.Status,
+		Reason:     i.Reason,
+		CreatedBy:  actorID,
+		CreatedAt:  time.Now(),
+	}
+}
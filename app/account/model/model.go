@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// StatusChange is append only record of user or balance status transition
+type StatusChange struct {
+	ID         uuid.UUID          `json:"id"`
+	TargetType TargetType         `json:"target_type"`
+	TargetID   uuid.UUID          `json:"target_id"`
+	StatusFrom base.AccountStatus `json:"status_from"`
+	StatusTo   base.AccountStatus `json:"status_to"`
+	Reason     string             `json:"reason"`
+	CreatedBy  uuid.UUID          `json:"created_by"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// StatusChanges is list of status change model
+type StatusChanges []StatusChange
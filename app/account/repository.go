@@ -0,0 +1,15 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/account/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Repository represent the account's repository contract
+type Repository interface {
+	TxStoreStatusChange(context.Context, *sql.Tx, model.StatusChange) error
+	FetchStatusChanges(context.Context, model.TargetType, uuid.UUID) (model.StatusChanges, error)
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/account"
+	"github.com/fajardm/ewallet-example/app/account/model"
+	"github.com/fajardm/ewallet-example/database"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Table account_status_changes
+	querySelectStatusChanges = `
+		SELECT 
+			id,
+			target_type,
+			target_id,
+			status_from,
+			status_to,
+			reason,
+			created_by,
+			created_at
+		FROM account_status_changes
+	`
+	queryInsertStatusChange = `
+		INSERT INTO account_status_changes (
+			id,
+			target_type,
+			target_id,
+			status_from,
+			status_to,
+			reason,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+)
+
+type accountRepository struct {
+	db *database.MySQL
+}
+
+func NewAccountRepository(conn *database.MySQL) account.Repository {
+	return &accountRepository{db: conn}
+}
+
+func (a accountRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return a.db.WithTransaction(ctx, fn)
+}
+
+func (a accountRepository) TxStoreStatusChange(ctx context.Context, tx *sql.Tx, change model.StatusChange) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertStatusChange, change.ID, change.TargetType, change.TargetID, change.StatusFrom, change.StatusTo, change.Reason, change.CreatedBy, change.CreatedAt)
+	return
+}
+
+func (a accountRepository) FetchStatusChanges(ctx context.Context, targetType model.TargetType, targetID uuid.UUID) (model.StatusChanges, error) {
+	q := querySelectStatusChanges + " WHERE target_type=? AND target_id=? ORDER BY created_at DESC"
+	rows, err := a.db.QueryContext(ctx, q, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.StatusChanges, 0)
+	for rows.Next() {
+		r := model.StatusChange{}
+		err = rows.Scan(&r.ID, &r.TargetType, &r.TargetID, &r.StatusFrom, &r.StatusTo, &r.Reason, &r.CreatedBy, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
@@ -0,0 +1,13 @@
+package account
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/account/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the account's usecase contract
+type Usecase interface {
+	ChangeStatus(context.Context, model.StatusChange) (*model.StatusChange, error)
+	FetchStatusChanges(context.Context, model.TargetType, uuid.UUID) (model.StatusChanges, error)
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/account"
+	"github.com/fajardm/ewallet-example/app/account/model"
+	"github.com/fajardm/ewallet-example/app/balance"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type accountUsecase struct {
+	accountRepository account.Repository
+	userRepository    user.Repository
+	balanceRepository balance.Repository
+	auditor           audit.Auditor
+	contextTimeout    time.Duration
+}
+
+func NewAccountUsecase(accountRepository account.Repository, userRepository user.Repository, balanceRepository balance.Repository, auditor audit.Auditor, contextTimeout time.Duration) account.Usecase {
+	return accountUsecase{accountRepository: accountRepository, userRepository: userRepository, balanceRepository: balanceRepository, auditor: auditor, contextTimeout: contextTimeout}
+}
+
+// ChangeStatus moves user or balance into the requested status and keeps the transition history
+func (a accountUsecase) ChangeStatus(ctx context.Context, change model.StatusChange) (*model.StatusChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	var update func(tx *sql.Tx) error
+	switch change.TargetType {
+	case model.UserTarget:
+		u, err := a.userRepository.GetByID(ctx, change.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		if !u.Status.CanTransitionTo(change.StatusTo) {
+			return nil, errorcode.ErrConflict
+		}
+		change.StatusFrom = u.Status
+		u.Status = change.StatusTo
+		u.UpdatedBy = &change.CreatedBy
+		u.UpdatedAt = &change.CreatedAt
+		update = func(tx *sql.Tx) error {
+			return a.userRepository.TxUpdateStatus(ctx, tx, *u)
+		}
+	case model.BalanceTarget:
+		b, err := a.balanceRepository.GetByID(ctx, change.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		if !b.Status.CanTransitionTo(change.StatusTo) {
+			return nil, errorcode.ErrConflict
+		}
+		change.StatusFrom = b.Status
+		b.Status = change.StatusTo
+		b.UpdatedBy = &change.CreatedBy
+		b.UpdatedAt = &change.CreatedAt
+		update = func(tx *sql.Tx) error {
+			return a.balanceRepository.TxUpdateStatus(ctx, tx, *b)
+		}
+	default:
+		return nil, errorcode.ErrBadParamInput
+	}
+
+	err := a.accountRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = update(tx); err != nil {
+			return err
+		}
+		if err = a.accountRepository.TxStoreStatusChange(ctx, tx, change); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, a.auditor, audit.Event{
+		ActorID:    &change.CreatedBy,
+		Action:     audit.AccountStatus,
+		TargetType: change.TargetType.String(),
+		TargetID:   change.TargetID,
+		Before:     audit.Snapshot(map[string]interface{}{"status": change.StatusFrom}),
+		After:      audit.Snapshot(change),
+	})
+	return &change, nil
+}
+
+func (a accountUsecase) FetchStatusChanges(ctx context.Context, targetType model.TargetType, targetID uuid.UUID) (model.StatusChanges, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.accountRepository.FetchStatusChanges(ctx, targetType, targetID)
+}
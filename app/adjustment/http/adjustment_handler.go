@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/adjustment"
+	"github.com/fajardm/ewallet-example/app/adjustment/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type adjustmentHandler struct {
+	adjustmentUsecase adjustment.Usecase
+}
+
+func NewAdjustmentHandler(app *bootstrap.Bootstrap, adjustmentUsecase adjustment.Usecase) {
+	handler := adjustmentHandler{adjustmentUsecase: adjustmentUsecase}
+	app.Admin.Post("/adjustments", middleware.Require(_roleModel.AdjustmentsPropose), handler.Propose)
+	app.Admin.Get("/adjustments", middleware.Require(_roleModel.AdjustmentsPropose), handler.Fetch)
+	app.Admin.Get("/adjustments/:id", middleware.Require(_roleModel.AdjustmentsPropose), handler.Get)
+	app.Admin.Post("/adjustments/:id/approve", middleware.Require(_roleModel.AdjustmentsReview), handler.Approve)
+	app.Admin.Post("/adjustments/:id/reject", middleware.Require(_roleModel.AdjustmentsReview), handler.Reject)
+}
+
+func (a adjustmentHandler) Propose(ctx *fiber.Ctx) {
+	operatorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.Input)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	adj := input.NewAdjustment(*operatorID)
+	if err := a.adjustmentUsecase.Propose(ctx.Context(), *adj); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": adj})
+}
+
+func (a adjustmentHandler) Fetch(ctx *fiber.Ctx) {
+	status := model.Pending
+	if s := ctx.Query("status"); s != "" {
+		st, err := model.AdjustmentStatusFromString(s)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+			return
+		}
+		status = st
+	}
+
+	data, err := a.adjustmentUsecase.FetchByStatus(ctx.Context(), status)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (a adjustmentHandler) Get(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := a.adjustmentUsecase.GetByID(ctx.Context(), id)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (a adjustmentHandler) Approve(ctx *fiber.Ctx) {
+	a.review(ctx, a.adjustmentUsecase.Approve)
+}
+
+func (a adjustmentHandler) Reject(ctx *fiber.Ctx) {
+	a.review(ctx, a.adjustmentUsecase.Reject)
+}
+
+func (a adjustmentHandler) review(ctx *fiber.Ctx, fn func(context.Context, uuid.UUID, uuid.UUID, *string) error) {
+	operatorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.ReviewInput)
+	if len(ctx.Fasthttp.Request.Body()) > 0 {
+		if err := ctx.BodyParser(input); err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+			return
+		}
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := fn(ctx.Context(), id, *operatorID, input.Note); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	data, err := a.adjustmentUsecase.GetByID(ctx.Context(), id)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
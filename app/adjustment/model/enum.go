@@ -0,0 +1,145 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidAdjustmentStatus represent error when invalid AdjustmentStatus
+var ErrInvalidAdjustmentStatus = errors.New("InvalidAdjustmentStatus")
+
+type AdjustmentStatus int
+
+const (
+	// Pending represent adjustment waiting for approval
+	Pending AdjustmentStatus = 1 + iota
+	// Approved represent adjustment approved and posted to the balance
+	Approved
+	// Rejected represent adjustment rejected by reviewer
+	Rejected
+)
+
+// AdjustmentStatusFromString will converts a string to a AdjustmentStatus, will return AdjustmentStatus if string is
+// valid representation of AdjustmentStatus, or error otherwise
+func AdjustmentStatusFromString(s string) (res AdjustmentStatus, err error) {
+	switch s {
+	case "pending":
+		res = Pending
+	case "approved":
+		res = Approved
+	case "rejected":
+		res = Rejected
+	default:
+		err = errors.WithMessagef(ErrInvalidAdjustmentStatus, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for AdjustmentStatus. With this when marshalling to json
+// AdjustmentStatus will be shown as its string representation instead of int
+func (a AdjustmentStatus) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// String returns the string representation of AdjustmentStatus
+func (a AdjustmentStatus) String() string {
+	var s string
+	switch a {
+	case Pending:
+		s = "pending"
+	case Approved:
+		s = "approved"
+	case Rejected:
+		s = "rejected"
+	}
+	return s
+}
+
+// Value transforms AdjustmentStatus to its value for its column in database (MySQL)
+func (a AdjustmentStatus) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan transforms MySQL enum column value for status column to AdjustmentStatus
+func (a *AdjustmentStatus) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	st, err := AdjustmentStatusFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*a = st
+	return nil
+}
+
+// ErrInvalidAdjustmentAction represent error when invalid AdjustmentAction
+var ErrInvalidAdjustmentAction = errors.New("InvalidAdjustmentAction")
+
+type AdjustmentAction int
+
+const (
+	// Proposed represent action when operator proposes an adjustment
+	Proposed AdjustmentAction = 1 + iota
+	// Approve represent action when other operator approves an adjustment
+	Approve
+	// Reject represent action when other operator rejects an adjustment
+	Reject
+)
+
+// AdjustmentActionFromString will converts a string to a AdjustmentAction, will return AdjustmentAction if string is
+// valid representation of AdjustmentAction, or error otherwise
+func AdjustmentActionFromString(s string) (res AdjustmentAction, err error) {
+	switch s {
+	case "proposed":
+		res = Proposed
+	case "approved":
+		res = Approve
+	case "rejected":
+		res = Reject
+	default:
+		err = errors.WithMessagef(ErrInvalidAdjustmentAction, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for AdjustmentAction. With this when marshalling to json
+// AdjustmentAction will be shown as its string representation instead of int
+func (a AdjustmentAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// String returns the string representation of AdjustmentAction
+func (a AdjustmentAction) String() string {
+	var s string
+	switch a {
+	case Proposed:
+		s = "proposed"
+	case Approve:
+		s = "approved"
+	case Reject:
+		s = "rejected"
+	}
+	return s
+}
+
+// Value transforms AdjustmentAction to its value for its column in database (MySQL)
+func (a AdjustmentAction) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan transforms MySQL enum column value for action column to AdjustmentAction
+func (a *AdjustmentAction) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	st, err := AdjustmentActionFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*a = st
+	return nil
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/validator"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type Input struct {
+	UserID    uuid.UUID                            `json:"user_id" validate:"required"`
+	Type      _balanceModel.UserBalanceHistoryType `json:"type" validate:"required"`
+	Amount    float64                              `json:"amount" validate:"required,gt=0"`
+	Reason    string                               `json:"reason" validate:"required,max=256"`
+	Reference string                               `json:"reference" validate:"required,max=128"`
+}
+
+func (i Input) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+func (i Input) NewAdjustment(proposerID uuid.UUID) *Adjustment {
+	return &Adjustment{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: proposerID,
+			CreatedAt: time.Now(),
+		},
+		UserID:    i.UserID,
+		Type:      i.Type,
+		Amount:    i.Amount,
+		Reason:    i.Reason,
+		Reference: i.Reference,
+		Status:    Pending,
+	}
+}
+
+type ReviewInput struct {
+	Note *string `json:"note" validate:"omitempty,max=256"`
+}
+
+func (i ReviewInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
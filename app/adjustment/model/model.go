@@ -0,0 +1,61 @@
+package model
+
+import (
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Adjustment is a balance correction proposed by one operator and reviewed by another
+type Adjustment struct {
+	base.Model
+	UserID     uuid.UUID                            `json:"user_id"`
+	Type       _balanceModel.UserBalanceHistoryType `json:"type"`
+	Amount     float64                              `json:"amount"`
+	Reason     string                               `json:"reason"`
+	Reference  string                               `json:"reference"`
+	Status     AdjustmentStatus                     `json:"status"`
+	ReviewedBy *uuid.UUID                           `json:"reviewed_by"`
+	ReviewedAt *time.Time                           `json:"reviewed_at"`
+	ReviewNote *string                              `json:"review_note"`
+	Logs       AdjustmentLogs                       `json:"logs,omitempty"`
+}
+
+// Review marks adjustment as reviewed by the given operator
+func (a *Adjustment) Review(status AdjustmentStatus, reviewerID uuid.UUID, note *string, at time.Time) {
+	a.Status = status
+	a.ReviewedBy = &reviewerID
+	a.ReviewedAt = &at
+	a.ReviewNote = note
+	a.UpdatedBy = &reviewerID
+	a.UpdatedAt = &at
+}
+
+// NewLog creates log entry of the given action on the adjustment
+func (a Adjustment) NewLog(action AdjustmentAction, actorID uuid.UUID, note *string, at time.Time) AdjustmentLog {
+	return AdjustmentLog{
+		ID:           uuid.NewV4(),
+		AdjustmentID: a.ID,
+		Action:       action,
+		Note:         note,
+		CreatedBy:    actorID,
+		CreatedAt:    at,
+	}
+}
+
+// Adjustments is list of adjustment model
+type Adjustments []Adjustment
+
+// AdjustmentLog is append only record of adjustment lifecycle
+type AdjustmentLog struct {
+	ID           uuid.UUID        `json:"id"`
+	AdjustmentID uuid.UUID        `json:"adjustment_id"`
+	Action       AdjustmentAction `json:"action"`
+	Note         *string          `json:"note"`
+	CreatedBy    uuid.UUID        `json:"created_by"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// AdjustmentLogs is list of adjustment log model
+type AdjustmentLogs []AdjustmentLog
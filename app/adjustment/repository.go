@@ -0,0 +1,19 @@
+package adjustment
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/adjustment/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Repository represent the adjustment's repository contract
+type Repository interface {
+	TxStore(context.Context, *sql.Tx, model.Adjustment) error
+	GetByID(context.Context, uuid.UUID) (*model.Adjustment, error)
+	FetchByStatus(context.Context, model.AdjustmentStatus) (model.Adjustments, error)
+	TxReview(context.Context, *sql.Tx, model.Adjustment) error
+	TxStoreLog(context.Context, *sql.Tx, model.AdjustmentLog) error
+	FetchLogsByAdjustmentID(context.Context, uuid.UUID) (model.AdjustmentLogs, error)
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
@@ -0,0 +1,165 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/adjustment"
+	"github.com/fajardm/ewallet-example/app/adjustment/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Table balance_adjustments
+	querySelectAdjustment = `
+		SELECT 
+			id,
+			user_id,
+			type,
+			amount,
+			reason,
+			reference,
+			status,
+			reviewed_by,
+			reviewed_at,
+			review_note,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at 
+		FROM balance_adjustments
+	`
+	queryInsertAdjustment = `
+		INSERT INTO balance_adjustments (
+			id,
+			user_id,
+			type,
+			amount,
+			reason,
+			reference,
+			status,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	queryReviewAdjustment = `
+		UPDATE balance_adjustments SET status=?, reviewed_by=?, reviewed_at=?, review_note=?, updated_by=?, updated_at=? WHERE id=? AND status=?
+	`
+	// Table balance_adjustment_logs
+	querySelectAdjustmentLogs = `
+		SELECT 
+			id,
+			adjustment_id,
+			action,
+			note,
+			created_by,
+			created_at
+		FROM balance_adjustment_logs
+	`
+	queryInsertAdjustmentLog = `
+		INSERT INTO balance_adjustment_logs (
+			id,
+			adjustment_id,
+			action,
+			note,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+)
+
+type adjustmentRepository struct {
+	db *database.MySQL
+}
+
+func NewAdjustmentRepository(conn *database.MySQL) adjustment.Repository {
+	return &adjustmentRepository{db: conn}
+}
+
+func (a adjustmentRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return a.db.WithTransaction(ctx, fn)
+}
+
+func (a adjustmentRepository) TxStore(ctx context.Context, tx *sql.Tx, adj model.Adjustment) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertAdjustment, adj.ID, adj.UserID, adj.Type, adj.Amount, adj.Reason, adj.Reference, adj.Status, adj.CreatedBy, adj.CreatedAt)
+	return
+}
+
+func (a adjustmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Adjustment, error) {
+	q := querySelectAdjustment + " WHERE id=?"
+	list, err := a.fetchContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+	return nil, errorcode.ErrNotFound
+}
+
+func (a adjustmentRepository) FetchByStatus(ctx context.Context, status model.AdjustmentStatus) (model.Adjustments, error) {
+	q := querySelectAdjustment + " WHERE status=? ORDER BY created_at DESC LIMIT 100"
+	return a.fetchContext(ctx, q, status)
+}
+
+// TxReview only updates adjustment that is still pending, so an adjustment can not be reviewed twice
+func (a adjustmentRepository) TxReview(ctx context.Context, tx *sql.Tx, adj model.Adjustment) (err error) {
+	res, err := tx.ExecContext(ctx, queryReviewAdjustment, adj.Status, adj.ReviewedBy, adj.ReviewedAt, adj.ReviewNote, adj.UpdatedBy, adj.UpdatedAt, adj.ID, model.Pending)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected != 1 {
+		err = errorcode.ErrConflict
+		return
+	}
+	return
+}
+
+func (a adjustmentRepository) TxStoreLog(ctx context.Context, tx *sql.Tx, log model.AdjustmentLog) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertAdjustmentLog, log.ID, log.AdjustmentID, log.Action, log.Note, log.CreatedBy, log.CreatedAt)
+	return
+}
+
+func (a adjustmentRepository) FetchLogsByAdjustmentID(ctx context.Context, adjustmentID uuid.UUID) (model.AdjustmentLogs, error) {
+	q := querySelectAdjustmentLogs + " WHERE adjustment_id=? ORDER BY created_at ASC"
+	rows, err := a.db.QueryContext(ctx, q, adjustmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.AdjustmentLogs, 0)
+	for rows.Next() {
+		r := model.AdjustmentLog{}
+		err = rows.Scan(&r.ID, &r.AdjustmentID, &r.Action, &r.Note, &r.CreatedBy, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (a adjustmentRepository) fetchContext(ctx context.Context, query string, args ...interface{}) (model.Adjustments, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Adjustments, 0)
+	for rows.Next() {
+		r := model.Adjustment{}
+		err = rows.Scan(&r.ID, &r.UserID, &r.Type, &r.Amount, &r.Reason, &r.Reference, &r.Status, &r.ReviewedBy, &r.ReviewedAt, &r.ReviewNote, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
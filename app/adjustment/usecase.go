@@ -0,0 +1,16 @@
+package adjustment
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/adjustment/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the adjustment's usecase contract
+type Usecase interface {
+	Propose(context.Context, model.Adjustment) error
+	GetByID(context.Context, uuid.UUID) (*model.Adjustment, error)
+	FetchByStatus(context.Context, model.AdjustmentStatus) (model.Adjustments, error)
+	Approve(context.Context, uuid.UUID, uuid.UUID, *string) error
+	Reject(context.Context, uuid.UUID, uuid.UUID, *string) error
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/fajardm/ewallet-example/app/adjustment"
+	"github.com/fajardm/ewallet-example/app/adjustment/model"
+	"github.com/fajardm/ewallet-example/app/balance"
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type adjustmentUsecase struct {
+	adjustmentRepository adjustment.Repository
+	balanceRepository    balance.Repository
+	auditor              audit.Auditor
+	contextTimeout       time.Duration
+}
+
+func NewAdjustmentUsecase(adjustmentRepository adjustment.Repository, balanceRepository balance.Repository, auditor audit.Auditor, contextTimeout time.Duration) adjustment.Usecase {
+	return adjustmentUsecase{adjustmentRepository: adjustmentRepository, balanceRepository: balanceRepository, auditor: auditor, contextTimeout: contextTimeout}
+}
+
+func (a adjustmentUsecase) Propose(ctx context.Context, adj model.Adjustment) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	if _, err := a.balanceRepository.GetByUserID(ctx, adj.UserID); err != nil {
+		return err
+	}
+
+	log := adj.NewLog(model.Proposed, adj.CreatedBy, &adj.Reason, adj.CreatedAt)
+	err := a.adjustmentRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = a.adjustmentRepository.TxStore(ctx, tx, adj); err != nil {
+			return err
+		}
+		if err = a.adjustmentRepository.TxStoreLog(ctx, tx, log); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, a.auditor, audit.Event{ActorID: &adj.CreatedBy, Action: audit.AdjustmentPropose, TargetType: "adjustment", TargetID: adj.ID, After: audit.Snapshot(adj)})
+	return nil
+}
+
+func (a adjustmentUsecase) GetByID(ctx context.Context, id uuid.UUID) (*model.Adjustment, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	adj, err := a.adjustmentRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := a.adjustmentRepository.FetchLogsByAdjustmentID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	adj.Logs = logs
+	return adj, nil
+}
+
+func (a adjustmentUsecase) FetchByStatus(ctx context.Context, status model.AdjustmentStatus) (model.Adjustments, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.adjustmentRepository.FetchByStatus(ctx, status)
+}
+
+// Approve posts the adjustment to the user balance. The approver must be a different operator than the proposer.
+func (a adjustmentUsecase) Approve(ctx context.Context, id uuid.UUID, approverID uuid.UUID, note *string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	adj, err := a.adjustmentRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if adj.Status != model.Pending {
+		return errorcode.ErrConflict
+	}
+	if adj.CreatedBy == approverID {
+		return errorcode.ErrForbidden
+	}
+
+	now := time.Now()
+
+	userBalance, err := a.balanceRepository.GetByUserID(ctx, adj.UserID)
+	if err != nil {
+		return err
+	}
+	adjBefore := *adj
+	adj.Review(model.Approved, approverID, note, now)
+	log := adj.NewLog(model.Approve, approverID, note, now)
+
+	// The balance is read again and locked inside the transaction, so movements running meanwhile are not overwritten
+	var balanceBefore _balanceModel.Balance
+	err = a.adjustmentRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		locked, err := a.balanceRepository.TxLockByIDs(ctx, tx, userBalance.ID)
+		if err != nil {
+			return err
+		}
+		if len(locked) == 0 {
+			return errorcode.ErrNotFound
+		}
+		userBalance = &locked[0]
+		balanceBefore = *userBalance
+
+		activity := fmt.Sprintf("adjustment %s amount %f: %s", adj.Reference, adj.Amount, adj.Reason)
+		switch adj.Type {
+		case _balanceModel.Credit:
+			err = userBalance.Credit(adj.Amount, _balanceModel.Adjustment, activity, approverID, now)
+		case _balanceModel.Debit:
+			err = userBalance.Debit(adj.Amount, _balanceModel.Adjustment, activity, approverID, now)
+		default:
+			err = errorcode.ErrBadParamInput
+		}
+		if err != nil {
+			return err
+		}
+		userBalance.UpdatedBy = &approverID
+		userBalance.UpdatedAt = &now
+
+		if err = a.adjustmentRepository.TxReview(ctx, tx, *adj); err != nil {
+			return err
+		}
+		if err = a.balanceRepository.TxUpdate(ctx, tx, *userBalance); err != nil {
+			return err
+		}
+		for _, history := range userBalance.Histories {
+			if err = a.balanceRepository.TxStoreBalanceHistory(ctx, tx, history); err != nil {
+				return err
+			}
+		}
+		if err = a.adjustmentRepository.TxStoreLog(ctx, tx, log); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, a.auditor, audit.Event{
+		ActorID:    &approverID,
+		Action:     audit.AdjustmentApprove,
+		TargetType: "adjustment",
+		TargetID:   adj.ID,
+		Before:     audit.Snapshot(map[string]interface{}{"adjustment": adjBefore, "balance": balanceBefore}),
+		After:      audit.Snapshot(map[string]interface{}{"adjustment": adj, "balance": userBalance}),
+	})
+	return nil
+}
+
+// Reject closes the adjustment without touching the user balance
+func (a adjustmentUsecase) Reject(ctx context.Context, id uuid.UUID, reviewerID uuid.UUID, note *string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	adj, err := a.adjustmentRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if adj.Status != model.Pending {
+		return errorcode.ErrConflict
+	}
+
+	now := time.Now()
+	before := *adj
+	adj.Review(model.Rejected, reviewerID, note, now)
+	log := adj.NewLog(model.Reject, reviewerID, note, now)
+
+	err = a.adjustmentRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = a.adjustmentRepository.TxReview(ctx, tx, *adj); err != nil {
+			return err
+		}
+		if err = a.adjustmentRepository.TxStoreLog(ctx, tx, log); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, a.auditor, audit.Event{ActorID: &reviewerID, Action: audit.AdjustmentReject, TargetType: "adjustment", TargetID: adj.ID, Before: audit.Snapshot(before), After: audit.Snapshot(adj)})
+	return nil
+}
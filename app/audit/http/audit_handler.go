@@ -0,0 +1,52 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/audit"
+	"github.com/fajardm/ewallet-example/app/audit/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	"net/http"
+)
+
+type auditHandler struct {
+	auditUsecase audit.Usecase
+}
+
+func NewAuditHandler(app *bootstrap.Bootstrap, auditUsecase audit.Usecase) {
+	handler := auditHandler{auditUsecase: auditUsecase}
+	app.Admin.Get("/audit", middleware.Require(_roleModel.AuditRead), handler.Search)
+}
+
+// Search finds events by actor_id, target_type, target_id, action, from and to (RFC 3339), page and per_page query
+// string
+func (a auditHandler) Search(ctx *fiber.Ctx) {
+	input := model.FilterInput{
+		ActorID:    ctx.Query("actor_id"),
+		TargetType: ctx.Query("target_type"),
+		TargetID:   ctx.Query("target_id"),
+		Action:     ctx.Query("action"),
+		From:       ctx.Query("from"),
+		To:         ctx.Query("to"),
+		Page:       ctx.Query("page"),
+		PerPage:    ctx.Query("per_page"),
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	filter, err := input.NewFilter()
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	data, err := a.auditUsecase.Search(ctx.Context(), *filter)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
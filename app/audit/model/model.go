@@ -0,0 +1,102 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/validator"
+	uuid "github.com/satori/go.uuid"
+	"strconv"
+	"time"
+)
+
+// FilterInput is query string of admin audit query, every field is optional. From and To are RFC 3339 timestamps
+type FilterInput struct {
+	ActorID    string `validate:"omitempty,uuid"`
+	TargetType string `validate:"max=32"`
+	TargetID   string `validate:"omitempty,uuid"`
+	Action     string `validate:"max=64"`
+	From       string `validate:"omitempty,max=64"`
+	To         string `validate:"omitempty,max=64"`
+	Page       string `validate:"omitempty,numeric"`
+	PerPage    string `validate:"omitempty,numeric"`
+}
+
+func (f FilterInput) Validate() error {
+	return validator.Validate().Struct(f)
+}
+
+// NewFilter parses the input, 50 events per page unless told otherwise
+func (f FilterInput) NewFilter() (*Filter, error) {
+	res := &Filter{TargetType: f.TargetType, Action: f.Action, Page: 1, PerPage: 50}
+	if f.ActorID != "" {
+		id, err := uuid.FromString(f.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		res.ActorID = &id
+	}
+	if f.TargetID != "" {
+		id, err := uuid.FromString(f.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		res.TargetID = &id
+	}
+	if f.From != "" {
+		from, err := time.Parse(time.RFC3339, f.From)
+		if err != nil {
+			return nil, err
+		}
+		res.From = &from
+	}
+	if f.To != "" {
+		to, err := time.Parse(time.RFC3339, f.To)
+		if err != nil {
+			return nil, err
+		}
+		res.To = &to
+	}
+	if f.Page != "" {
+		page, err := strconv.Atoi(f.Page)
+		if err != nil {
+			return nil, err
+		}
+		if page > 1 {
+			res.Page = page
+		}
+	}
+	if f.PerPage != "" {
+		perPage, err := strconv.Atoi(f.PerPage)
+		if err != nil {
+			return nil, err
+		}
+		if perPage > 0 && perPage <= 200 {
+			res.PerPage = perPage
+		}
+	}
+	return res, nil
+}
+
+// Filter is criteria of admin audit query, events are returned newest first. To is exclusive
+type Filter struct {
+	ActorID    *uuid.UUID
+	TargetType string
+	TargetID   *uuid.UUID
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PerPage    int
+}
+
+// Offset is number of events skipped before the page
+func (f Filter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
+
+// Result is one page of events found
+type Result struct {
+	Events  audit.Events `json:"events"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+	Total   int          `json:"total"`
+}
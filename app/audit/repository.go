@@ -0,0 +1,15 @@
+package audit
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/audit/model"
+	"github.com/fajardm/ewallet-example/audit"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Repository represent the audit's repository contract, events are never updated nor deleted
+type Repository interface {
+	Store(context.Context, audit.Event) error
+	Search(context.Context, model.Filter) (audit.Events, int, error)
+	FetchByUserID(context.Context, uuid.UUID) (audit.Events, error)
+}
@@ -0,0 +1,143 @@
+package mysql
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/audit"
+	"github.com/fajardm/ewallet-example/app/audit/model"
+	_audit "github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/database"
+	uuid "github.com/satori/go.uuid"
+	"strings"
+)
+
+const (
+	// Table audit_events
+	querySelectAuditEvent = `
+		SELECT 
+			id,
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			before_snapshot,
+			after_snapshot,
+			ip,
+			user_agent,
+			request_id,
+			created_at
+		FROM audit_events
+	`
+	queryCountAuditEvent = `
+		SELECT COUNT(*) FROM audit_events
+	`
+	queryInsertAuditEvent = `
+		INSERT INTO audit_events (
+			id,
+			actor_id,
+			action,
+			target_type,
+			target_id,
+			before_snapshot,
+			after_snapshot,
+			ip,
+			user_agent,
+			request_id,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+)
+
+type auditRepository struct {
+	db *database.MySQL
+}
+
+func NewAuditRepository(conn *database.MySQL) audit.Repository {
+	return &auditRepository{db: conn}
+}
+
+func (a auditRepository) Store(ctx context.Context, e _audit.Event) (err error) {
+	_, err = a.db.ExecContext(ctx, queryInsertAuditEvent, e.ID, e.ActorID, e.Action, e.TargetType, e.TargetID, snapshot(e.Before), snapshot(e.After), e.IP, e.UserAgent, e.RequestID, e.CreatedAt)
+	return
+}
+
+// Search returns a page of events matching the filter, newest first, and the total of matching events
+func (a auditRepository) Search(ctx context.Context, filter model.Filter) (_audit.Events, int, error) {
+	where := make([]string, 0)
+	args := make([]interface{}, 0)
+	if filter.ActorID != nil {
+		where = append(where, "actor_id=?")
+		args = append(args, *filter.ActorID)
+	}
+	if filter.TargetType != "" {
+		where = append(where, "target_type=?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		where = append(where, "target_id=?")
+		args = append(args, *filter.TargetID)
+	}
+	if filter.Action != "" {
+		where = append(where, "action=?")
+		args = append(args, filter.Action)
+	}
+	if filter.From != nil {
+		where = append(where, "created_at>=?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		where = append(where, "created_at<?")
+		args = append(args, *filter.To)
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := a.db.QueryRowContext(ctx, queryCountAuditEvent+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	q := querySelectAuditEvent + cond + " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	list, err := a.fetchContext(ctx, q, append(args, filter.PerPage, filter.Offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// FetchByUserID returns events the user did or was target of, oldest first
+func (a auditRepository) FetchByUserID(ctx context.Context, userID uuid.UUID) (_audit.Events, error) {
+	q := querySelectAuditEvent + " WHERE actor_id=? OR target_id=? ORDER BY created_at, id"
+	return a.fetchContext(ctx, q, userID, userID)
+}
+
+func (a auditRepository) fetchContext(ctx context.Context, query string, args ...interface{}) (_audit.Events, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(_audit.Events, 0)
+	for rows.Next() {
+		r := _audit.Event{}
+		var before, after []byte
+		err = rows.Scan(&r.ID, &r.ActorID, &r.Action, &r.TargetType, &r.TargetID, &before, &after, &r.IP, &r.UserAgent, &r.RequestID, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Before = before
+		r.After = after
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+// snapshot stores empty snapshot as NULL
+func snapshot(s []byte) interface{} {
+	if len(s) == 0 {
+		return nil
+	}
+	return string(s)
+}
@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/audit/model"
+	"github.com/fajardm/ewallet-example/audit"
+)
+
+// Usecase represent the audit's usecase contract, it is the auditor events are recorded through
+type Usecase interface {
+	audit.Auditor
+	Search(context.Context, model.Filter) (*model.Result, error)
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/audit"
+	"github.com/fajardm/ewallet-example/app/audit/model"
+	_audit "github.com/fajardm/ewallet-example/audit"
+	"time"
+)
+
+type auditUsecase struct {
+	auditRepository audit.Repository
+	contextTimeout  time.Duration
+}
+
+func NewAuditUsecase(auditRepository audit.Repository, contextTimeout time.Duration) audit.Usecase {
+	return auditUsecase{auditRepository: auditRepository, contextTimeout: contextTimeout}
+}
+
+// Record stores the event, it is called through audit.Record which fills the request metadata
+func (a auditUsecase) Record(ctx context.Context, event _audit.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.auditRepository.Store(ctx, event)
+}
+
+func (a auditUsecase) Search(ctx context.Context, filter model.Filter) (*model.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	events, total, err := a.auditRepository.Search(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Result{Events: events, Page: filter.Page, PerPage: filter.PerPage, Total: total}, nil
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/auth/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/fajardm/ewallet-example/token"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+type authHandler struct {
+	authUsecase auth.Usecase
+}
+
+func NewAuthHandler(app *bootstrap.Bootstrap, authUsecase auth.Usecase) {
+	handler := authHandler{authUsecase: authUsecase}
+	app.Get("/.well-known/jwks.json", handler.JWKS)
+	api := app.Group("/api")
+	api.Post("/users/token/refresh", handler.Refresh)
+	api.Get("/users/sessions", middleware.Protected(), middleware.CheckSession, handler.FetchSessions)
+	api.Delete("/users/sessions", middleware.Protected(), middleware.CheckSession, handler.RevokeOtherSessions)
+	api.Delete("/users/sessions/:id", middleware.Protected(), middleware.CheckSession, handler.RevokeSession)
+}
+
+func (a authHandler) Refresh(ctx *fiber.Ctx) {
+	input := new(model.RefreshInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	token, err := a.authUsecase.Refresh(ctx.Context(), input.RefreshToken)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	ctx.JSON(fiber.Map{"status": "success", "data": token})
+}
+
+// JWKS publishes public signing keys so other services can verify our tokens
+func (a authHandler) JWKS(ctx *fiber.Ctx) {
+	keys, err := token.Keys()
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": errorcode.ErrInternalServerError.Error()})
+		return
+	}
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	ctx.JSON(keys.JWKS(time.Now()))
+}
+
+func (a authHandler) FetchSessions(ctx *fiber.Ctx) {
+	userID, sessionID, ok := sessionParams(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := a.authUsecase.FetchSessions(ctx.Context(), *userID, *sessionID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (a authHandler) RevokeSession(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := a.authUsecase.RevokeSession(ctx.Context(), *userID, id); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (a authHandler) RevokeOtherSessions(ctx *fiber.Ctx) {
+	userID, sessionID, ok := sessionParams(ctx)
+	if !ok {
+		return
+	}
+
+	if err := a.authUsecase.RevokeOtherSessions(ctx.Context(), *userID, *sessionID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+// sessionParams resolves user and session of the access token, writes the error response when failed
+func sessionParams(ctx *fiber.Ctx) (*uuid.UUID, *uuid.UUID, bool) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return nil, nil, false
+	}
+	sessionID, err := middleware.GetSessionID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return nil, nil, false
+	}
+	return userID, sessionID, true
+}
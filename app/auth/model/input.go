@@ -0,0 +1,11 @@
+package model
+
+import "github.com/fajardm/ewallet-example/validator"
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (r RefreshInput) Validate() error {
+	return validator.Validate().Struct(r)
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// RefreshToken is opaque token to obtain new access token, only its hash is persisted.
+// Every rotated token keeps the family of the token issued on login, the family is the login session
+type RefreshToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	FamilyID   uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID
+	CreatedAt  time.Time
+}
+
+// NewRefreshToken creates refresh token within the given family and returns its raw value
+func NewRefreshToken(userID, familyID uuid.UUID, now time.Time) (*RefreshToken, string, error) {
+	raw, err := token.NewOpaque()
+	if err != nil {
+		return nil, "", err
+	}
+	return &RefreshToken{
+		ID:        uuid.NewV4(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: token.Hash(raw),
+		ExpiresAt: now.Add(token.RefreshTTL()),
+		CreatedAt: now,
+	}, raw, nil
+}
+
+// Revoked reports whether the token has been rotated or revoked
+func (r RefreshToken) Revoked() bool {
+	return r.RevokedAt != nil
+}
+
+// Expired reports whether the token is past its expiry
+func (r RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Token is pair of access token and refresh token given to the client
+type Token struct {
+	UserID         uuid.UUID `json:"-"`
+	AccessToken    string    `json:"token"`
+	Expires        int64     `json:"expires"`
+	RefreshToken   string    `json:"refresh_token"`
+	RefreshExpires int64     `json:"refresh_expires"`
+}
+
+// Session is a login of the user on a device, its id is the jti claim of every access token
+// and the family of every refresh token issued for the login
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"`
+}
+
+// Active reports whether the session is neither revoked nor expired
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Sessions is list of session model
+type Sessions []Session
+
+// Device describes where the login comes from
+type Device struct {
+	Name      string
+	UserAgent string
+	IP        string
+}
+
+// NewSession creates session of the device that lives as long as its refresh token
+func (d Device) NewSession(userID uuid.UUID, now time.Time) *Session {
+	name := d.Name
+	if name == "" {
+		name = "unknown"
+	}
+	return &Session{
+		ID:         uuid.NewV4(),
+		UserID:     userID,
+		DeviceName: name,
+		UserAgent:  truncate(d.UserAgent, 256),
+		IP:         d.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(token.RefreshTTL()),
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/auth/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the auth's repository contract
+type Repository interface {
+	TxStoreRefreshToken(context.Context, *sql.Tx, model.RefreshToken) error
+	GetRefreshTokenByHash(context.Context, string) (*model.RefreshToken, error)
+	TxRotateRefreshToken(context.Context, *sql.Tx, model.RefreshToken) error
+	TxRevokeRefreshTokenFamily(context.Context, *sql.Tx, uuid.UUID, time.Time) error
+	TxStoreSession(context.Context, *sql.Tx, model.Session) error
+	GetSessionByID(context.Context, uuid.UUID) (*model.Session, error)
+	FetchActiveSessionsByUserID(context.Context, uuid.UUID, time.Time) (model.Sessions, error)
+	FetchSessionsByUserID(context.Context, uuid.UUID) (model.Sessions, error)
+	TxUpdateSession(context.Context, *sql.Tx, model.Session) error
+	UpdateSessionLastSeen(context.Context, uuid.UUID, time.Time) error
+	TxRevokeSession(context.Context, *sql.Tx, uuid.UUID, time.Time) error
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/auth/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table refresh_tokens
+	querySelectRefreshToken = `
+		SELECT 
+			id,
+			user_id,
+			family_id,
+			token_hash,
+			expires_at,
+			revoked_at,
+			replaced_by,
+			created_at
+		FROM refresh_tokens
+	`
+	queryInsertRefreshToken = `
+		INSERT INTO refresh_tokens (
+			id,
+			user_id,
+			family_id,
+			token_hash,
+			expires_at,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+	queryRotateRefreshToken = `
+		UPDATE refresh_tokens SET 
+			revoked_at=?,
+			replaced_by=?
+		WHERE id=? AND revoked_at IS NULL
+	`
+	queryRevokeRefreshTokenFamily = `
+		UPDATE refresh_tokens SET 
+			revoked_at=?
+		WHERE family_id=? AND revoked_at IS NULL
+	`
+
+	// Table user_sessions
+	querySelectSession = `
+		SELECT 
+			id,
+			user_id,
+			device_name,
+			user_agent,
+			ip,
+			created_at,
+			last_seen_at,
+			expires_at,
+			revoked_at
+		FROM user_sessions
+	`
+	queryInsertSession = `
+		INSERT INTO user_sessions (
+			id,
+			user_id,
+			device_name,
+			user_agent,
+			ip,
+			created_at,
+			last_seen_at,
+			expires_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	queryUpdateSession = `
+		UPDATE user_sessions SET 
+			ip=?,
+			last_seen_at=?,
+			expires_at=?
+		WHERE id=?
+	`
+	queryUpdateSessionLastSeen = `
+		UPDATE user_sessions SET 
+			last_seen_at=?
+		WHERE id=?
+	`
+	queryRevokeSession = `
+		UPDATE user_sessions SET 
+			revoked_at=?
+		WHERE id=? AND revoked_at IS NULL
+	`
+)
+
+type authRepository struct {
+	db *database.MySQL
+}
+
+func NewAuthRepository(conn *database.MySQL) auth.Repository {
+	return &authRepository{db: conn}
+}
+
+func (a authRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return a.db.WithTransaction(ctx, fn)
+}
+
+func (a authRepository) TxStoreRefreshToken(ctx context.Context, tx *sql.Tx, rt model.RefreshToken) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertRefreshToken, rt.ID, rt.UserID, rt.FamilyID, rt.TokenHash, rt.ExpiresAt, rt.CreatedAt)
+	return
+}
+
+func (a authRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	q := querySelectRefreshToken + " WHERE token_hash=?"
+	rt := model.RefreshToken{}
+	err := a.db.QueryRowContext(ctx, q, hash).Scan(&rt.ID, &rt.UserID, &rt.FamilyID, &rt.TokenHash, &rt.ExpiresAt, &rt.RevokedAt, &rt.ReplacedBy, &rt.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// TxRotateRefreshToken only revokes token that is still active, so a token can not be rotated twice
+func (a authRepository) TxRotateRefreshToken(ctx context.Context, tx *sql.Tx, rt model.RefreshToken) (err error) {
+	res, err := tx.ExecContext(ctx, queryRotateRefreshToken, rt.RevokedAt, rt.ReplacedBy, rt.ID)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected != 1 {
+		err = errorcode.ErrConflict
+		return
+	}
+	return
+}
+
+func (a authRepository) TxRevokeRefreshTokenFamily(ctx context.Context, tx *sql.Tx, familyID uuid.UUID, at time.Time) (err error) {
+	_, err = tx.ExecContext(ctx, queryRevokeRefreshTokenFamily, at, familyID)
+	return
+}
+
+func (a authRepository) TxStoreSession(ctx context.Context, tx *sql.Tx, s model.Session) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertSession, s.ID, s.UserID, s.DeviceName, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+	return
+}
+
+func (a authRepository) GetSessionByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	q := querySelectSession + " WHERE id=?"
+	list, err := a.fetchSessionsContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+	return nil, errorcode.ErrNotFound
+}
+
+func (a authRepository) FetchActiveSessionsByUserID(ctx context.Context, userID uuid.UUID, now time.Time) (model.Sessions, error) {
+	q := querySelectSession + " WHERE user_id=? AND revoked_at IS NULL AND expires_at>? ORDER BY last_seen_at DESC"
+	return a.fetchSessionsContext(ctx, q, userID, now)
+}
+
+// FetchSessionsByUserID returns every session of the user including revoked and expired ones, oldest first
+func (a authRepository) FetchSessionsByUserID(ctx context.Context, userID uuid.UUID) (model.Sessions, error) {
+	q := querySelectSession + " WHERE user_id=? ORDER BY created_at ASC"
+	return a.fetchSessionsContext(ctx, q, userID)
+}
+
+func (a authRepository) TxUpdateSession(ctx context.Context, tx *sql.Tx, s model.Session) (err error) {
+	_, err = tx.ExecContext(ctx, queryUpdateSession, s.IP, s.LastSeenAt, s.ExpiresAt, s.ID)
+	return
+}
+
+func (a authRepository) UpdateSessionLastSeen(ctx context.Context, id uuid.UUID, at time.Time) (err error) {
+	_, err = a.db.ExecContext(ctx, queryUpdateSessionLastSeen, at, id)
+	return
+}
+
+func (a authRepository) TxRevokeSession(ctx context.Context, tx *sql.Tx, id uuid.UUID, at time.Time) (err error) {
+	_, err = tx.ExecContext(ctx, queryRevokeSession, at, id)
+	return
+}
+
+func (a authRepository) fetchSessionsContext(ctx context.Context, query string, args ...interface{}) (model.Sessions, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Sessions, 0)
+	for rows.Next() {
+		s := model.Session{}
+		err = rows.Scan(&s.ID, &s.UserID, &s.DeviceName, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/auth/model"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the auth's usecase contract
+type Usecase interface {
+	IssueToken(context.Context, _userModel.User, model.Device) (*model.Token, error)
+	Refresh(context.Context, string) (*model.Token, error)
+	CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	FetchSessions(ctx context.Context, userID, currentID uuid.UUID) (model.Sessions, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID, currentID uuid.UUID) error
+	RevokeByUserID(context.Context, uuid.UUID) error
+}
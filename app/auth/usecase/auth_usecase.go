@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/auth/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/role"
+	"github.com/fajardm/ewallet-example/app/user"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// sessionCacheTTL is how long a checked session is trusted without hitting database,
+// it also bounds how often last seen is written
+const sessionCacheTTL = time.Minute
+
+type authUsecase struct {
+	authRepository auth.Repository
+	userRepository user.Repository
+	roleRepository role.Repository
+	contextTimeout time.Duration
+}
+
+func NewAuthUsecase(authRepository auth.Repository, userRepository user.Repository, roleRepository role.Repository, contextTimeout time.Duration) auth.Usecase {
+	return authUsecase{authRepository: authRepository, userRepository: userRepository, roleRepository: roleRepository, contextTimeout: contextTimeout}
+}
+
+// IssueToken starts new session of the device with its own refresh token family, called after successful login
+func (a authUsecase) IssueToken(ctx context.Context, u _userModel.User, device model.Device) (*model.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	s := device.NewSession(u.ID, now)
+	rt, raw, err := model.NewRefreshToken(u.ID, s.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	err = a.authRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = a.authRepository.TxStoreSession(ctx, tx, *s); err != nil {
+			return err
+		}
+		if err = a.authRepository.TxStoreRefreshToken(ctx, tx, *rt); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a.token(ctx, u, *rt, raw, now)
+}
+
+// Refresh exchanges refresh token with new token pair. The used refresh token is rotated, presenting it
+// again is treated as token theft and revokes the whole session
+func (a authUsecase) Refresh(ctx context.Context, raw string) (*model.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	current, err := a.authRepository.GetRefreshTokenByHash(ctx, token.Hash(raw))
+	if err == errorcode.ErrNotFound {
+		return nil, errorcode.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if current.Revoked() {
+		if err := a.revokeSession(ctx, current.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, errorcode.ErrUnauthorized
+	}
+	if current.Expired(now) {
+		return nil, errorcode.ErrUnauthorized
+	}
+
+	s, err := a.authRepository.GetSessionByID(ctx, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Active(now) {
+		return nil, errorcode.ErrUnauthorized
+	}
+
+	u, err := a.userRepository.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status == base.Closed {
+		if err := a.revokeSession(ctx, s.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, errorcode.ErrAccountClosed
+	}
+
+	next, nextRaw, err := model.NewRefreshToken(current.UserID, current.FamilyID, now)
+	if err != nil {
+		return nil, err
+	}
+	current.RevokedAt = &now
+	current.ReplacedBy = &next.ID
+	s.LastSeenAt = now
+	s.ExpiresAt = next.ExpiresAt
+
+	err = a.authRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = a.authRepository.TxRotateRefreshToken(ctx, tx, *current); err != nil {
+			return err
+		}
+		if err = a.authRepository.TxStoreRefreshToken(ctx, tx, *next); err != nil {
+			return err
+		}
+		if err = a.authRepository.TxUpdateSession(ctx, tx, *s); err != nil {
+			return err
+		}
+		return
+	})
+	if err == errorcode.ErrConflict {
+		// Other request rotated the same token first
+		if err := a.revokeSession(ctx, s.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, errorcode.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.token(ctx, *u, *next, nextRaw, now)
+}
+
+// CheckSession returns errorcode.ErrUnauthorized when the session is not an active session of the user.
+// Checked session is cached in session store, so revocation must clear the cache
+func (a authUsecase) CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	key := sessionKey(sessionID)
+	cached, err := session.Session().Get(ctx, key)
+	if err == nil {
+		if !uuid.Equal(uuid.FromBytesOrNil(cached), userID) {
+			return errorcode.ErrUnauthorized
+		}
+		return nil
+	}
+	if err != errorcode.ErrNotFound {
+		return err
+	}
+
+	now := time.Now()
+	s, err := a.authRepository.GetSessionByID(ctx, sessionID)
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrUnauthorized
+	}
+	if err != nil {
+		return err
+	}
+	if !uuid.Equal(s.UserID, userID) || !s.Active(now) {
+		return errorcode.ErrUnauthorized
+	}
+	if err := a.authRepository.UpdateSessionLastSeen(ctx, sessionID, now); err != nil {
+		return err
+	}
+	return session.Session().Set(ctx, key, userID.Bytes(), sessionCacheTTL)
+}
+
+// FetchSessions returns active sessions of the user, flagging the one making the request
+func (a authUsecase) FetchSessions(ctx context.Context, userID, currentID uuid.UUID) (model.Sessions, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	list, err := a.authRepository.FetchActiveSessionsByUserID(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Current = uuid.Equal(list[i].ID, currentID)
+	}
+	return list, nil
+}
+
+// RevokeSession logs the user out of one of its sessions
+func (a authUsecase) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	s, err := a.authRepository.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !uuid.Equal(s.UserID, userID) || !s.Active(now) {
+		return errorcode.ErrNotFound
+	}
+	return a.revokeSession(ctx, s.ID, now)
+}
+
+// RevokeOtherSessions logs the user out everywhere except the current session
+func (a authUsecase) RevokeOtherSessions(ctx context.Context, userID, currentID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.revokeSessions(ctx, userID, &currentID)
+}
+
+// RevokeByUserID logs the user out of every session
+func (a authUsecase) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, a.contextTimeout)
+	defer cancel()
+
+	return a.revokeSessions(ctx, userID, nil)
+}
+
+func (a authUsecase) revokeSessions(ctx context.Context, userID uuid.UUID, except *uuid.UUID) error {
+	now := time.Now()
+	list, err := a.authRepository.FetchActiveSessionsByUserID(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if except != nil && uuid.Equal(s.ID, *except) {
+			continue
+		}
+		if err := a.revokeSession(ctx, s.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeSession revokes the session along with its refresh token family
+func (a authUsecase) revokeSession(ctx context.Context, sessionID uuid.UUID, now time.Time) error {
+	err := a.authRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = a.authRepository.TxRevokeSession(ctx, tx, sessionID, now); err != nil {
+			return err
+		}
+		if err = a.authRepository.TxRevokeRefreshTokenFamily(ctx, tx, sessionID, now); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return err
+	}
+	return session.Session().Delete(ctx, sessionKey(sessionID))
+}
+
+// token signs access token carrying the roles and permissions of the user, so changed roles take effect on next refresh
+func (a authUsecase) token(ctx context.Context, u _userModel.User, rt model.RefreshToken, raw string, now time.Time) (*model.Token, error) {
+	grant, err := a.roleRepository.GetGrantByUserID(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	exp := now.Add(token.AccessTTL()).Unix()
+	t, err := token.Sign(jwt.MapClaims{
+		"jti":         rt.FamilyID.String(),
+		"user_id":     u.ID.String(),
+		"username":    u.Username,
+		"roles":       grant.Roles,
+		"permissions": grant.Permissions,
+		"iat":         now.Unix(),
+		"exp":         exp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.Token{
+		UserID:         u.ID,
+		AccessToken:    t,
+		Expires:        exp,
+		RefreshToken:   raw,
+		RefreshExpires: rt.ExpiresAt.Unix(),
+	}, nil
+}
+
+func sessionKey(sessionID uuid.UUID) string {
+	return "session:" + sessionID.String()
+}
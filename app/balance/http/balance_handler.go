@@ -2,9 +2,12 @@ package http
 
 import (
 	"github.com/fajardm/ewallet-example/app/balance"
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
 	"github.com/fajardm/ewallet-example/bootstrap"
 	"github.com/fajardm/ewallet-example/errorcode"
 	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/fajardm/ewallet-example/validator"
 	"github.com/gofiber/fiber"
 	uuid "github.com/satori/go.uuid"
 	"net/http"
@@ -19,8 +22,33 @@ func NewBalanceHandler(app *bootstrap.Bootstrap, balanceUsecase balance.Usecase)
 	api := app.Group("/api")
 	api.Get("/balances", middleware.Protected(), middleware.CheckSession, handler.GetBalance)
 	api.Get("/balances/histories", middleware.Protected(), middleware.CheckSession, handler.GetBalanceHistories)
-	api.Post("/balances/transfer", middleware.Protected(), middleware.CheckSession, handler.TransferBalance)
+	api.Post("/balances/transfer", middleware.Protected(), middleware.CheckSession, middleware.Require(_roleModel.BalancesTransfer), middleware.PhoneVerified, middleware.StepUp, handler.TransferBalance)
 	api.Post("/balances/topup", middleware.Protected(), middleware.CheckSession, handler.TopUp)
+	api.Get("/balances/net-worth", middleware.Protected(), middleware.CheckSession, handler.GetNetWorth)
+	api.Post("/balances/pockets", middleware.Protected(), middleware.CheckSession, handler.CreatePocket)
+	api.Get("/balances/pockets", middleware.Protected(), middleware.CheckSession, handler.FetchPockets)
+	api.Get("/balances/pockets/:id", middleware.Protected(), middleware.CheckSession, handler.GetPocket)
+	api.Put("/balances/pockets/:id", middleware.Protected(), middleware.CheckSession, handler.UpdatePocket)
+	api.Delete("/balances/pockets/:id", middleware.Protected(), middleware.CheckSession, handler.DeletePocket)
+	api.Get("/balances/pockets/:id/histories", middleware.Protected(), middleware.CheckSession, handler.GetPocketHistories)
+	api.Post("/balances/pockets/:id/deposit", middleware.Protected(), middleware.CheckSession, handler.MoveToPocket)
+	api.Post("/balances/pockets/:id/withdraw", middleware.Protected(), middleware.CheckSession, handler.MoveFromPocket)
+	api.Post("/balances/shared", middleware.Protected(), middleware.CheckSession, handler.CreateSharedWallet)
+	api.Get("/balances/shared", middleware.Protected(), middleware.CheckSession, handler.FetchSharedWallets)
+	api.Get("/balances/shared/invitations", middleware.Protected(), middleware.CheckSession, handler.FetchInvitations)
+	api.Get("/balances/shared/:id", middleware.Protected(), middleware.CheckSession, handler.GetSharedWallet)
+	api.Delete("/balances/shared/:id", middleware.Protected(), middleware.CheckSession, handler.DeleteSharedWallet)
+	api.Get("/balances/shared/:id/histories", middleware.Protected(), middleware.CheckSession, handler.GetSharedHistories)
+	api.Get("/balances/shared/:id/spendings", middleware.Protected(), middleware.CheckSession, handler.GetMemberSpendings)
+	api.Get("/balances/shared/:id/members", middleware.Protected(), middleware.CheckSession, handler.FetchMembers)
+	api.Post("/balances/shared/:id/members", middleware.Protected(), middleware.CheckSession, handler.AddMember)
+	api.Post("/balances/shared/:id/members/accept", middleware.Protected(), middleware.CheckSession, handler.AcceptMember)
+	api.Put("/balances/shared/:id/members/:user_id", middleware.Protected(), middleware.CheckSession, handler.UpdateMember)
+	api.Delete("/balances/shared/:id/members/:user_id", middleware.Protected(), middleware.CheckSession, handler.RemoveMember)
+	api.Post("/balances/shared/:id/transfer", middleware.Protected(), middleware.CheckSession, middleware.Require(_roleModel.BalancesTransfer), middleware.PhoneVerified, middleware.StepUp, handler.TransferFromShared)
+	api.Post("/balances/shared/:id/contribute", middleware.Protected(), middleware.CheckSession, middleware.Require(_roleModel.BalancesTransfer), middleware.PhoneVerified, middleware.StepUp, handler.ContributeToShared)
+	api.Post("/balances/shared/:id/withdraw", middleware.Protected(), middleware.CheckSession, middleware.StepUp, handler.WithdrawFromShared)
+	app.Admin.Put("/balances/:user_id/overdraft", middleware.Require(_roleModel.BalancesManage), handler.SetOverdraft)
 }
 
 func (b balanceHandler) GetBalance(ctx *fiber.Ctx) {
@@ -43,7 +71,13 @@ func (b balanceHandler) GetBalanceHistories(ctx *fiber.Ctx) {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
-	data, err := b.balanceUsecase.GetBalanceHistoriesByUserID(ctx.Context(), *userID)
+	categories, err := model.BalanceHistoryCategoriesFromString(ctx.Query("category"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	filter := model.BalanceHistoryFilter{Categories: categories}
+	data, err := b.balanceUsecase.GetBalanceHistoriesByUserID(ctx.Context(), *userID, filter)
 	if err != nil {
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
@@ -101,3 +135,37 @@ func (b balanceHandler) TopUp(ctx *fiber.Ctx) {
 
 	ctx.JSON(fiber.Map{"status": "success", "data": true})
 }
+
+func (b balanceHandler) SetOverdraft(ctx *fiber.Ctx) {
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	userID, err := uuid.FromString(ctx.Params("user_id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	// Binds input
+	input := new(model.Overdraft)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	// Validate input
+	if err := validator.Validate().Struct(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	data, err := b.balanceUsecase.SetOverdraft(ctx.Context(), userID, *input, *actorID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
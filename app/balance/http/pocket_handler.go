@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+func (b balanceHandler) GetNetWorth(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	data, err := b.balanceUsecase.GetNetWorth(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) CreatePocket(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.PocketInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	pocket := input.NewPocket(*userID)
+	if err := b.balanceUsecase.CreatePocket(ctx.Context(), *pocket); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": pocket})
+}
+
+func (b balanceHandler) FetchPockets(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	data, err := b.balanceUsecase.FetchPocketsByUserID(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) GetPocket(ctx *fiber.Ctx) {
+	userID, pocketID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	data, err := b.balanceUsecase.GetPocket(ctx.Context(), userID, pocketID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) UpdatePocket(ctx *fiber.Ctx) {
+	userID, pocketID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+
+	input := new(model.PocketInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	pocket, err := b.balanceUsecase.GetPocket(ctx.Context(), userID, pocketID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	input.Apply(pocket, userID)
+	if err := b.balanceUsecase.UpdatePocket(ctx.Context(), userID, *pocket); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": pocket})
+}
+
+func (b balanceHandler) DeletePocket(ctx *fiber.Ctx) {
+	userID, pocketID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	if err := b.balanceUsecase.DeletePocket(ctx.Context(), userID, pocketID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (b balanceHandler) GetPocketHistories(ctx *fiber.Ctx) {
+	userID, pocketID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	categories, err := model.BalanceHistoryCategoriesFromString(ctx.Query("category"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	filter := model.BalanceHistoryFilter{Categories: categories}
+	data, err := b.balanceUsecase.GetPocketHistories(ctx.Context(), userID, pocketID, filter)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) MoveToPocket(ctx *fiber.Ctx) {
+	b.moveAmount(ctx, b.balanceUsecase.MoveToPocket)
+}
+
+func (b balanceHandler) MoveFromPocket(ctx *fiber.Ctx) {
+	b.moveAmount(ctx, b.balanceUsecase.MoveFromPocket)
+}
+
+func (b balanceHandler) moveAmount(ctx *fiber.Ctx, fn func(context.Context, uuid.UUID, uuid.UUID, float64) error) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+
+	input := new(model.AmountInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := fn(ctx.Context(), userID, walletID, input.Amount); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+// walletParams resolves the logged in user and the wallet id, writes bad request response on failure
+func walletParams(ctx *fiber.Ctx) (userID uuid.UUID, walletID uuid.UUID, ok bool) {
+	id, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	walletID, err = uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	return *id, walletID, true
+}
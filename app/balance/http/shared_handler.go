@@ -0,0 +1,275 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"time"
+)
+
+func (b balanceHandler) CreateSharedWallet(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.SharedWalletInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	wallet, owner := input.NewSharedWallet(*userID)
+	if err := b.balanceUsecase.CreateSharedWallet(ctx.Context(), *wallet, *owner); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": wallet})
+}
+
+func (b balanceHandler) FetchSharedWallets(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	data, err := b.balanceUsecase.FetchSharedWallets(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) FetchInvitations(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	data, err := b.balanceUsecase.FetchInvitations(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) GetSharedWallet(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	data, err := b.balanceUsecase.GetSharedWallet(ctx.Context(), userID, walletID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) DeleteSharedWallet(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	if err := b.balanceUsecase.DeleteSharedWallet(ctx.Context(), userID, walletID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (b balanceHandler) GetSharedHistories(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	categories, err := model.BalanceHistoryCategoriesFromString(ctx.Query("category"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	filter := model.BalanceHistoryFilter{Categories: categories}
+	data, err := b.balanceUsecase.GetSharedHistories(ctx.Context(), userID, walletID, filter)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) FetchMembers(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	data, err := b.balanceUsecase.FetchMembers(ctx.Context(), userID, walletID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (b balanceHandler) AddMember(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+
+	input := new(model.MemberInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	member := input.NewMember(walletID, userID)
+	if err := b.balanceUsecase.AddMember(ctx.Context(), userID, *member); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": member})
+}
+
+func (b balanceHandler) AcceptMember(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	if err := b.balanceUsecase.AcceptMember(ctx.Context(), userID, walletID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (b balanceHandler) UpdateMember(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	memberUserID, err := uuid.FromString(ctx.Params("user_id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.MemberInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input.UserID = memberUserID
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	member, err := b.balanceUsecase.GetMember(ctx.Context(), userID, walletID, memberUserID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	input.Apply(member, userID)
+	if err := b.balanceUsecase.UpdateMember(ctx.Context(), userID, *member); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": member})
+}
+
+func (b balanceHandler) RemoveMember(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+	memberUserID, err := uuid.FromString(ctx.Params("user_id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := b.balanceUsecase.RemoveMember(ctx.Context(), userID, walletID, memberUserID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+// GetMemberSpendings returns spending per member, defaults to the current month
+func (b balanceHandler) GetMemberSpendings(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	from := model.SpendingPeriodStart(now)
+	until := from.AddDate(0, 1, 0)
+	if s := ctx.Query("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+			return
+		}
+		from = t
+	}
+	if s := ctx.Query("until"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+			return
+		}
+		until = t
+	}
+
+	data, err := b.balanceUsecase.GetMemberSpendings(ctx.Context(), userID, walletID, from, until)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": fiber.Map{"from": from, "until": until, "members": data}})
+}
+
+func (b balanceHandler) TransferFromShared(ctx *fiber.Ctx) {
+	userID, walletID, ok := walletParams(ctx)
+	if !ok {
+		return
+	}
+
+	input := new(model.SharedTransferInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := b.balanceUsecase.TransferFromShared(ctx.Context(), userID, walletID, input.ToUserID, input.Amount); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (b balanceHandler) ContributeToShared(ctx *fiber.Ctx) {
+	b.moveAmount(ctx, b.balanceUsecase.ContributeToShared)
+}
+
+func (b balanceHandler) WithdrawFromShared(ctx *fiber.Ctx) {
+	b.moveAmount(ctx, b.balanceUsecase.WithdrawFromShared)
+}
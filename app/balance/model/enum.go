@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"github.com/pkg/errors"
+	"strings"
 )
 
 // ErrInvalidUserBalanceHistoryType represent error when invalid UserBalanceHistoryType
@@ -38,14 +39,24 @@ func (u UserBalanceHistoryType) MarshalText() ([]byte, error) {
 	return []byte(u.String()), nil
 }
 
+// UnmarshalText parses UserBalanceHistoryType from its string representation
+func (u *UserBalanceHistoryType) UnmarshalText(text []byte) error {
+	t, err := UserBalanceHistoryTypeFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*u = t
+	return nil
+}
+
 // String returns the string representation of UserBalanceHistoryType
 func (u UserBalanceHistoryType) String() string {
 	var s string
 	switch u {
 	case Credit:
-		s = "debit"
-	case Debit:
 		s = "credit"
+	case Debit:
+		s = "debit"
 	}
 	return s
 }
@@ -55,7 +66,7 @@ func (u UserBalanceHistoryType) Value() (driver.Value, error) {
 	return u.String(), nil
 }
 
-//// Scan transforms MySQL enum column value for type column to UserBalanceHistoryType
+// Scan transforms MySQL enum column value for type column to UserBalanceHistoryType
 func (u *UserBalanceHistoryType) Scan(value interface{}) error {
 	b, ok := value.([]uint8)
 	if !ok {
@@ -69,3 +80,343 @@ func (u *UserBalanceHistoryType) Scan(value interface{}) error {
 	*u = st
 	return nil
 }
+
+// ErrInvalidBalanceHistoryCategory represent error when invalid BalanceHistoryCategory
+var ErrInvalidBalanceHistoryCategory = errors.New("InvalidBalanceHistoryCategory")
+
+type BalanceHistoryCategory int
+
+const (
+	// Opening represent opening balance category enum
+	Opening BalanceHistoryCategory = 1 + iota
+	// TopUp represent top up category enum
+	TopUp
+	// TransferIn represent incoming transfer category enum
+	TransferIn
+	// TransferOut represent outgoing transfer category enum
+	TransferOut
+	// Payment represent payment category enum
+	Payment
+	// Fee represent fee category enum
+	Fee
+	// Refund represent refund category enum
+	Refund
+	// Cashback represent cashback category enum
+	Cashback
+	// Adjustment represent adjustment category enum
+	Adjustment
+	// Withdrawal represent withdrawal category enum
+	Withdrawal
+	// InternalTransfer represent move between wallets of the same user category enum
+	InternalTransfer
+)
+
+var balanceHistoryCategories = map[BalanceHistoryCategory]string{
+	Opening:          "opening",
+	TopUp:            "topup",
+	TransferIn:       "transfer_in",
+	TransferOut:      "transfer_out",
+	Payment:          "payment",
+	Fee:              "fee",
+	Refund:           "refund",
+	Cashback:         "cashback",
+	Adjustment:       "adjustment",
+	Withdrawal:       "withdrawal",
+	InternalTransfer: "internal_transfer",
+}
+
+// BalanceHistoryCategoryFromString will converts a string to a BalanceHistoryCategory, will return BalanceHistoryCategory if string is
+// valid representation of BalanceHistoryCategory, or error otherwise
+func BalanceHistoryCategoryFromString(s string) (BalanceHistoryCategory, error) {
+	for c, name := range balanceHistoryCategories {
+		if name == s {
+			return c, nil
+		}
+	}
+	return 0, errors.WithMessagef(ErrInvalidBalanceHistoryCategory, "invalid value: %s", s)
+}
+
+// MarshalText is the custom marshalling for BalanceHistoryCategory. With this when marshalling to json
+// BalanceHistoryCategory will be shown as its string representation instead of int
+func (c BalanceHistoryCategory) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses BalanceHistoryCategory from its string representation
+func (c *BalanceHistoryCategory) UnmarshalText(text []byte) error {
+	category, err := BalanceHistoryCategoryFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*c = category
+	return nil
+}
+
+// String returns the string representation of BalanceHistoryCategory
+func (c BalanceHistoryCategory) String() string {
+	return balanceHistoryCategories[c]
+}
+
+// Value transforms BalanceHistoryCategory to its value for its column in database (MySQL)
+func (c BalanceHistoryCategory) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+// Scan transforms MySQL enum column value for category column to BalanceHistoryCategory
+func (c *BalanceHistoryCategory) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return c.UnmarshalText(b)
+}
+
+// BalanceHistoryCategories is list of BalanceHistoryCategory
+type BalanceHistoryCategories []BalanceHistoryCategory
+
+// BalanceHistoryCategoriesFromString parses comma separated categories, e.g. "topup,fee"
+func BalanceHistoryCategoriesFromString(s string) (BalanceHistoryCategories, error) {
+	res := make(BalanceHistoryCategories, 0)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		c, err := BalanceHistoryCategoryFromString(v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// ErrInvalidBalanceKind represent error when invalid BalanceKind
+var ErrInvalidBalanceKind = errors.New("InvalidBalanceKind")
+
+type BalanceKind int
+
+const (
+	// Main represent the main wallet of a user
+	Main BalanceKind = 1 + iota
+	// Pocket represent a named savings pocket of a user
+	Pocket
+	// Shared represent a wallet owned by a user and shared with other members
+	Shared
+)
+
+// BalanceKindFromString will converts a string to a BalanceKind, will return BalanceKind if string is
+// valid representation of BalanceKind, or error otherwise
+func BalanceKindFromString(s string) (res BalanceKind, err error) {
+	switch s {
+	case "main":
+		res = Main
+	case "pocket":
+		res = Pocket
+	case "shared":
+		res = Shared
+	default:
+		err = errors.WithMessagef(ErrInvalidBalanceKind, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for BalanceKind. With this when marshalling to json
+// BalanceKind will be shown as its string representation instead of int
+func (k BalanceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// String returns the string representation of BalanceKind
+func (k BalanceKind) String() string {
+	var s string
+	switch k {
+	case Main:
+		s = "main"
+	case Pocket:
+		s = "pocket"
+	case Shared:
+		s = "shared"
+	}
+	return s
+}
+
+// Value transforms BalanceKind to its value for its column in database (MySQL)
+func (k BalanceKind) Value() (driver.Value, error) {
+	return k.String(), nil
+}
+
+// Scan transforms MySQL enum column value for kind column to BalanceKind
+func (k *BalanceKind) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	st, err := BalanceKindFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*k = st
+	return nil
+}
+
+// ErrInvalidMemberRole represent error when invalid MemberRole
+var ErrInvalidMemberRole = errors.New("InvalidMemberRole")
+
+type MemberRole int
+
+const (
+	// Owner represent member who owns the shared wallet
+	Owner MemberRole = 1 + iota
+	// Spender represent member who may spend from the shared wallet within its limit
+	Spender
+	// Viewer represent member who may only see the shared wallet
+	Viewer
+)
+
+// MemberRoleFromString will converts a string to a MemberRole, will return MemberRole if string is
+// valid representation of MemberRole, or error otherwise
+func MemberRoleFromString(s string) (res MemberRole, err error) {
+	switch s {
+	case "owner":
+		res = Owner
+	case "spender":
+		res = Spender
+	case "viewer":
+		res = Viewer
+	default:
+		err = errors.WithMessagef(ErrInvalidMemberRole, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for MemberRole. With this when marshalling to json
+// MemberRole will be shown as its string representation instead of int
+func (r MemberRole) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses MemberRole from its string representation
+func (r *MemberRole) UnmarshalText(text []byte) error {
+	role, err := MemberRoleFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*r = role
+	return nil
+}
+
+// String returns the string representation of MemberRole
+func (r MemberRole) String() string {
+	var s string
+	switch r {
+	case Owner:
+		s = "owner"
+	case Spender:
+		s = "spender"
+	case Viewer:
+		s = "viewer"
+	}
+	return s
+}
+
+// Value transforms MemberRole to its value for its column in database (MySQL)
+func (r MemberRole) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan transforms MySQL enum column value for role column to MemberRole
+func (r *MemberRole) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return r.UnmarshalText(b)
+}
+
+// ErrInvalidMemberOperation represent error when invalid MemberOperation
+var ErrInvalidMemberOperation = errors.New("InvalidMemberOperation")
+
+type MemberOperation int
+
+const (
+	// TransferOperation allows member to transfer from the shared wallet to other users
+	TransferOperation MemberOperation = 1 + iota
+	// WithdrawOperation allows member to move from the shared wallet into its own main balance
+	WithdrawOperation
+)
+
+// MemberOperationFromString will converts a string to a MemberOperation, will return MemberOperation if string is
+// valid representation of MemberOperation, or error otherwise
+func MemberOperationFromString(s string) (res MemberOperation, err error) {
+	switch s {
+	case "transfer":
+		res = TransferOperation
+	case "withdraw":
+		res = WithdrawOperation
+	default:
+		err = errors.WithMessagef(ErrInvalidMemberOperation, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for MemberOperation. With this when marshalling to json
+// MemberOperation will be shown as its string representation instead of int
+func (o MemberOperation) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText parses MemberOperation from its string representation
+func (o *MemberOperation) UnmarshalText(text []byte) error {
+	op, err := MemberOperationFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*o = op
+	return nil
+}
+
+// String returns the string representation of MemberOperation
+func (o MemberOperation) String() string {
+	var s string
+	switch o {
+	case TransferOperation:
+		s = "transfer"
+	case WithdrawOperation:
+		s = "withdraw"
+	}
+	return s
+}
+
+// MemberOperations is list of MemberOperation, stored as comma separated SET column
+type MemberOperations []MemberOperation
+
+// Value transforms MemberOperations to its value for its column in database (MySQL)
+func (o MemberOperations) Value() (driver.Value, error) {
+	s := make([]string, 0, len(o))
+	for _, op := range o {
+		s = append(s, op.String())
+	}
+	return strings.Join(s, ","), nil
+}
+
+// Scan transforms MySQL set column value for allowed_operations column to MemberOperations
+func (o *MemberOperations) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	res := make(MemberOperations, 0)
+	for _, v := range strings.Split(string(b), ",") {
+		if v == "" {
+			continue
+		}
+		op, err := MemberOperationFromString(v)
+		if err != nil {
+			return err
+		}
+		res = append(res, op)
+	}
+	*o = res
+	return nil
+}
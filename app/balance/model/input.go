@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/validator"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type PocketInput struct {
+	Name         string     `json:"name" validate:"required,max=45"`
+	TargetAmount *float64   `json:"target_amount" validate:"omitempty,gt=0"`
+	Deadline     *time.Time `json:"deadline"`
+}
+
+func (i PocketInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+func (i PocketInput) NewPocket(userID uuid.UUID) *Balance {
+	now := time.Now()
+	pocket := &Balance{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: userID,
+			CreatedAt: now,
+		},
+		UserID:       userID,
+		Kind:         Pocket,
+		Balance:      0,
+		Status:       base.Active,
+		TargetAmount: i.TargetAmount,
+		Deadline:     i.Deadline,
+	}
+	pocket.Name = &i.Name
+	pocket.Histories = BalanceHistories{
+		pocket.NewHistory(0, Credit, Opening, "initial balance", userID, now),
+	}
+	return pocket
+}
+
+// Apply copies the input into the existing pocket
+func (i PocketInput) Apply(pocket *Balance, actorID uuid.UUID) {
+	now := time.Now()
+	pocket.Name = &i.Name
+	pocket.TargetAmount = i.TargetAmount
+	pocket.Deadline = i.Deadline
+	pocket.UpdatedBy = &actorID
+	pocket.UpdatedAt = &now
+}
+
+type AmountInput struct {
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+}
+
+func (i AmountInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
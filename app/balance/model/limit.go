@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/errorcode"
+)
+
+// TierLimit caps money of users in a kyc tier, zero means unlimited
+type TierLimit struct {
+	// MaxBalance is the most money the main balance may hold after top up or incoming transfer
+	MaxBalance float64
+	// MaxTransfer is the most money sent in one transfer
+	MaxTransfer float64
+	// DailyTransfer is the most money sent by transfer in a day
+	DailyTransfer float64
+}
+
+// Limits holds transaction limits and features of every kyc tier
+type Limits struct {
+	Tiers map[base.Tier]TierLimit
+	// SharedWalletTier is the lowest tier allowed to create shared wallet
+	SharedWalletTier base.Tier
+}
+
+// For returns limit of the tier, unlimited when not configured
+func (l Limits) For(tier base.Tier) TierLimit {
+	return l.Tiers[tier]
+}
+
+// CheckBalance returns errorcode.ErrLimitExceeded when the balance would hold more than its owner tier allows
+func (l Limits) CheckBalance(b Balance) error {
+	limit := l.For(b.OwnerTier)
+	if limit.MaxBalance > 0 && b.Balance > limit.MaxBalance {
+		return errorcode.ErrLimitExceeded
+	}
+	return nil
+}
+
+// CheckTransfer returns errorcode.ErrLimitExceeded when sending amount on top of already sent today exceeds
+// the sender tier limits
+func (l Limits) CheckTransfer(sender Balance, sentToday, amount float64) error {
+	limit := l.For(sender.OwnerTier)
+	if limit.MaxTransfer > 0 && amount > limit.MaxTransfer {
+		return errorcode.ErrLimitExceeded
+	}
+	if limit.DailyTransfer > 0 && sentToday+amount > limit.DailyTransfer {
+		return errorcode.ErrLimitExceeded
+	}
+	return nil
+}
+
+// CheckSharedWallet returns errorcode.ErrTierRequired when the owner tier is below SharedWalletTier
+func (l Limits) CheckSharedWallet(b Balance) error {
+	if b.OwnerTier < l.SharedWalletTier {
+		return errorcode.ErrTierRequired
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/validator"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Member is user who joined a shared wallet
+type Member struct {
+	base.Model
+	BalanceID         uuid.UUID        `json:"balance_id"`
+	UserID            uuid.UUID        `json:"user_id"`
+	Role              MemberRole       `json:"role"`
+	SpendingLimit     *float64         `json:"spending_limit"`
+	AllowedOperations MemberOperations `json:"allowed_operations"`
+	AcceptedAt        *time.Time       `json:"accepted_at"`
+}
+
+// Accepted reports whether the invited user already agreed to join the shared wallet
+func (m Member) Accepted() bool {
+	return m.AcceptedAt != nil
+}
+
+// Can reports whether member is allowed to perform the operation
+func (m Member) Can(op MemberOperation) bool {
+	switch m.Role {
+	case Owner:
+		return true
+	case Spender:
+		for _, allowed := range m.AllowedOperations {
+			if allowed == op {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithinLimit reports whether member still can spend amount after already spent this period
+func (m Member) WithinLimit(spent, amount float64) bool {
+	if m.Role == Owner || m.SpendingLimit == nil {
+		return true
+	}
+	return spent+amount <= *m.SpendingLimit
+}
+
+// Members is list of member model
+type Members []Member
+
+// MemberSpending is total spending of a member from a shared wallet in a period
+type MemberSpending struct {
+	UserID uuid.UUID `json:"user_id"`
+	Amount float64   `json:"amount"`
+}
+
+// MemberSpendings is list of member spending
+type MemberSpendings []MemberSpending
+
+// SpendingPeriodStart returns the start of the period the spending limit applies to
+func SpendingPeriodStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+type SharedWalletInput struct {
+	Name string `json:"name" validate:"required,max=45"`
+}
+
+func (i SharedWalletInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+// NewSharedWallet creates the shared wallet together with its owner membership
+func (i SharedWalletInput) NewSharedWallet(ownerID uuid.UUID) (*Balance, *Member) {
+	now := time.Now()
+	wallet := &Balance{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: ownerID,
+			CreatedAt: now,
+		},
+		UserID:  ownerID,
+		Kind:    Shared,
+		Balance: 0,
+		Status:  base.Active,
+	}
+	wallet.Name = &i.Name
+	wallet.Histories = BalanceHistories{
+		wallet.NewHistory(0, Credit, Opening, "initial balance", ownerID, now),
+	}
+	owner := &Member{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: ownerID,
+			CreatedAt: now,
+		},
+		BalanceID:         wallet.ID,
+		UserID:            ownerID,
+		Role:              Owner,
+		AllowedOperations: MemberOperations{TransferOperation, WithdrawOperation},
+		AcceptedAt:        &now,
+	}
+	return wallet, owner
+}
+
+type MemberInput struct {
+	UserID            uuid.UUID        `json:"user_id" validate:"required"`
+	Role              MemberRole       `json:"role" validate:"required"`
+	SpendingLimit     *float64         `json:"spending_limit" validate:"omitempty,gte=0"`
+	AllowedOperations MemberOperations `json:"allowed_operations"`
+}
+
+func (i MemberInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+func (i MemberInput) NewMember(balanceID, actorID uuid.UUID) *Member {
+	return &Member{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: actorID,
+			CreatedAt: time.Now(),
+		},
+		BalanceID:         balanceID,
+		UserID:            i.UserID,
+		Role:              i.Role,
+		SpendingLimit:     i.SpendingLimit,
+		AllowedOperations: i.AllowedOperations,
+	}
+}
+
+// Apply copies the input into the existing member
+func (i MemberInput) Apply(member *Member, actorID uuid.UUID) {
+	now := time.Now()
+	member.Role = i.Role
+	member.SpendingLimit = i.SpendingLimit
+	member.AllowedOperations = i.AllowedOperations
+	member.UpdatedBy = &actorID
+	member.UpdatedAt = &now
+}
+
+type SharedTransferInput struct {
+	ToUserID uuid.UUID `json:"to_user_id" validate:"required"`
+	Amount   float64   `json:"amount" validate:"required,gt=0"`
+}
+
+func (i SharedTransferInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
@@ -1,35 +1,200 @@
 package model
 
 import (
-	"errors"
 	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/errorcode"
 	uuid "github.com/satori/go.uuid"
+	"math"
+	"time"
 )
 
 // Balance is balance model
 type Balance struct {
 	base.Model
-	UserID    uuid.UUID        `json:"user_id"`
-	Balance   float64          `json:"balance"`
-	Histories BalanceHistories `json:"-"`
+	UserID             uuid.UUID          `json:"user_id"`
+	Kind               BalanceKind        `json:"kind"`
+	Name               *string            `json:"name"`
+	TargetAmount       *float64           `json:"target_amount"`
+	Deadline           *time.Time         `json:"deadline"`
+	Balance            float64            `json:"balance"`
+	Status             base.AccountStatus `json:"status"`
+	OwnerStatus        base.AccountStatus `json:"-"`
+	OwnerTier          base.Tier          `json:"-"`
+	OverdraftLimit     float64            `json:"overdraft_limit"`
+	OverdraftRate      float64            `json:"overdraft_rate"`
+	OverdraftInterest  float64            `json:"overdraft_interest"`
+	OverdraftAccruedAt *time.Time         `json:"-"`
+	Histories          BalanceHistories   `json:"-"`
+}
+
+// EffectiveStatus returns the most restrictive status of the balance and its owner
+func (b Balance) EffectiveStatus() base.AccountStatus {
+	return base.MostRestrictive(b.Status, b.OwnerStatus)
+}
+
+// Available returns amount that still can be spent, including the unused overdraft
+func (b Balance) Available() float64 {
+	return b.Balance + b.OverdraftLimit
 }
 
 func (b *Balance) Reduce(amount float64) error {
-	if b.Balance == 0 {
-		return errors.New("not enough amount")
+	if amount <= 0 {
+		return errorcode.ErrBadParamInput
+	}
+	if b.Available() < amount {
+		return errorcode.ErrInsufficientFunds
 	}
 	b.Balance = b.Balance - amount
 	return nil
 }
 
 func (b *Balance) Add(amount float64) error {
+	if amount <= 0 {
+		return errorcode.ErrBadParamInput
+	}
 	b.Balance = b.Balance + amount
 	return nil
 }
 
+// AccrueOverdraftInterest accrues daily interest on negative balance for every full day since the last accrual
+func (b *Balance) AccrueOverdraftInterest(now time.Time) {
+	if b.Balance >= 0 || b.OverdraftAccruedAt == nil {
+		b.OverdraftAccruedAt = &now
+		return
+	}
+	days := math.Floor(now.Sub(*b.OverdraftAccruedAt).Hours() / 24)
+	if days < 1 {
+		return
+	}
+	b.OverdraftInterest = b.OverdraftInterest + (-b.Balance * b.OverdraftRate * days)
+	accruedAt := b.OverdraftAccruedAt.Add(time.Duration(days) * 24 * time.Hour)
+	b.OverdraftAccruedAt = &accruedAt
+}
+
+// RepayOverdraftInterest collects outstanding overdraft interest out of credited amount,
+// returns the collected amount
+func (b *Balance) RepayOverdraftInterest(credited float64) float64 {
+	paid := math.Min(credited, b.OverdraftInterest)
+	if paid <= 0 {
+		return 0
+	}
+	b.OverdraftInterest = b.OverdraftInterest - paid
+	b.Balance = b.Balance - paid
+	return paid
+}
+
+// NewHistory creates history of the balance mutation from balanceBefore to the current balance
+func (b Balance) NewHistory(balanceBefore float64, t UserBalanceHistoryType, category BalanceHistoryCategory, activity string, actorID uuid.UUID, at time.Time) BalanceHistory {
+	return BalanceHistory{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: actorID,
+			CreatedAt: at,
+		},
+		BalanceID:     b.ID,
+		BalanceBefore: balanceBefore,
+		BalanceAfter:  b.Balance,
+		Activity:      &activity,
+		Type:          t,
+		Category:      category,
+		IP:            nil,
+		Location:      nil,
+		UserAgent:     nil,
+	}
+}
+
+// Credit adds amount into balance and appends the histories. Outstanding overdraft interest is repaid first.
+func (b *Balance) Credit(amount float64, category BalanceHistoryCategory, activity string, actorID uuid.UUID, at time.Time) error {
+	if err := b.EffectiveStatus().CheckCredit(); err != nil {
+		return err
+	}
+	b.AccrueOverdraftInterest(at)
+	before := b.Balance
+	if err := b.Add(amount); err != nil {
+		return err
+	}
+	b.Histories = append(b.Histories, b.NewHistory(before, Credit, category, activity, actorID, at))
+	before = b.Balance
+	if paid := b.RepayOverdraftInterest(amount); paid > 0 {
+		b.Histories = append(b.Histories, b.NewHistory(before, Debit, Fee, "overdraft interest repayment", actorID, at))
+	}
+	return nil
+}
+
+// Debit reduces amount from balance and appends the history
+func (b *Balance) Debit(amount float64, category BalanceHistoryCategory, activity string, actorID uuid.UUID, at time.Time) error {
+	if err := b.EffectiveStatus().CheckDebit(); err != nil {
+		return err
+	}
+	b.AccrueOverdraftInterest(at)
+	before := b.Balance
+	if err := b.Reduce(amount); err != nil {
+		return err
+	}
+	b.Histories = append(b.Histories, b.NewHistory(before, Debit, category, activity, actorID, at))
+	return nil
+}
+
+// Settled reports whether the balance neither holds nor owes money
+func (b Balance) Settled() bool {
+	return b.Balance == 0 && b.OverdraftInterest == 0
+}
+
 // Balances is list of balance model
 type Balances []Balance
 
+// Main returns the main wallet of the list
+func (b Balances) Main() *Balance {
+	for i := range b {
+		if b[i].Kind == Main {
+			return &b[i]
+		}
+	}
+	return nil
+}
+
+// Pockets returns the open pockets of the list, closed pockets are only kept for their histories
+func (b Balances) Pockets() Balances {
+	res := make(Balances, 0)
+	for _, balance := range b {
+		if balance.Kind == Pocket && balance.Status != base.Closed {
+			res = append(res, balance)
+		}
+	}
+	return res
+}
+
+// NetWorth is summary of every wallet owned by a user. Shared wallets hold money of other members as well, so they
+// are reported on their own and left out of Total
+type NetWorth struct {
+	Main    float64  `json:"main"`
+	Pockets float64  `json:"pockets"`
+	Shared  float64  `json:"shared"`
+	Total   float64  `json:"total"`
+	Wallets Balances `json:"wallets"`
+}
+
+// NewNetWorth sums up the given balances, closed wallets are left out
+func NewNetWorth(balances Balances) NetWorth {
+	res := NetWorth{Wallets: make(Balances, 0, len(balances))}
+	for _, balance := range balances {
+		if balance.Kind != Main && balance.Status == base.Closed {
+			continue
+		}
+		res.Wallets = append(res.Wallets, balance)
+		switch balance.Kind {
+		case Main:
+			res.Main = res.Main + balance.Balance
+		case Pocket:
+			res.Pockets = res.Pockets + balance.Balance
+		case Shared:
+			res.Shared = res.Shared + balance.Balance
+		}
+	}
+	res.Total = res.Main + res.Pockets
+	return res
+}
+
 // BalanceHistory is balance history model
 type BalanceHistory struct {
 	base.Model
@@ -38,6 +203,7 @@ type BalanceHistory struct {
 	BalanceAfter  float64                `json:"balance_after"`
 	Activity      *string                `json:"activity"`
 	Type          UserBalanceHistoryType `json:"type"`
+	Category      BalanceHistoryCategory `json:"category"`
 	IP            *string                `json:"ip"`
 	Location      *string                `json:"location"`
 	UserAgent     *string                `json:"user_agent"`
@@ -46,6 +212,17 @@ type BalanceHistory struct {
 // BalanceHistories is list of balance history model
 type BalanceHistories []BalanceHistory
 
+// BalanceHistoryFilter is criteria used to narrow down balance histories
+type BalanceHistoryFilter struct {
+	Categories BalanceHistoryCategories
+}
+
+// Overdraft is overdraft facility configured for a balance
+type Overdraft struct {
+	Limit float64 `json:"limit" validate:"gte=0"`
+	Rate  float64 `json:"rate" validate:"gte=0,lte=1"`
+}
+
 type TransferBalance struct {
 	BalanceSender   Balance
 	BalanceReceiver Balance
@@ -5,16 +5,37 @@ import (
 	"database/sql"
 	"github.com/fajardm/ewallet-example/app/balance/model"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 // Repository represent the balance's repository contract
 type Repository interface {
 	TxStore(context.Context, *sql.Tx, model.Balance) error
 	GetByUserID(context.Context, uuid.UUID) (*model.Balance, error)
+	GetByID(context.Context, uuid.UUID) (*model.Balance, error)
+	FetchByUserID(context.Context, uuid.UUID) (model.Balances, error)
+	TxLockByIDs(context.Context, *sql.Tx, ...uuid.UUID) (model.Balances, error)
 	TxUpdate(context.Context, *sql.Tx, model.Balance) error
+	UpdateDetail(context.Context, model.Balance) error
+	TxUpdateStatus(context.Context, *sql.Tx, model.Balance) error
+	UpdateOverdraft(context.Context, model.Balance) error
 	TxDelete(context.Context, *sql.Tx, uuid.UUID) error
 	TxStoreBalanceHistory(context.Context, *sql.Tx, model.BalanceHistory) error
-	FetchBalanceHistoriesByBalanceID(context.Context, uuid.UUID) (model.BalanceHistories, error)
+	FetchBalanceHistoriesByBalanceID(context.Context, uuid.UUID, model.BalanceHistoryFilter) (model.BalanceHistories, error)
+	FetchAllBalanceHistoriesByBalanceID(context.Context, uuid.UUID) (model.BalanceHistories, error)
+	SumTransferOutByUserID(context.Context, uuid.UUID, time.Time) (float64, error)
 	TxDeleteBalanceHistoriesByBalanceID(context.Context, *sql.Tx, uuid.UUID) error
+	TxStoreMember(context.Context, *sql.Tx, model.Member) error
+	GetMember(context.Context, uuid.UUID, uuid.UUID) (*model.Member, error)
+	FetchMembersByBalanceID(context.Context, uuid.UUID) (model.Members, error)
+	FetchSharedByMemberUserID(context.Context, uuid.UUID) (model.Balances, error)
+	FetchInvitationsByUserID(context.Context, uuid.UUID) (model.Balances, error)
+	AcceptMember(context.Context, model.Member) error
+	UpdateMember(context.Context, model.Member) error
+	DeleteMember(context.Context, uuid.UUID, uuid.UUID) error
+	TxDeleteMembersByBalanceID(context.Context, *sql.Tx, uuid.UUID) error
+	TxDeleteMembersByUserID(context.Context, *sql.Tx, uuid.UUID) error
+	SumMemberSpending(context.Context, uuid.UUID, uuid.UUID, time.Time) (float64, error)
+	FetchMemberSpendings(context.Context, uuid.UUID, time.Time, time.Time) (model.MemberSpendings, error)
 	WithTransaction(context.Context, func(tx *sql.Tx) error) error
 }
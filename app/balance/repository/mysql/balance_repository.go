@@ -9,6 +9,8 @@ import (
 	"github.com/fajardm/ewallet-example/database"
 	"github.com/fajardm/ewallet-example/errorcode"
 	uuid "github.com/satori/go.uuid"
+	"strings"
+	"time"
 )
 
 const (
@@ -18,6 +20,17 @@ const (
 			id,
 			balance,
 			user_id,
+			kind,
+			name,
+			target_amount,
+			deadline,
+			status,
+			(SELECT users.status FROM users WHERE users.id = balances.user_id) AS owner_status,
+			(SELECT users.tier FROM users WHERE users.id = balances.user_id) AS owner_tier,
+			overdraft_limit,
+			overdraft_rate,
+			overdraft_interest,
+			overdraft_accrued_at,
 			created_by,
 			created_at,
 			updated_by,
@@ -29,12 +42,26 @@ const (
 			id,
 			balance,
 			user_id,
+			kind,
+			name,
+			target_amount,
+			deadline,
+			status,
 			created_by,
 			created_at
-		) VALUES (?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	queryUpdateBalance = `
-		UPDATE balances SET balance=?, updated_by=?, updated_at=? WHERE id=?
+		UPDATE balances SET balance=?, overdraft_interest=?, overdraft_accrued_at=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryUpdateBalanceDetail = `
+		UPDATE balances SET name=?, target_amount=?, deadline=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryUpdateBalanceStatus = `
+		UPDATE balances SET status=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryUpdateBalanceOverdraft = `
+		UPDATE balances SET overdraft_limit=?, overdraft_rate=?, updated_by=?, updated_at=? WHERE id=?
 	`
 	queryDeleteBalance = `
 		DELETE FROM balances WHERE id=?
@@ -47,6 +74,7 @@ const (
 			balance_after,
 			activity,
 			type,
+			category,
 			ip,
 			location,
 			user_agent,
@@ -64,13 +92,21 @@ const (
 			balance_after,
 			activity,
 			type,
+			category,
 			ip,
 			location,
 			user_agent,
 			balance_id,
 			created_by,
 			created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	querySumTransferOutByUserID = `
+		SELECT COALESCE(SUM(balance_histories.balance_before - balance_histories.balance_after), 0) 
+		FROM balance_histories 
+		JOIN balances ON balances.id = balance_histories.balance_id 
+		WHERE balance_histories.type=? AND balance_histories.category=? AND balance_histories.created_at >= ? 
+			AND ((balances.kind=? AND balances.user_id=?) OR (balances.kind=? AND balance_histories.created_by=?))
 	`
 	queryDeleteBalanceHistories = `
 		DELETE FROM balance_histories WHERE balance_id=?
@@ -90,13 +126,14 @@ func (b balanceRepository) WithTransaction(ctx context.Context, fn func(tx *sql.
 }
 
 func (b balanceRepository) TxStore(ctx context.Context, tx *sql.Tx, balance model.Balance) (err error) {
-	_, err = tx.ExecContext(ctx, queryInsertBalance, balance.ID, balance.Balance, balance.UserID, balance.CreatedBy, balance.CreatedAt)
+	_, err = tx.ExecContext(ctx, queryInsertBalance, balance.ID, balance.Balance, balance.UserID, balance.Kind, balance.Name, balance.TargetAmount, balance.Deadline, balance.Status, balance.CreatedBy, balance.CreatedAt)
 	return
 }
 
+// GetByUserID returns the main wallet of the user
 func (b balanceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Balance, error) {
-	q := querySelectBalance + " WHERE user_id=?"
-	list, err := b.fetchContext(ctx, q, userID)
+	q := querySelectBalance + " WHERE user_id=? AND kind=?"
+	list, err := b.fetchContext(ctx, q, userID, model.Main)
 	if err != nil {
 		return nil, err
 	}
@@ -106,8 +143,92 @@ func (b balanceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*
 	return nil, errorcode.ErrNotFound
 }
 
+func (b balanceRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Balance, error) {
+	q := querySelectBalance + " WHERE id=?"
+	list, err := b.fetchContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+	return nil, errorcode.ErrNotFound
+}
+
+// FetchByUserID returns every wallet of the user, main wallet first
+func (b balanceRepository) FetchByUserID(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	q := querySelectBalance + " WHERE user_id=? ORDER BY kind ASC, created_at ASC"
+	return b.fetchContext(ctx, q, userID)
+}
+
+// TxLockByIDs reads the balances with exclusive lock held until the transaction ends, rows are locked in id order so
+// transactions locking the same balances can not deadlock
+func (b balanceRepository) TxLockByIDs(ctx context.Context, tx *sql.Tx, ids ...uuid.UUID) (model.Balances, error) {
+	if len(ids) == 0 {
+		return model.Balances{}, nil
+	}
+	q := querySelectBalance + " WHERE id IN (?" + strings.Repeat(", ?", len(ids)-1) + ") ORDER BY id ASC FOR UPDATE"
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBalances(rows)
+}
+
 func (b balanceRepository) TxUpdate(ctx context.Context, tx *sql.Tx, balance model.Balance) (err error) {
-	res, err := tx.ExecContext(ctx, queryUpdateBalance, balance.Balance, balance.UpdatedBy, balance.UpdatedAt, balance.ID)
+	res, err := tx.ExecContext(ctx, queryUpdateBalance, balance.Balance, balance.OverdraftInterest, balance.OverdraftAccruedAt, balance.UpdatedBy, balance.UpdatedAt, balance.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+func (b balanceRepository) UpdateDetail(ctx context.Context, balance model.Balance) (err error) {
+	res, err := b.db.ExecContext(ctx, queryUpdateBalanceDetail, balance.Name, balance.TargetAmount, balance.Deadline, balance.UpdatedBy, balance.UpdatedAt, balance.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+func (b balanceRepository) TxUpdateStatus(ctx context.Context, tx *sql.Tx, balance model.Balance) (err error) {
+	res, err := tx.ExecContext(ctx, queryUpdateBalanceStatus, balance.Status, balance.UpdatedBy, balance.UpdatedAt, balance.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+func (b balanceRepository) UpdateOverdraft(ctx context.Context, balance model.Balance) (err error) {
+	res, err := b.db.ExecContext(ctx, queryUpdateBalanceOverdraft, balance.OverdraftLimit, balance.OverdraftRate, balance.UpdatedBy, balance.UpdatedAt, balance.ID)
 	if err != nil {
 		return err
 	}
@@ -139,15 +260,36 @@ func (b balanceRepository) TxDelete(ctx context.Context, tx *sql.Tx, id uuid.UUI
 }
 
 func (b balanceRepository) TxStoreBalanceHistory(ctx context.Context, tx *sql.Tx, history model.BalanceHistory) (err error) {
-	_, err = tx.ExecContext(ctx, queryInsertBalanceHistories, history.ID, history.BalanceBefore, history.BalanceAfter, history.Activity, history.Type, history.IP, history.Location, history.UserAgent, history.BalanceID, history.CreatedBy, history.CreatedAt)
+	_, err = tx.ExecContext(ctx, queryInsertBalanceHistories, history.ID, history.BalanceBefore, history.BalanceAfter, history.Activity, history.Type, history.Category, history.IP, history.Location, history.UserAgent, history.BalanceID, history.CreatedBy, history.CreatedAt)
 	return
 }
 
-func (b balanceRepository) FetchBalanceHistoriesByBalanceID(ctx context.Context, balanceID uuid.UUID) (model.BalanceHistories, error) {
-	q := querySelectBalanceHistories + " WHERE balance_id = ? ORDER BY created_at DESC LIMIT 10"
+func (b balanceRepository) FetchBalanceHistoriesByBalanceID(ctx context.Context, balanceID uuid.UUID, filter model.BalanceHistoryFilter) (model.BalanceHistories, error) {
+	q := querySelectBalanceHistories + " WHERE balance_id = ?"
+	args := []interface{}{balanceID}
+	if len(filter.Categories) > 0 {
+		q += " AND category IN (?" + strings.Repeat(", ?", len(filter.Categories)-1) + ")"
+		for _, category := range filter.Categories {
+			args = append(args, category)
+		}
+	}
+	q += " ORDER BY created_at DESC LIMIT 10"
+	return b.fetchBalanceHistoriesContext(ctx, q, args...)
+}
+
+// FetchAllBalanceHistoriesByBalanceID returns every history of the balance, oldest first
+func (b balanceRepository) FetchAllBalanceHistoriesByBalanceID(ctx context.Context, balanceID uuid.UUID) (model.BalanceHistories, error) {
+	q := querySelectBalanceHistories + " WHERE balance_id = ? ORDER BY created_at ASC"
 	return b.fetchBalanceHistoriesContext(ctx, q, balanceID)
 }
 
+// SumTransferOutByUserID returns total money the user sent by transfer since the given time, from its main balance
+// and from every shared wallet it spent
+func (b balanceRepository) SumTransferOutByUserID(ctx context.Context, userID uuid.UUID, since time.Time) (total float64, err error) {
+	err = b.db.QueryRowContext(ctx, querySumTransferOutByUserID, model.Debit, model.TransferOut, since, model.Main, userID, model.Shared, userID).Scan(&total)
+	return
+}
+
 func (b balanceRepository) TxDeleteBalanceHistoriesByBalanceID(ctx context.Context, tx *sql.Tx, balanceID uuid.UUID) (err error) {
 	res, err := tx.ExecContext(ctx, queryDeleteBalanceHistories, balanceID)
 	if err != nil {
@@ -162,12 +304,16 @@ func (b balanceRepository) fetchContext(ctx context.Context, query string, args
 	if err != nil {
 		return nil, err
 	}
+	return scanBalances(rows)
+}
+
+func scanBalances(rows *sql.Rows) (model.Balances, error) {
 	defer rows.Close()
 
 	res := make(model.Balances, 0)
 	for rows.Next() {
 		r := model.Balance{}
-		err = rows.Scan(&r.ID, &r.Balance, &r.UserID, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		err := rows.Scan(&r.ID, &r.Balance, &r.UserID, &r.Kind, &r.Name, &r.TargetAmount, &r.Deadline, &r.Status, &r.OwnerStatus, &r.OwnerTier, &r.OverdraftLimit, &r.OverdraftRate, &r.OverdraftInterest, &r.OverdraftAccruedAt, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -186,7 +332,7 @@ func (b balanceRepository) fetchBalanceHistoriesContext(ctx context.Context, que
 	res := make(model.BalanceHistories, 0)
 	for rows.Next() {
 		r := model.BalanceHistory{}
-		err = rows.Scan(&r.ID, &r.BalanceBefore, &r.BalanceAfter, &r.Activity, &r.Type, &r.IP, &r.Location, &r.UserAgent, &r.BalanceID, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		err = rows.Scan(&r.ID, &r.BalanceBefore, &r.BalanceAfter, &r.Activity, &r.Type, &r.Category, &r.IP, &r.Location, &r.UserAgent, &r.BalanceID, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,207 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table balance_members
+	querySelectMember = `
+		SELECT 
+			id,
+			balance_id,
+			user_id,
+			role,
+			spending_limit,
+			allowed_operations,
+			accepted_at,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at 
+		FROM balance_members
+	`
+	queryInsertMember = `
+		INSERT INTO balance_members (
+			id,
+			balance_id,
+			user_id,
+			role,
+			spending_limit,
+			allowed_operations,
+			accepted_at,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	queryUpdateMember = `
+		UPDATE balance_members SET role=?, spending_limit=?, allowed_operations=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryAcceptMember = `
+		UPDATE balance_members SET accepted_at=?, updated_by=?, updated_at=? WHERE id=? AND accepted_at IS NULL
+	`
+	queryDeleteMember = `
+		DELETE FROM balance_members WHERE balance_id=? AND user_id=?
+	`
+	queryDeleteMembersByBalanceID = `
+		DELETE FROM balance_members WHERE balance_id=?
+	`
+	queryDeleteMembersByUserID = `
+		DELETE FROM balance_members WHERE user_id=?
+	`
+	querySumMemberSpending = `
+		SELECT COALESCE(SUM(balance_before - balance_after), 0) 
+		FROM balance_histories 
+		WHERE balance_id=? AND created_by=? AND type=? AND created_at >= ?
+	`
+	queryFetchMemberSpendings = `
+		SELECT created_by, COALESCE(SUM(balance_before - balance_after), 0) 
+		FROM balance_histories 
+		WHERE balance_id=? AND type=? AND created_at >= ? AND created_at < ? 
+		GROUP BY created_by
+	`
+)
+
+func (b balanceRepository) TxStoreMember(ctx context.Context, tx *sql.Tx, member model.Member) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertMember, member.ID, member.BalanceID, member.UserID, member.Role, member.SpendingLimit, member.AllowedOperations, member.AcceptedAt, member.CreatedBy, member.CreatedAt)
+	return
+}
+
+func (b balanceRepository) GetMember(ctx context.Context, balanceID, userID uuid.UUID) (*model.Member, error) {
+	q := querySelectMember + " WHERE balance_id=? AND user_id=?"
+	list, err := b.fetchMembersContext(ctx, q, balanceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+	return nil, errorcode.ErrNotFound
+}
+
+func (b balanceRepository) FetchMembersByBalanceID(ctx context.Context, balanceID uuid.UUID) (model.Members, error) {
+	q := querySelectMember + " WHERE balance_id=? ORDER BY created_at ASC"
+	return b.fetchMembersContext(ctx, q, balanceID)
+}
+
+// FetchSharedByMemberUserID returns every open shared wallet the user accepted to join
+func (b balanceRepository) FetchSharedByMemberUserID(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	q := querySelectBalance + " WHERE kind=? AND status<>? AND id IN (SELECT balance_id FROM balance_members WHERE user_id=? AND accepted_at IS NOT NULL) ORDER BY created_at ASC"
+	return b.fetchContext(ctx, q, model.Shared, base.Closed, userID)
+}
+
+// FetchInvitationsByUserID returns every open shared wallet the user is invited to but not yet accepted
+func (b balanceRepository) FetchInvitationsByUserID(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	q := querySelectBalance + " WHERE kind=? AND status<>? AND id IN (SELECT balance_id FROM balance_members WHERE user_id=? AND accepted_at IS NULL) ORDER BY created_at ASC"
+	return b.fetchContext(ctx, q, model.Shared, base.Closed, userID)
+}
+
+func (b balanceRepository) UpdateMember(ctx context.Context, member model.Member) (err error) {
+	res, err := b.db.ExecContext(ctx, queryUpdateMember, member.Role, member.SpendingLimit, member.AllowedOperations, member.UpdatedBy, member.UpdatedAt, member.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+// AcceptMember marks the invitation as accepted, returns ErrConflict when it was already accepted
+func (b balanceRepository) AcceptMember(ctx context.Context, member model.Member) (err error) {
+	res, err := b.db.ExecContext(ctx, queryAcceptMember, member.AcceptedAt, member.UpdatedBy, member.UpdatedAt, member.ID)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected == 0 {
+		err = errorcode.ErrConflict
+		return
+	}
+	return
+}
+
+func (b balanceRepository) DeleteMember(ctx context.Context, balanceID, userID uuid.UUID) (err error) {
+	res, err := b.db.ExecContext(ctx, queryDeleteMember, balanceID, userID)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+func (b balanceRepository) TxDeleteMembersByBalanceID(ctx context.Context, tx *sql.Tx, balanceID uuid.UUID) (err error) {
+	_, err = tx.ExecContext(ctx, queryDeleteMembersByBalanceID, balanceID)
+	return
+}
+
+func (b balanceRepository) TxDeleteMembersByUserID(ctx context.Context, tx *sql.Tx, userID uuid.UUID) (err error) {
+	_, err = tx.ExecContext(ctx, queryDeleteMembersByUserID, userID)
+	return
+}
+
+// SumMemberSpending returns total debit made by the member from the wallet since the given time
+func (b balanceRepository) SumMemberSpending(ctx context.Context, balanceID, userID uuid.UUID, since time.Time) (total float64, err error) {
+	err = b.db.QueryRowContext(ctx, querySumMemberSpending, balanceID, userID, model.Debit, since).Scan(&total)
+	return
+}
+
+// FetchMemberSpendings returns total debit of the wallet grouped by acting member
+func (b balanceRepository) FetchMemberSpendings(ctx context.Context, balanceID uuid.UUID, from, until time.Time) (model.MemberSpendings, error) {
+	rows, err := b.db.QueryContext(ctx, queryFetchMemberSpendings, balanceID, model.Debit, from, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.MemberSpendings, 0)
+	for rows.Next() {
+		r := model.MemberSpending{}
+		if err = rows.Scan(&r.UserID, &r.Amount); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (b balanceRepository) fetchMembersContext(ctx context.Context, query string, args ...interface{}) (model.Members, error) {
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Members, 0)
+	for rows.Next() {
+		r := model.Member{}
+		err = rows.Scan(&r.ID, &r.BalanceID, &r.UserID, &r.Role, &r.SpendingLimit, &r.AllowedOperations, &r.AcceptedAt, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
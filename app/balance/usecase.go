@@ -4,12 +4,39 @@ import (
 	"context"
 	"github.com/fajardm/ewallet-example/app/balance/model"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 // Usecase represent the balance's usecase contract
 type Usecase interface {
 	GetBalanceByUserID(context.Context, uuid.UUID) (*model.Balance, error)
-	GetBalanceHistoriesByUserID(context.Context, uuid.UUID) (model.BalanceHistories, error)
+	GetBalanceHistoriesByUserID(context.Context, uuid.UUID, model.BalanceHistoryFilter) (model.BalanceHistories, error)
 	TransferBalance(context.Context, uuid.UUID, uuid.UUID, float64) error
 	TopUp(context.Context, uuid.UUID, float64) error
+	SetOverdraft(context.Context, uuid.UUID, model.Overdraft, uuid.UUID) (*model.Balance, error)
+	GetNetWorth(context.Context, uuid.UUID) (*model.NetWorth, error)
+	CreatePocket(context.Context, model.Balance) error
+	FetchPocketsByUserID(context.Context, uuid.UUID) (model.Balances, error)
+	GetPocket(context.Context, uuid.UUID, uuid.UUID) (*model.Balance, error)
+	UpdatePocket(context.Context, uuid.UUID, model.Balance) error
+	DeletePocket(context.Context, uuid.UUID, uuid.UUID) error
+	GetPocketHistories(context.Context, uuid.UUID, uuid.UUID, model.BalanceHistoryFilter) (model.BalanceHistories, error)
+	MoveToPocket(context.Context, uuid.UUID, uuid.UUID, float64) error
+	MoveFromPocket(context.Context, uuid.UUID, uuid.UUID, float64) error
+	CreateSharedWallet(context.Context, model.Balance, model.Member) error
+	FetchSharedWallets(context.Context, uuid.UUID) (model.Balances, error)
+	FetchInvitations(context.Context, uuid.UUID) (model.Balances, error)
+	GetSharedWallet(context.Context, uuid.UUID, uuid.UUID) (*model.Balance, error)
+	DeleteSharedWallet(context.Context, uuid.UUID, uuid.UUID) error
+	GetSharedHistories(context.Context, uuid.UUID, uuid.UUID, model.BalanceHistoryFilter) (model.BalanceHistories, error)
+	FetchMembers(context.Context, uuid.UUID, uuid.UUID) (model.Members, error)
+	GetMember(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) (*model.Member, error)
+	AddMember(context.Context, uuid.UUID, model.Member) error
+	AcceptMember(context.Context, uuid.UUID, uuid.UUID) error
+	UpdateMember(context.Context, uuid.UUID, model.Member) error
+	RemoveMember(context.Context, uuid.UUID, uuid.UUID, uuid.UUID) error
+	GetMemberSpendings(context.Context, uuid.UUID, uuid.UUID, time.Time, time.Time) (model.MemberSpendings, error)
+	TransferFromShared(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, float64) error
+	ContributeToShared(context.Context, uuid.UUID, uuid.UUID, float64) error
+	WithdrawFromShared(context.Context, uuid.UUID, uuid.UUID, float64) error
 }
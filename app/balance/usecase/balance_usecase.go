@@ -7,27 +7,39 @@ import (
 	"github.com/fajardm/ewallet-example/app/balance"
 	"github.com/fajardm/ewallet-example/app/balance/model"
 	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
 	uuid "github.com/satori/go.uuid"
 	"time"
 )
 
 type balanceUsecase struct {
 	balanceRepository balance.Repository
+	auditor           audit.Auditor
+	limits            model.Limits
 	contextTimeout    time.Duration
 }
 
-func NewBalanceUsecase(balanceRepository balance.Repository, contextTimeout time.Duration) balance.Usecase {
-	return balanceUsecase{balanceRepository: balanceRepository, contextTimeout: contextTimeout}
+func NewBalanceUsecase(balanceRepository balance.Repository, auditor audit.Auditor, limits model.Limits, contextTimeout time.Duration) balance.Usecase {
+	if limits.SharedWalletTier == 0 {
+		limits.SharedWalletTier = base.Basic
+	}
+	return balanceUsecase{balanceRepository: balanceRepository, auditor: auditor, limits: limits, contextTimeout: contextTimeout}
 }
 
 func (b balanceUsecase) GetBalanceByUserID(ctx context.Context, userID uuid.UUID) (*model.Balance, error) {
 	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
 	defer cancel()
 
-	return b.balanceRepository.GetByUserID(ctx, userID)
+	balance, err := b.balanceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	balance.AccrueOverdraftInterest(time.Now())
+	return balance, nil
 }
 
-func (b balanceUsecase) GetBalanceHistoriesByUserID(ctx context.Context, userID uuid.UUID) (model.BalanceHistories, error) {
+func (b balanceUsecase) GetBalanceHistoriesByUserID(ctx context.Context, userID uuid.UUID, filter model.BalanceHistoryFilter) (model.BalanceHistories, error) {
 	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
 	defer cancel()
 
@@ -36,84 +48,68 @@ func (b balanceUsecase) GetBalanceHistoriesByUserID(ctx context.Context, userID
 		return nil, err
 	}
 
-	return b.balanceRepository.FetchBalanceHistoriesByBalanceID(ctx, balance.ID)
+	return b.balanceRepository.FetchBalanceHistoriesByBalanceID(ctx, balance.ID, filter)
 }
 
+// TransferBalance moves amount from the sender main balance to the receiver main balance. Both balances are read
+// again and locked inside the transaction, so concurrent movements can not pass the funds check on a stale copy
 func (b balanceUsecase) TransferBalance(ctx context.Context, fromUserID, toUserID uuid.UUID, amount float64) (err error) {
 	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
 	defer cancel()
 
+	if uuid.Equal(fromUserID, toUserID) {
+		return errorcode.ErrBadParamInput
+	}
 	now := time.Now()
 
 	sender, err := b.balanceRepository.GetByUserID(ctx, fromUserID)
 	if err != nil {
 		return err
 	}
-	bfs := sender.Balance
-	if err := sender.Reduce(amount); err != nil {
-		return err
-	}
-	senderActivity := fmt.Sprintf("transfer amount %f to %s", amount, toUserID)
-	sender.Histories = model.BalanceHistories{
-		model.BalanceHistory{
-			Model: base.Model{
-				ID:        uuid.NewV4(),
-				CreatedBy: sender.UserID,
-				CreatedAt: now,
-			},
-			BalanceID:     sender.ID,
-			BalanceBefore: bfs,
-			BalanceAfter:  sender.Balance,
-			Activity:      &senderActivity,
-			Type:          model.Debit,
-			IP:            nil,
-			Location:      nil,
-			UserAgent:     nil,
-		},
-	}
-
 	reciever, err := b.balanceRepository.GetByUserID(ctx, toUserID)
 	if err != nil {
 		return err
 	}
-	bfr := reciever.Balance
-	if err := reciever.Add(amount); err != nil {
-		return err
-	}
-	rActivity := fmt.Sprintf("retrieve amount %f from %s", amount, fromUserID)
-	reciever.Histories = model.BalanceHistories{
-		model.BalanceHistory{
-			Model: base.Model{
-				ID:        uuid.NewV4(),
-				CreatedBy: reciever.UserID,
-				CreatedAt: now,
-			},
-			BalanceID:     reciever.ID,
-			BalanceBefore: bfr,
-			BalanceAfter:  reciever.Balance,
-			Activity:      &rActivity,
-			Type:          model.Credit,
-			IP:            nil,
-			Location:      nil,
-			UserAgent:     nil,
-		},
-	}
-
-	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
-		if err = b.balanceRepository.TxUpdate(ctx, tx, *sender); err != nil {
+
+	var senderBefore, recieverBefore model.Balance
+	err = b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		locked, err := b.lockBalances(ctx, tx, sender.ID, reciever.ID)
+		if err != nil {
+			return err
+		}
+		sender, reciever = locked[0], locked[1]
+		senderBefore, recieverBefore = *sender, *reciever
+
+		if err = b.checkTransfer(ctx, *sender, amount, now); err != nil {
+			return err
+		}
+		senderActivity := fmt.Sprintf("transfer amount %f to %s", amount, toUserID)
+		if err = sender.Debit(amount, model.TransferOut, senderActivity, sender.UserID, now); err != nil {
 			return err
 		}
-		if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, sender.Histories[0]); err != nil {
+		sender.UpdatedBy = &fromUserID
+		sender.UpdatedAt = &now
+
+		rActivity := fmt.Sprintf("retrieve amount %f from %s", amount, fromUserID)
+		if err = reciever.Credit(amount, model.TransferIn, rActivity, reciever.UserID, now); err != nil {
 			return err
 		}
-		if err = b.balanceRepository.TxUpdate(ctx, tx, *reciever); err != nil {
+		if err = b.limits.CheckBalance(*reciever); err != nil {
 			return err
 		}
-		if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, reciever.Histories[0]); err != nil {
+		reciever.UpdatedBy = &fromUserID
+		reciever.UpdatedAt = &now
+
+		if err = b.txSave(ctx, tx, *sender); err != nil {
 			return err
 		}
-		return
+		return b.txSave(ctx, tx, *reciever)
 	})
+	if err != nil {
+		return err
+	}
+	b.recordTransfer(ctx, audit.BalanceTransfer, senderBefore, recieverBefore, *sender, *reciever)
+	return nil
 }
 
 func (b balanceUsecase) TopUp(ctx context.Context, userID uuid.UUID, amount float64) (err error) {
@@ -126,36 +122,120 @@ func (b balanceUsecase) TopUp(ctx context.Context, userID uuid.UUID, amount floa
 	if err != nil {
 		return err
 	}
-	bfs := balance.Balance
-	if err := balance.Add(amount); err != nil {
-		return err
-	}
-	activity := fmt.Sprintf("topup amount %f", amount)
-	balance.Histories = model.BalanceHistories{
-		model.BalanceHistory{
-			Model: base.Model{
-				ID:        uuid.NewV4(),
-				CreatedBy: balance.UserID,
-				CreatedAt: now,
-			},
-			BalanceID:     balance.ID,
-			BalanceBefore: bfs,
-			BalanceAfter:  balance.Balance,
-			Activity:      &activity,
-			Type:          model.Credit,
-			IP:            nil,
-			Location:      nil,
-			UserAgent:     nil,
-		},
-	}
-
-	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
-		if err = b.balanceRepository.TxUpdate(ctx, tx, *balance); err != nil {
+
+	var before model.Balance
+	err = b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		locked, err := b.lockBalances(ctx, tx, balance.ID)
+		if err != nil {
+			return err
+		}
+		balance = locked[0]
+		before = *balance
+
+		activity := fmt.Sprintf("topup amount %f", amount)
+		if err = balance.Credit(amount, model.TopUp, activity, balance.UserID, now); err != nil {
 			return err
 		}
-		if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, balance.Histories[0]); err != nil {
+		if err = b.limits.CheckBalance(*balance); err != nil {
 			return err
 		}
-		return
+		balance.UpdatedBy = &userID
+		balance.UpdatedAt = &now
+		return b.txSave(ctx, tx, *balance)
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{Action: audit.BalanceTopUp, TargetType: "balance", TargetID: balance.ID, Before: audit.Snapshot(before), After: audit.Snapshot(balance)})
+	return nil
+}
+
+func (b balanceUsecase) SetOverdraft(ctx context.Context, userID uuid.UUID, overdraft model.Overdraft, actorID uuid.UUID) (*model.Balance, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	balance, err := b.balanceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Status == base.Closed {
+		return nil, errorcode.ErrAccountClosed
+	}
+	if balance.Balance < -overdraft.Limit {
+		return nil, errorcode.ErrConflict
+	}
+
+	now := time.Now()
+	before := *balance
+	balance.OverdraftLimit = overdraft.Limit
+	balance.OverdraftRate = overdraft.Rate
+	balance.UpdatedBy = &actorID
+	balance.UpdatedAt = &now
+	if err := b.balanceRepository.UpdateOverdraft(ctx, *balance); err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{ActorID: &actorID, Action: audit.BalanceOverdraft, TargetType: "balance", TargetID: balance.ID, Before: audit.Snapshot(before), After: audit.Snapshot(balance)})
+	return balance, nil
+}
+
+// checkTransfer checks the amount against the tier limits of the sender, including what it sent today from shared
+// wallets
+func (b balanceUsecase) checkTransfer(ctx context.Context, sender model.Balance, amount float64, now time.Time) error {
+	sentToday, err := b.balanceRepository.SumTransferOutByUserID(ctx, sender.UserID, startOfDay(now))
+	if err != nil {
+		return err
+	}
+	return b.limits.CheckTransfer(sender, sentToday, amount)
+}
+
+// startOfDay returns midnight of the day in local time, daily limits reset then
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// recordTransfer audits money moved between two balances, snapshots hold both sides. The event targets the balance
+// the money is taken from
+func (b balanceUsecase) recordTransfer(ctx context.Context, action string, fromBefore, toBefore, fromAfter, toAfter model.Balance) {
+	audit.Record(ctx, b.auditor, audit.Event{
+		Action:     action,
+		TargetType: "balance",
+		TargetID:   fromAfter.ID,
+		Before:     audit.Snapshot(map[string]model.Balance{"from": fromBefore, "to": toBefore}),
+		After:      audit.Snapshot(map[string]model.Balance{"from": fromAfter, "to": toAfter}),
 	})
 }
+
+// txSave updates the balance and stores its pending histories
+// lockBalances reads the balances again with exclusive lock held until the transaction ends, returned in the order of
+// the given ids
+func (b balanceUsecase) lockBalances(ctx context.Context, tx *sql.Tx, ids ...uuid.UUID) ([]*model.Balance, error) {
+	list, err := b.balanceRepository.TxLockByIDs(ctx, tx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*model.Balance, len(ids))
+	for i, id := range ids {
+		for j := range list {
+			if uuid.Equal(list[j].ID, id) {
+				res[i] = &list[j]
+			}
+		}
+		if res[i] == nil {
+			return nil, errorcode.ErrNotFound
+		}
+	}
+	return res, nil
+}
+
+func (b balanceUsecase) txSave(ctx context.Context, tx *sql.Tx, balance model.Balance) (err error) {
+	if err = b.balanceRepository.TxUpdate(ctx, tx, balance); err != nil {
+		return err
+	}
+	for _, history := range balance.Histories {
+		if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, history); err != nil {
+			return err
+		}
+	}
+	return
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"strings"
+	"time"
+)
+
+func (b balanceUsecase) GetNetWorth(ctx context.Context, userID uuid.UUID) (*model.NetWorth, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	balances, err := b.balanceRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, errorcode.ErrNotFound
+	}
+	netWorth := model.NewNetWorth(balances)
+	return &netWorth, nil
+}
+
+func (b balanceUsecase) CreatePocket(ctx context.Context, pocket model.Balance) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	balances, err := b.balanceRepository.FetchByUserID(ctx, pocket.UserID)
+	if err != nil {
+		return err
+	}
+	if balances.Main() == nil {
+		return errorcode.ErrNotFound
+	}
+	if err := balances.Main().EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	for _, existed := range balances.Pockets() {
+		if existed.Name != nil && strings.EqualFold(*existed.Name, *pocket.Name) {
+			return errorcode.ErrConflict
+		}
+	}
+
+	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = b.balanceRepository.TxStore(ctx, tx, pocket); err != nil {
+			return err
+		}
+		for _, history := range pocket.Histories {
+			if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, history); err != nil {
+				return err
+			}
+		}
+		return
+	})
+}
+
+func (b balanceUsecase) FetchPocketsByUserID(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	balances, err := b.balanceRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return balances.Pockets(), nil
+}
+
+func (b balanceUsecase) GetPocket(ctx context.Context, userID, pocketID uuid.UUID) (*model.Balance, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	return b.getPocket(ctx, userID, pocketID)
+}
+
+func (b balanceUsecase) UpdatePocket(ctx context.Context, userID uuid.UUID, pocket model.Balance) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	existed, err := b.getPocket(ctx, userID, pocket.ID)
+	if err != nil {
+		return err
+	}
+	if err := existed.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	balances, err := b.balanceRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, existed := range balances.Pockets() {
+		if existed.ID != pocket.ID && existed.Name != nil && strings.EqualFold(*existed.Name, *pocket.Name) {
+			return errorcode.ErrConflict
+		}
+	}
+
+	return b.balanceRepository.UpdateDetail(ctx, pocket)
+}
+
+// DeletePocket closes an empty pocket, it stays with its histories in the ledger but is no longer listed and can not
+// receive money
+func (b balanceUsecase) DeletePocket(ctx context.Context, userID, pocketID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	pocket, err := b.getPocket(ctx, userID, pocketID)
+	if err != nil {
+		return err
+	}
+	if err := pocket.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	if pocket.Balance != 0 {
+		return errorcode.ErrConflict
+	}
+
+	now := time.Now()
+	pocket.Status = base.Closed
+	pocket.UpdatedBy = &userID
+	pocket.UpdatedAt = &now
+	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return b.balanceRepository.TxUpdateStatus(ctx, tx, *pocket)
+	})
+}
+
+func (b balanceUsecase) GetPocketHistories(ctx context.Context, userID, pocketID uuid.UUID, filter model.BalanceHistoryFilter) (model.BalanceHistories, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	pocket, err := b.getPocket(ctx, userID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	return b.balanceRepository.FetchBalanceHistoriesByBalanceID(ctx, pocket.ID, filter)
+}
+
+// MoveToPocket moves amount from the main wallet into the pocket
+func (b balanceUsecase) MoveToPocket(ctx context.Context, userID, pocketID uuid.UUID, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	mainBalance, err := b.balanceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	pocket, err := b.getPocket(ctx, userID, pocketID)
+	if err != nil {
+		return err
+	}
+	return b.move(ctx, userID, mainBalance, pocket, amount)
+}
+
+// MoveFromPocket moves amount from the pocket back into the main wallet
+func (b balanceUsecase) MoveFromPocket(ctx context.Context, userID, pocketID uuid.UUID, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	mainBalance, err := b.balanceRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	pocket, err := b.getPocket(ctx, userID, pocketID)
+	if err != nil {
+		return err
+	}
+	return b.move(ctx, userID, pocket, mainBalance, amount)
+}
+
+// move transfers amount between wallets the actor has access to, overdraft is never used for internal moves. Both
+// wallets are read again and locked inside the transaction before the funds check. Money leaving a main balance into a
+// wallet of another owner is a transfer and counts toward the tier limits of the actor
+func (b balanceUsecase) move(ctx context.Context, actorID uuid.UUID, from, to *model.Balance, amount float64) error {
+	if amount <= 0 {
+		return errorcode.ErrBadParamInput
+	}
+
+	now := time.Now()
+	transfer := from.Kind == model.Main && from.UserID != to.UserID
+	fromCategory, toCategory := model.InternalTransfer, model.InternalTransfer
+	if transfer {
+		fromCategory, toCategory = model.TransferOut, model.TransferIn
+	}
+	var fromBefore, toBefore model.Balance
+	err := b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		locked, err := b.lockBalances(ctx, tx, from.ID, to.ID)
+		if err != nil {
+			return err
+		}
+		from, to = locked[0], locked[1]
+		fromBefore, toBefore = *from, *to
+		if from.Balance < amount {
+			return errorcode.ErrInsufficientFunds
+		}
+		if transfer {
+			if err = b.checkTransfer(ctx, *from, amount, now); err != nil {
+				return err
+			}
+		}
+
+		fromActivity := fmt.Sprintf("move amount %f to %s", amount, walletName(*to))
+		if err = from.Debit(amount, fromCategory, fromActivity, actorID, now); err != nil {
+			return err
+		}
+		from.UpdatedBy = &actorID
+		from.UpdatedAt = &now
+
+		toActivity := fmt.Sprintf("move amount %f from %s", amount, walletName(*from))
+		if err = to.Credit(amount, toCategory, toActivity, actorID, now); err != nil {
+			return err
+		}
+		if err = b.limits.CheckBalance(*to); err != nil {
+			return err
+		}
+		to.UpdatedBy = &actorID
+		to.UpdatedAt = &now
+
+		if err = b.txSave(ctx, tx, *from); err != nil {
+			return err
+		}
+		return b.txSave(ctx, tx, *to)
+	})
+	if err != nil {
+		return err
+	}
+	b.recordTransfer(ctx, audit.BalanceMove, fromBefore, toBefore, *from, *to)
+	return nil
+}
+
+// getPocket returns the pocket only when it belongs to the user
+func (b balanceUsecase) getPocket(ctx context.Context, userID, pocketID uuid.UUID) (*model.Balance, error) {
+	pocket, err := b.balanceRepository.GetByID(ctx, pocketID)
+	if err != nil {
+		return nil, err
+	}
+	if pocket.UserID != userID || pocket.Kind != model.Pocket {
+		return nil, errorcode.ErrNotFound
+	}
+	return pocket, nil
+}
+
+func walletName(balance model.Balance) string {
+	if balance.Name != nil {
+		return fmt.Sprintf("%s %s", balance.Kind, *balance.Name)
+	}
+	return "main balance"
+}
@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+func (b balanceUsecase) CreateSharedWallet(ctx context.Context, wallet model.Balance, owner model.Member) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	mainBalance, err := b.balanceRepository.GetByUserID(ctx, wallet.UserID)
+	if err != nil {
+		return err
+	}
+	if err := mainBalance.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	if err := b.limits.CheckSharedWallet(*mainBalance); err != nil {
+		return err
+	}
+
+	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = b.balanceRepository.TxStore(ctx, tx, wallet); err != nil {
+			return err
+		}
+		for _, history := range wallet.Histories {
+			if err = b.balanceRepository.TxStoreBalanceHistory(ctx, tx, history); err != nil {
+				return err
+			}
+		}
+		if err = b.balanceRepository.TxStoreMember(ctx, tx, owner); err != nil {
+			return err
+		}
+		return
+	})
+}
+
+func (b balanceUsecase) FetchSharedWallets(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	return b.balanceRepository.FetchSharedByMemberUserID(ctx, userID)
+}
+
+// FetchInvitations returns shared wallets the user is invited to and has not accepted yet
+func (b balanceUsecase) FetchInvitations(ctx context.Context, userID uuid.UUID) (model.Balances, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	return b.balanceRepository.FetchInvitationsByUserID(ctx, userID)
+}
+
+func (b balanceUsecase) GetSharedWallet(ctx context.Context, userID, walletID uuid.UUID) (*model.Balance, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, _, err := b.getSharedWallet(ctx, userID, walletID)
+	return wallet, err
+}
+
+// DeleteSharedWallet closes an empty shared wallet. Members and histories are kept so everyone's contributions and
+// spending stay in the ledger, the wallet is no longer listed and can not move money
+func (b balanceUsecase) DeleteSharedWallet(ctx context.Context, userID, walletID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, member, err := b.getSharedWallet(ctx, userID, walletID)
+	if err != nil {
+		return err
+	}
+	if member.Role != model.Owner {
+		return errorcode.ErrForbidden
+	}
+	if err := wallet.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	if wallet.Balance != 0 {
+		return errorcode.ErrConflict
+	}
+
+	now := time.Now()
+	wallet.Status = base.Closed
+	wallet.UpdatedBy = &userID
+	wallet.UpdatedAt = &now
+	return b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return b.balanceRepository.TxUpdateStatus(ctx, tx, *wallet)
+	})
+}
+
+func (b balanceUsecase) GetSharedHistories(ctx context.Context, userID, walletID uuid.UUID, filter model.BalanceHistoryFilter) (model.BalanceHistories, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, _, err := b.getSharedWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return b.balanceRepository.FetchBalanceHistoriesByBalanceID(ctx, wallet.ID, filter)
+}
+
+func (b balanceUsecase) FetchMembers(ctx context.Context, userID, walletID uuid.UUID) (model.Members, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, _, err := b.getSharedWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return b.balanceRepository.FetchMembersByBalanceID(ctx, wallet.ID)
+}
+
+func (b balanceUsecase) GetMember(ctx context.Context, userID, walletID, memberUserID uuid.UUID) (*model.Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, _, err := b.getSharedWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return b.balanceRepository.GetMember(ctx, wallet.ID, memberUserID)
+}
+
+// AddMember lets the owner invite another registered user into the shared wallet, the invited user only becomes
+// member after accepting
+func (b balanceUsecase) AddMember(ctx context.Context, actorID uuid.UUID, member model.Member) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	if member.Role == model.Owner {
+		return errorcode.ErrBadParamInput
+	}
+	wallet, err := b.getSharedWalletAsOwner(ctx, actorID, member.BalanceID)
+	if err != nil {
+		return err
+	}
+	if err := wallet.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	memberBalance, err := b.balanceRepository.GetByUserID(ctx, member.UserID)
+	if err != nil {
+		return err
+	}
+	if err := memberBalance.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	if existed, _ := b.balanceRepository.GetMember(ctx, member.BalanceID, member.UserID); existed != nil {
+		return errorcode.ErrConflict
+	}
+
+	err = b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return b.balanceRepository.TxStoreMember(ctx, tx, member)
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{ActorID: &actorID, Action: audit.MemberAdd, TargetType: "balance", TargetID: member.BalanceID, After: audit.Snapshot(member)})
+	return nil
+}
+
+// AcceptMember lets the invited user accept the invitation into the shared wallet
+func (b balanceUsecase) AcceptMember(ctx context.Context, actorID, walletID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, member, err := b.getMembership(ctx, actorID, walletID)
+	if err != nil {
+		return err
+	}
+	if err := wallet.EffectiveStatus().CheckModify(); err != nil {
+		return err
+	}
+	if member.Accepted() {
+		return errorcode.ErrConflict
+	}
+
+	before := *member
+	now := time.Now()
+	member.AcceptedAt = &now
+	member.UpdatedBy = &actorID
+	member.UpdatedAt = &now
+	if err := b.balanceRepository.AcceptMember(ctx, *member); err != nil {
+		return err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{ActorID: &actorID, Action: audit.MemberAccept, TargetType: "balance", TargetID: walletID, Before: audit.Snapshot(before), After: audit.Snapshot(member)})
+	return nil
+}
+
+func (b balanceUsecase) UpdateMember(ctx context.Context, actorID uuid.UUID, member model.Member) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	if member.Role == model.Owner {
+		return errorcode.ErrBadParamInput
+	}
+	if _, err := b.getSharedWalletAsOwner(ctx, actorID, member.BalanceID); err != nil {
+		return err
+	}
+	existed, err := b.balanceRepository.GetMember(ctx, member.BalanceID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if existed.Role == model.Owner {
+		return errorcode.ErrForbidden
+	}
+
+	if err := b.balanceRepository.UpdateMember(ctx, member); err != nil {
+		return err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{ActorID: &actorID, Action: audit.MemberUpdate, TargetType: "balance", TargetID: member.BalanceID, Before: audit.Snapshot(existed), After: audit.Snapshot(member)})
+	return nil
+}
+
+// RemoveMember lets the owner remove a member, or a member leave the shared wallet. An invited user declines the
+// invitation by removing itself
+func (b balanceUsecase) RemoveMember(ctx context.Context, actorID, walletID, memberUserID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	_, actor, err := b.getMembership(ctx, actorID, walletID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != model.Owner && actorID != memberUserID {
+		return errorcode.ErrForbidden
+	}
+	existed, err := b.balanceRepository.GetMember(ctx, walletID, memberUserID)
+	if err != nil {
+		return err
+	}
+	if existed.Role == model.Owner {
+		return errorcode.ErrForbidden
+	}
+
+	if err := b.balanceRepository.DeleteMember(ctx, walletID, memberUserID); err != nil {
+		return err
+	}
+	audit.Record(ctx, b.auditor, audit.Event{ActorID: &actorID, Action: audit.MemberRemove, TargetType: "balance", TargetID: walletID, Before: audit.Snapshot(existed)})
+	return nil
+}
+
+// GetMemberSpendings returns spending breakdown per member within the period
+func (b balanceUsecase) GetMemberSpendings(ctx context.Context, actorID, walletID uuid.UUID, from, until time.Time) (model.MemberSpendings, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, err := b.getSharedWalletAsOwner(ctx, actorID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return b.balanceRepository.FetchMemberSpendings(ctx, wallet.ID, from, until)
+}
+
+// TransferFromShared transfers from the shared wallet to other user, recording the acting member in CreatedBy. Both
+// balances are read again and locked inside the transaction before the funds check
+func (b balanceUsecase) TransferFromShared(ctx context.Context, actorID, walletID, toUserID uuid.UUID, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+
+	wallet, err := b.authorizeSpending(ctx, actorID, walletID, model.TransferOperation, amount, now)
+	if err != nil {
+		return err
+	}
+	reciever, err := b.balanceRepository.GetByUserID(ctx, toUserID)
+	if err != nil {
+		return err
+	}
+
+	var walletBefore, recieverBefore model.Balance
+	err = b.balanceRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		locked, err := b.lockBalances(ctx, tx, wallet.ID, reciever.ID)
+		if err != nil {
+			return err
+		}
+		wallet, reciever = locked[0], locked[1]
+		walletBefore, recieverBefore = *wallet, *reciever
+
+		activity := fmt.Sprintf("transfer amount %f to %s by %s", amount, toUserID, actorID)
+		if err = wallet.Debit(amount, model.TransferOut, activity, actorID, now); err != nil {
+			return err
+		}
+		wallet.UpdatedBy = &actorID
+		wallet.UpdatedAt = &now
+
+		rActivity := fmt.Sprintf("retrieve amount %f from shared wallet %s", amount, *wallet.Name)
+		if err = reciever.Credit(amount, model.TransferIn, rActivity, reciever.UserID, now); err != nil {
+			return err
+		}
+		if err = b.limits.CheckBalance(*reciever); err != nil {
+			return err
+		}
+		reciever.UpdatedBy = &actorID
+		reciever.UpdatedAt = &now
+
+		if err = b.txSave(ctx, tx, *wallet); err != nil {
+			return err
+		}
+		return b.txSave(ctx, tx, *reciever)
+	})
+	if err != nil {
+		return err
+	}
+	b.recordTransfer(ctx, audit.BalanceTransfer, walletBefore, recieverBefore, *wallet, *reciever)
+	return nil
+}
+
+// ContributeToShared moves amount from the member main balance into the shared wallet
+func (b balanceUsecase) ContributeToShared(ctx context.Context, actorID, walletID uuid.UUID, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, _, err := b.getSharedWallet(ctx, actorID, walletID)
+	if err != nil {
+		return err
+	}
+	mainBalance, err := b.balanceRepository.GetByUserID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	return b.move(ctx, actorID, mainBalance, wallet, amount)
+}
+
+// WithdrawFromShared moves amount from the shared wallet into the member main balance
+func (b balanceUsecase) WithdrawFromShared(ctx context.Context, actorID, walletID uuid.UUID, amount float64) error {
+	ctx, cancel := context.WithTimeout(ctx, b.contextTimeout)
+	defer cancel()
+
+	wallet, err := b.authorizeSpending(ctx, actorID, walletID, model.WithdrawOperation, amount, time.Now())
+	if err != nil {
+		return err
+	}
+	mainBalance, err := b.balanceRepository.GetByUserID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+	return b.move(ctx, actorID, wallet, mainBalance, amount)
+}
+
+// authorizeSpending checks the member may perform the operation and is still within its spending limit. Transfers
+// count toward the tier limits of the member as if sent from its main balance
+func (b balanceUsecase) authorizeSpending(ctx context.Context, actorID, walletID uuid.UUID, op model.MemberOperation, amount float64, now time.Time) (*model.Balance, error) {
+	wallet, member, err := b.getSharedWallet(ctx, actorID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if !member.Can(op) {
+		return nil, errorcode.ErrForbidden
+	}
+	actorBalance, err := b.balanceRepository.GetByUserID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := actorBalance.EffectiveStatus().CheckDebit(); err != nil {
+		return nil, err
+	}
+	if op == model.TransferOperation {
+		if err := b.checkTransfer(ctx, *actorBalance, amount, now); err != nil {
+			return nil, err
+		}
+	}
+	spent, err := b.balanceRepository.SumMemberSpending(ctx, wallet.ID, actorID, model.SpendingPeriodStart(now))
+	if err != nil {
+		return nil, err
+	}
+	if !member.WithinLimit(spent, amount) {
+		return nil, errorcode.ErrLimitExceeded
+	}
+	return wallet, nil
+}
+
+// getSharedWallet returns the shared wallet and the membership of the user, hides wallets the user is not member of
+// or has not accepted to join yet
+func (b balanceUsecase) getSharedWallet(ctx context.Context, userID, walletID uuid.UUID) (*model.Balance, *model.Member, error) {
+	wallet, member, err := b.getMembership(ctx, userID, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !member.Accepted() {
+		return nil, nil, errorcode.ErrNotFound
+	}
+	return wallet, member, nil
+}
+
+// getMembership returns the shared wallet and the membership of the user, including a pending invitation
+func (b balanceUsecase) getMembership(ctx context.Context, userID, walletID uuid.UUID) (*model.Balance, *model.Member, error) {
+	wallet, err := b.balanceRepository.GetByID(ctx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wallet.Kind != model.Shared {
+		return nil, nil, errorcode.ErrNotFound
+	}
+	member, err := b.balanceRepository.GetMember(ctx, walletID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet, member, nil
+}
+
+func (b balanceUsecase) getSharedWalletAsOwner(ctx context.Context, userID, walletID uuid.UUID) (*model.Balance, error) {
+	wallet, member, err := b.getSharedWallet(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != model.Owner {
+		return nil, errorcode.ErrForbidden
+	}
+	return wallet, nil
+}
@@ -0,0 +1,137 @@
+package base
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidAccountStatus represent error when invalid AccountStatus
+var ErrInvalidAccountStatus = errors.New("InvalidAccountStatus")
+
+// AccountStatus is state of user or balance, ordered from the least to the most restrictive
+type AccountStatus int
+
+const (
+	// Active represent account without restriction
+	Active AccountStatus = 1 + iota
+	// DebitFrozen represent account which can receive but can not send money
+	DebitFrozen
+	// Frozen represent account which can neither receive nor send money
+	Frozen
+	// Closed represent account which is permanently closed
+	Closed
+)
+
+// AccountStatusFromString will converts a string to a AccountStatus, will return AccountStatus if string is
+// valid representation of AccountStatus, or error otherwise
+func AccountStatusFromString(s string) (res AccountStatus, err error) {
+	switch s {
+	case "active":
+		res = Active
+	case "debit_frozen":
+		res = DebitFrozen
+	case "frozen":
+		res = Frozen
+	case "closed":
+		res = Closed
+	default:
+		err = errors.WithMessagef(ErrInvalidAccountStatus, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for AccountStatus. With this when marshalling to json
+// AccountStatus will be shown as its string representation instead of int
+func (s AccountStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses AccountStatus from its string representation
+func (s *AccountStatus) UnmarshalText(text []byte) error {
+	st, err := AccountStatusFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*s = st
+	return nil
+}
+
+// String returns the string representation of AccountStatus
+func (s AccountStatus) String() string {
+	var res string
+	switch s {
+	case Active:
+		res = "active"
+	case DebitFrozen:
+		res = "debit_frozen"
+	case Frozen:
+		res = "frozen"
+	case Closed:
+		res = "closed"
+	}
+	return res
+}
+
+// Value transforms AccountStatus to its value for its column in database (MySQL)
+func (s AccountStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan transforms MySQL enum column value for status column to AccountStatus
+func (s *AccountStatus) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return s.UnmarshalText(b)
+}
+
+// CanTransitionTo reports whether status may change into next, closed is final
+func (s AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	if s == Closed || s == next {
+		return false
+	}
+	return next >= Active && next <= Closed
+}
+
+// CheckDebit returns error when money can not leave the account
+func (s AccountStatus) CheckDebit() error {
+	switch s {
+	case Active:
+		return nil
+	case Closed:
+		return errorcode.ErrAccountClosed
+	default:
+		return errorcode.ErrAccountFrozen
+	}
+}
+
+// CheckCredit returns error when money can not enter the account
+func (s AccountStatus) CheckCredit() error {
+	switch s {
+	case Active, DebitFrozen:
+		return nil
+	case Closed:
+		return errorcode.ErrAccountClosed
+	default:
+		return errorcode.ErrAccountFrozen
+	}
+}
+
+// CheckModify returns error when the account itself can not be changed by its owner
+func (s AccountStatus) CheckModify() error {
+	return s.CheckCredit()
+}
+
+// MostRestrictive returns the most restrictive of the given statuses
+func MostRestrictive(statuses ...AccountStatus) AccountStatus {
+	res := Active
+	for _, s := range statuses {
+		if s > res {
+			res = s
+		}
+	}
+	return res
+}
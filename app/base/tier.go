@@ -0,0 +1,82 @@
+package base
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTier represent error when invalid Tier
+var ErrInvalidTier = errors.New("InvalidTier")
+
+// Tier is kyc level of user, ordered from the least to the most verified. Higher tier unlocks higher limits
+type Tier int
+
+const (
+	// Basic represent user who has not proven its identity
+	Basic Tier = 1 + iota
+	// Verified represent user with approved identity document and selfie
+	Verified
+	// Premium represent verified user with approved proof of address
+	Premium
+)
+
+// TierFromString will converts a string to a Tier, will return Tier if string is valid representation of Tier,
+// or error otherwise
+func TierFromString(s string) (res Tier, err error) {
+	switch s {
+	case "basic":
+		res = Basic
+	case "verified":
+		res = Verified
+	case "premium":
+		res = Premium
+	default:
+		err = errors.WithMessagef(ErrInvalidTier, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for Tier. With this when marshalling to json
+// Tier will be shown as its string representation instead of int
+func (t Tier) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses Tier from its string representation
+func (t *Tier) UnmarshalText(text []byte) error {
+	res, err := TierFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*t = res
+	return nil
+}
+
+// String returns the string representation of Tier
+func (t Tier) String() string {
+	var res string
+	switch t {
+	case Basic:
+		res = "basic"
+	case Verified:
+		res = "verified"
+	case Premium:
+		res = "premium"
+	}
+	return res
+}
+
+// Value transforms Tier to its value for its column in database (MySQL)
+func (t Tier) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+// Scan transforms MySQL enum column value for tier column to Tier
+func (t *Tier) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return t.UnmarshalText(b)
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/export"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type exportHandler struct {
+	exportUsecase export.Usecase
+}
+
+func NewExportHandler(app *bootstrap.Bootstrap, exportUsecase export.Usecase) {
+	handler := exportHandler{exportUsecase: exportUsecase}
+	api := app.Group("/api")
+	api.Post("/users/export", middleware.Protected(), middleware.CheckSession, handler.Request)
+	api.Get("/users/export/:id", middleware.Protected(), middleware.CheckSession, handler.Get)
+	// The download link is signed, so it works without access token
+	api.Get("/users/export/:id/download", handler.Download)
+}
+
+func (e exportHandler) Request(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := e.exportUsecase.Request(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusAccepted).JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (e exportHandler) Get(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := e.exportUsecase.Get(ctx.Context(), *userID, id)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// Download streams the archive to holder of the signed token query string
+func (e exportHandler) Download(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, content, err := e.exportUsecase.Open(ctx.Context(), id, ctx.Query("token"))
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Attachment("export-" + data.ID.String() + ".zip")
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	// fasthttp closes the stream once the body is written
+	ctx.SendStream(content, int(data.Size))
+}
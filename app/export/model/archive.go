@@ -0,0 +1,255 @@
+package model
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	_accountModel "github.com/fajardm/ewallet-example/app/account/model"
+	_authModel "github.com/fajardm/ewallet-example/app/auth/model"
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	_kycModel "github.com/fajardm/ewallet-example/app/kyc/model"
+	_loginModel "github.com/fajardm/ewallet-example/app/login/model"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/audit"
+	uuid "github.com/satori/go.uuid"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Section is one dataset of the archive, written as <Name>.json from Records and <Name>.csv from Header and Rows
+type Section struct {
+	Name    string
+	Records interface{}
+	Header  []string
+	Rows    [][]string
+}
+
+// WriteArchive writes every section as json and csv file into zip archive
+func WriteArchive(w io.Writer, sections []Section) error {
+	archive := zip.NewWriter(w)
+	for _, section := range sections {
+		f, err := archive.Create(section.Name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.Records); err != nil {
+			return err
+		}
+
+		f, err = archive.Create(section.Name + ".csv")
+		if err != nil {
+			return err
+		}
+		writer := csv.NewWriter(f)
+		if err := writer.Write(section.Header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(section.Rows); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func ProfileSection(user _userModel.User) Section {
+	return Section{
+		Name:    "profile",
+		Records: user,
+		Header:  []string{"id", "username", "email", "mobile_phone", "status", "tier", "email_verified_at", "phone_verified_at", "created_at", "updated_at"},
+		Rows: [][]string{{
+			user.ID.String(), user.Username, user.Email, user.MobilePhone, user.Status.String(), user.Tier.String(),
+			formatTime(user.EmailVerifiedAt), formatTime(user.PhoneVerifiedAt), formatTime(&user.CreatedAt), formatTime(user.UpdatedAt),
+		}},
+	}
+}
+
+func BalancesSection(balances _balanceModel.Balances) Section {
+	rows := make([][]string, 0, len(balances))
+	for _, b := range balances {
+		rows = append(rows, []string{
+			b.ID.String(), b.Kind.String(), formatString(b.Name), formatFloat(b.Balance), b.Status.String(),
+			formatFloat(b.OverdraftLimit), formatFloat(b.OverdraftInterest), formatTime(&b.CreatedAt),
+		})
+	}
+	return Section{
+		Name:    "balances",
+		Records: balances,
+		Header:  []string{"id", "kind", "name", "balance", "status", "overdraft_limit", "overdraft_interest", "created_at"},
+		Rows:    rows,
+	}
+}
+
+func BalanceHistoriesSection(histories _balanceModel.BalanceHistories) Section {
+	rows := make([][]string, 0, len(histories))
+	for _, h := range histories {
+		rows = append(rows, []string{
+			h.ID.String(), h.BalanceID.String(), h.Type.String(), h.Category.String(), formatFloat(h.BalanceBefore),
+			formatFloat(h.BalanceAfter), formatString(h.Activity), formatString(h.IP), formatString(h.UserAgent), formatTime(&h.CreatedAt),
+		})
+	}
+	return Section{
+		Name:    "balance_histories",
+		Records: histories,
+		Header:  []string{"id", "balance_id", "type", "category", "balance_before", "balance_after", "activity", "ip", "user_agent", "created_at"},
+		Rows:    rows,
+	}
+}
+
+// sessionRecord shows session fields hidden from the session list, the export covers revoked sessions too
+type sessionRecord struct {
+	ID         uuid.UUID  `json:"id"`
+	DeviceName string     `json:"device_name"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func SessionsSection(sessions _authModel.Sessions) Section {
+	records := make([]sessionRecord, 0, len(sessions))
+	rows := make([][]string, 0, len(sessions))
+	for _, s := range sessions {
+		records = append(records, sessionRecord{
+			ID:         s.ID,
+			DeviceName: s.DeviceName,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			RevokedAt:  s.RevokedAt,
+		})
+		rows = append(rows, []string{
+			s.ID.String(), s.DeviceName, s.UserAgent, s.IP, formatTime(&s.CreatedAt), formatTime(&s.LastSeenAt),
+			formatTime(&s.ExpiresAt), formatTime(s.RevokedAt),
+		})
+	}
+	return Section{
+		Name:    "sessions",
+		Records: records,
+		Header:  []string{"id", "device_name", "user_agent", "ip", "created_at", "last_seen_at", "expires_at", "revoked_at"},
+		Rows:    rows,
+	}
+}
+
+// KYCSection lists submissions with metadata of their documents, the documents themselves are not exported
+func KYCSection(submissions _kycModel.Submissions) Section {
+	rows := make([][]string, 0)
+	for _, s := range submissions {
+		for _, d := range s.Documents {
+			rows = append(rows, []string{
+				s.ID.String(), s.Tier.String(), s.Status.String(), formatTime(&s.CreatedAt), formatTime(s.ReviewedAt),
+				formatString(s.ReviewNote), d.ID.String(), d.Type.String(), d.ContentType, strconv.FormatInt(d.Size, 10),
+			})
+		}
+	}
+	return Section{
+		Name:    "kyc_submissions",
+		Records: submissions,
+		Header:  []string{"submission_id", "tier", "status", "submitted_at", "reviewed_at", "review_note", "document_id", "document_type", "content_type", "size"},
+		Rows:    rows,
+	}
+}
+
+// StatusChangesSection lists status changes of the user and its balances
+func StatusChangesSection(changes _accountModel.StatusChanges) Section {
+	rows := make([][]string, 0, len(changes))
+	for _, c := range changes {
+		rows = append(rows, []string{
+			c.ID.String(), c.TargetType.String(), c.TargetID.String(), c.StatusFrom.String(), c.StatusTo.String(),
+			c.Reason, c.CreatedBy.String(), formatTime(&c.CreatedAt),
+		})
+	}
+	return Section{
+		Name:    "status_changes",
+		Records: changes,
+		Header:  []string{"id", "target_type", "target_id", "status_from", "status_to", "reason", "created_by", "created_at"},
+		Rows:    rows,
+	}
+}
+
+// LoginsSection lists login attempts of the user
+func LoginsSection(attempts _loginModel.Attempts) Section {
+	rows := make([][]string, 0, len(attempts))
+	for _, a := range attempts {
+		rows = append(rows, []string{
+			a.ID.String(), formatString(a.Identifier), strconv.FormatBool(a.Success), formatString(a.Reason), a.IP,
+			a.UserAgent, a.Fingerprint, formatString(a.Country), formatTime(&a.CreatedAt),
+		})
+	}
+	return Section{
+		Name:    "logins",
+		Records: attempts,
+		Header:  []string{"id", "identifier", "success", "reason", "ip", "user_agent", "fingerprint", "country", "created_at"},
+		Rows:    rows,
+	}
+}
+
+// auditRecord leaves snapshots out, they may hold data of other users such as the receiver of a transfer
+type auditRecord struct {
+	ID         uuid.UUID  `json:"id"`
+	ActorID    *uuid.UUID `json:"actor_id"`
+	Action     string     `json:"action"`
+	TargetType string     `json:"target_type"`
+	TargetID   uuid.UUID  `json:"target_id"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	RequestID  string     `json:"request_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AuditSection lists audit events the user did or was target of
+func AuditSection(events audit.Events) Section {
+	records := make([]auditRecord, 0, len(events))
+	rows := make([][]string, 0, len(events))
+	for _, e := range events {
+		records = append(records, auditRecord{
+			ID:         e.ID,
+			ActorID:    e.ActorID,
+			Action:     e.Action,
+			TargetType: e.TargetType,
+			TargetID:   e.TargetID,
+			IP:         e.IP,
+			UserAgent:  e.UserAgent,
+			RequestID:  e.RequestID,
+			CreatedAt:  e.CreatedAt,
+		})
+		actorID := ""
+		if e.ActorID != nil {
+			actorID = e.ActorID.String()
+		}
+		rows = append(rows, []string{
+			e.ID.String(), actorID, e.Action, e.TargetType, e.TargetID.String(), e.IP, e.UserAgent, e.RequestID,
+			formatTime(&e.CreatedAt),
+		})
+	}
+	return Section{
+		Name:    "audit_events",
+		Records: records,
+		Header:  []string{"id", "actor_id", "action", "target_type", "target_id", "ip", "user_agent", "request_id", "created_at"},
+		Rows:    rows,
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidStatus represent error when invalid Status
+var ErrInvalidStatus = errors.New("InvalidStatus")
+
+// Status is state of data export
+type Status int
+
+const (
+	// Pending represent export whose archive is being built
+	Pending Status = 1 + iota
+	// Ready represent export whose archive can be downloaded until it expires
+	Ready
+	// Failed represent export whose archive could not be built, the user may request again
+	Failed
+)
+
+// StatusFromString will converts a string to a Status, will return Status if string is valid representation of
+// Status, or error otherwise
+func StatusFromString(s string) (res Status, err error) {
+	switch s {
+	case "pending":
+		res = Pending
+	case "ready":
+		res = Ready
+	case "failed":
+		res = Failed
+	default:
+		err = errors.WithMessagef(ErrInvalidStatus, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for Status. With this when marshalling to json Status will be shown as
+// its string representation instead of int
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses Status from its string representation
+func (s *Status) UnmarshalText(text []byte) error {
+	st, err := StatusFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*s = st
+	return nil
+}
+
+// String returns the string representation of Status
+func (s Status) String() string {
+	var res string
+	switch s {
+	case Pending:
+		res = "pending"
+	case Ready:
+		res = "ready"
+	case Failed:
+		res = "failed"
+	}
+	return res
+}
+
+// Value transforms Status to its value for its column in database (MySQL)
+func (s Status) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan transforms MySQL enum column value for status column to Status
+func (s *Status) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return s.UnmarshalText(b)
+}
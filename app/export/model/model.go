@@ -0,0 +1,75 @@
+package model
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Policy configures data exports
+type Policy struct {
+	// BaseURL is public address of the api used to build download link
+	BaseURL string
+	// TTL is how long the archive and its download link stay available
+	TTL time.Duration
+	// BuildTimeout bounds building one archive, pending export older than this is considered dead
+	BuildTimeout time.Duration
+	// CleanupInterval is how often expired archives are deleted, zero disables the cleanup
+	CleanupInterval time.Duration
+}
+
+// Export is archive of every personal data held about the user
+type Export struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Status      Status     `json:"status"`
+	Size        int64      `json:"size"`
+	BlobKey     string     `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	DownloadURL string     `json:"download_url,omitempty"`
+}
+
+// Exports is list of export model
+type Exports []Export
+
+// BlobPrefix is the prefix of blob keys of every archive of the user
+func BlobPrefix(userID uuid.UUID) string {
+	return "exports/" + userID.String()
+}
+
+func NewExport(userID uuid.UUID, at time.Time) *Export {
+	id := uuid.NewV4()
+	return &Export{
+		ID:        id,
+		UserID:    userID,
+		Status:    Pending,
+		BlobKey:   BlobPrefix(userID) + "/" + id.String() + ".zip",
+		CreatedAt: at,
+	}
+}
+
+// Building reports whether the archive is still being built, so another export is not started meanwhile
+func (e Export) Building(policy Policy, now time.Time) bool {
+	return e.Status == Pending && now.Before(e.CreatedAt.Add(policy.BuildTimeout))
+}
+
+// Downloadable reports whether the archive is built and not expired
+func (e Export) Downloadable(now time.Time) bool {
+	return e.Status == Ready && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// Complete marks the archive built, it expires after the policy TTL
+func (e *Export) Complete(size int64, policy Policy, at time.Time) {
+	expiresAt := at.Add(policy.TTL)
+	e.Status = Ready
+	e.Size = size
+	e.CompletedAt = &at
+	e.ExpiresAt = &expiresAt
+}
+
+// Fail marks the archive could not be built
+func (e *Export) Fail(at time.Time) {
+	e.Status = Failed
+	e.CompletedAt = &at
+}
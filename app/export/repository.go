@@ -0,0 +1,18 @@
+package export
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/export/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the export's repository contract
+type Repository interface {
+	Store(context.Context, model.Export) error
+	GetByID(context.Context, uuid.UUID) (*model.Export, error)
+	GetLatestByUserID(context.Context, uuid.UUID) (*model.Export, error)
+	Complete(context.Context, model.Export) error
+	FetchExpired(ctx context.Context, now time.Time, limit int) (model.Exports, error)
+	Delete(context.Context, uuid.UUID) error
+}
@@ -0,0 +1,121 @@
+package mysql
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/export"
+	"github.com/fajardm/ewallet-example/app/export/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table data_exports
+	querySelectExport = `
+		SELECT 
+			id,
+			user_id,
+			status,
+			size,
+			blob_key,
+			created_at,
+			completed_at,
+			expires_at
+		FROM data_exports
+	`
+	queryInsertExport = `
+		INSERT INTO data_exports (
+			id,
+			user_id,
+			status,
+			size,
+			blob_key,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+	queryCompleteExport = `
+		UPDATE data_exports SET status=?, size=?, completed_at=?, expires_at=? WHERE id=? AND status=?
+	`
+	queryDeleteExport = `
+		DELETE FROM data_exports WHERE id=?
+	`
+)
+
+type exportRepository struct {
+	db *database.MySQL
+}
+
+func NewExportRepository(conn *database.MySQL) export.Repository {
+	return &exportRepository{db: conn}
+}
+
+func (e exportRepository) Store(ctx context.Context, ex model.Export) (err error) {
+	_, err = e.db.ExecContext(ctx, queryInsertExport, ex.ID, ex.UserID, ex.Status, ex.Size, ex.BlobKey, ex.CreatedAt)
+	return
+}
+
+func (e exportRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	return e.get(ctx, querySelectExport+" WHERE id=?", id)
+}
+
+func (e exportRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*model.Export, error) {
+	return e.get(ctx, querySelectExport+" WHERE user_id=? ORDER BY created_at DESC LIMIT 1", userID)
+}
+
+// Complete records the outcome of pending export, returns errorcode.ErrConflict when the export is not pending
+func (e exportRepository) Complete(ctx context.Context, ex model.Export) error {
+	res, err := e.db.ExecContext(ctx, queryCompleteExport, ex.Status, ex.Size, ex.CompletedAt, ex.ExpiresAt, ex.ID, model.Pending)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrConflict
+	}
+	return nil
+}
+
+// FetchExpired returns exports whose archive expired, and failed or dead pending exports older than a day
+func (e exportRepository) FetchExpired(ctx context.Context, now time.Time, limit int) (model.Exports, error) {
+	q := querySelectExport + " WHERE expires_at<? OR (expires_at IS NULL AND created_at<?) ORDER BY created_at LIMIT ?"
+	return e.fetchContext(ctx, q, now, now.Add(-24*time.Hour), limit)
+}
+
+func (e exportRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	_, err = e.db.ExecContext(ctx, queryDeleteExport, id)
+	return
+}
+
+func (e exportRepository) get(ctx context.Context, query string, args ...interface{}) (*model.Export, error) {
+	list, err := e.fetchContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errorcode.ErrNotFound
+	}
+	return &list[0], nil
+}
+
+func (e exportRepository) fetchContext(ctx context.Context, query string, args ...interface{}) (model.Exports, error) {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Exports, 0)
+	for rows.Next() {
+		r := model.Export{}
+		err = rows.Scan(&r.ID, &r.UserID, &r.Status, &r.Size, &r.BlobKey, &r.CreatedAt, &r.CompletedAt, &r.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
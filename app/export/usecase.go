@@ -0,0 +1,15 @@
+package export
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/export/model"
+	uuid "github.com/satori/go.uuid"
+	"io"
+)
+
+// Usecase represent the export's usecase contract
+type Usecase interface {
+	Request(ctx context.Context, userID uuid.UUID) (*model.Export, error)
+	Get(ctx context.Context, userID, id uuid.UUID) (*model.Export, error)
+	Open(ctx context.Context, id uuid.UUID, token string) (*model.Export, io.ReadCloser, error)
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fajardm/ewallet-example/app/account"
+	_accountModel "github.com/fajardm/ewallet-example/app/account/model"
+	"github.com/fajardm/ewallet-example/app/audit"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/balance"
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/export"
+	"github.com/fajardm/ewallet-example/app/export/model"
+	"github.com/fajardm/ewallet-example/app/kyc"
+	"github.com/fajardm/ewallet-example/app/login"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/blob"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const downloadTokenPurpose = "data_export"
+
+type exportUsecase struct {
+	exportRepository  export.Repository
+	userRepository    user.Repository
+	balanceRepository balance.Repository
+	authRepository    auth.Repository
+	kycRepository     kyc.Repository
+	accountRepository account.Repository
+	auditRepository   audit.Repository
+	loginRepository   login.Repository
+	policy            model.Policy
+	contextTimeout    time.Duration
+}
+
+// NewExportUsecase creates the usecase, expired archives are deleted every policy CleanupInterval
+func NewExportUsecase(exportRepository export.Repository, userRepository user.Repository, balanceRepository balance.Repository, authRepository auth.Repository, kycRepository kyc.Repository, accountRepository account.Repository, auditRepository audit.Repository, loginRepository login.Repository, policy model.Policy, contextTimeout time.Duration) export.Usecase {
+	if policy.TTL <= 0 {
+		policy.TTL = time.Hour * 24
+	}
+	if policy.BuildTimeout <= 0 {
+		policy.BuildTimeout = time.Minute * 5
+	}
+	policy.BaseURL = strings.TrimRight(policy.BaseURL, "/")
+	e := exportUsecase{
+		exportRepository:  exportRepository,
+		userRepository:    userRepository,
+		balanceRepository: balanceRepository,
+		authRepository:    authRepository,
+		kycRepository:     kycRepository,
+		accountRepository: accountRepository,
+		auditRepository:   auditRepository,
+		loginRepository:   loginRepository,
+		policy:            policy,
+		contextTimeout:    contextTimeout,
+	}
+	if policy.CleanupInterval > 0 {
+		go e.cleanup(policy.CleanupInterval)
+	}
+	return e
+}
+
+// Request starts building the archive in background and returns the pending export right away. Returns
+// errorcode.ErrConflict while the previous export of the user is still being built
+func (e exportUsecase) Request(ctx context.Context, userID uuid.UUID) (*model.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.contextTimeout)
+	defer cancel()
+
+	existed, err := e.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existed.Deleted() {
+		return nil, errorcode.ErrAccountClosed
+	}
+	now := time.Now()
+	latest, err := e.exportRepository.GetLatestByUserID(ctx, userID)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	if latest != nil && latest.Building(e.policy, now) {
+		return nil, errorcode.ErrConflict
+	}
+
+	res := model.NewExport(userID, now)
+	if err := e.exportRepository.Store(ctx, *res); err != nil {
+		return nil, err
+	}
+	go e.build(*res)
+	return res, nil
+}
+
+// build collects the data and stores the archive, the outcome is recorded on the export
+func (e exportUsecase) build(ex model.Export) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.policy.BuildTimeout)
+	defer cancel()
+
+	var archive bytes.Buffer
+	err := e.write(ctx, ex.UserID, &archive)
+	if err == nil {
+		size := int64(archive.Len())
+		if err = blob.Blob().Put(ctx, ex.BlobKey, &archive); err == nil {
+			ex.Complete(size, e.policy, time.Now())
+		}
+	}
+	if err != nil {
+		log.WithError(err).WithField("export_id", ex.ID).Error("build data export")
+		ex.Fail(time.Now())
+	}
+	if err := e.exportRepository.Complete(ctx, ex); err != nil {
+		log.WithError(err).WithField("export_id", ex.ID).Error("complete data export")
+	}
+}
+
+// write collects every data held about the user into zip archive
+func (e exportUsecase) write(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	u, err := e.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	balances, err := e.balanceRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	changes, err := e.accountRepository.FetchStatusChanges(ctx, _accountModel.UserTarget, userID)
+	if err != nil {
+		return err
+	}
+	histories := make(_balanceModel.BalanceHistories, 0)
+	for _, b := range balances {
+		list, err := e.balanceRepository.FetchAllBalanceHistoriesByBalanceID(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		histories = append(histories, list...)
+
+		balanceChanges, err := e.accountRepository.FetchStatusChanges(ctx, _accountModel.BalanceTarget, b.ID)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, balanceChanges...)
+	}
+	sessions, err := e.authRepository.FetchSessionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	submissions, err := e.kycRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	events, err := e.auditRepository.FetchByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	logins, err := e.loginRepository.FetchAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return model.WriteArchive(w, []model.Section{
+		model.ProfileSection(*u),
+		model.BalancesSection(balances),
+		model.BalanceHistoriesSection(histories),
+		model.SessionsSection(sessions),
+		model.KYCSection(submissions),
+		model.StatusChangesSection(changes),
+		model.AuditSection(events),
+		model.LoginsSection(logins),
+	})
+}
+
+// Get returns export of the user, with signed download link once the archive is ready
+func (e exportUsecase) Get(ctx context.Context, userID, id uuid.UUID) (*model.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.contextTimeout)
+	defer cancel()
+
+	res, err := e.exportRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.UserID != userID {
+		return nil, errorcode.ErrNotFound
+	}
+	now := time.Now()
+	if res.Downloadable(now) {
+		t, err := token.SignPurpose(downloadTokenPurpose, jwt.MapClaims{"sub": res.ID.String()}, res.ExpiresAt.Sub(now))
+		if err != nil {
+			return nil, err
+		}
+		res.DownloadURL = e.policy.BaseURL + "/api/users/export/" + res.ID.String() + "/download?token=" + url.QueryEscape(t)
+	}
+	return res, nil
+}
+
+// Open verifies the signed download token and returns the archive content, the caller must close the content
+func (e exportUsecase) Open(ctx context.Context, id uuid.UUID, t string) (*model.Export, io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.contextTimeout)
+	defer cancel()
+
+	claims, err := token.ParsePurpose(downloadTokenPurpose, t)
+	if err != nil {
+		return nil, nil, errorcode.ErrInvalidCredential
+	}
+	if sub, _ := claims["sub"].(string); sub != id.String() {
+		return nil, nil, errorcode.ErrInvalidCredential
+	}
+	res, err := e.exportRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !res.Downloadable(time.Now()) {
+		return nil, nil, errorcode.ErrNotFound
+	}
+	content, err := blob.Blob().Open(ctx, res.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res, content, nil
+}
+
+func (e exportUsecase) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		e.deleteExpired(now)
+	}
+}
+
+// deleteExpired removes expired archives along with their exports
+func (e exportUsecase) deleteExpired(now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.contextTimeout)
+	defer cancel()
+
+	expired, err := e.exportRepository.FetchExpired(ctx, now, 100)
+	if err != nil {
+		log.WithError(err).Warn("fetch expired data exports")
+		return
+	}
+	for _, ex := range expired {
+		if err := blob.Blob().Delete(ctx, ex.BlobKey); err != nil {
+			log.WithError(err).WithField("export_id", ex.ID).Warn("delete data export archive")
+			continue
+		}
+		if err := e.exportRepository.Delete(ctx, ex.ID); err != nil {
+			log.WithError(err).WithField("export_id", ex.ID).Warn("delete data export")
+		}
+	}
+}
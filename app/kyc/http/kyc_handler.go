@@ -0,0 +1,185 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/kyc"
+	"github.com/fajardm/ewallet-example/app/kyc/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type kycHandler struct {
+	kycUsecase kyc.Usecase
+}
+
+func NewKYCHandler(app *bootstrap.Bootstrap, kycUsecase kyc.Usecase) {
+	handler := kycHandler{kycUsecase: kycUsecase}
+	api := app.Group("/api")
+	api.Post("/users/kyc", middleware.Protected(), middleware.CheckSession, handler.Submit)
+	api.Get("/users/kyc", middleware.Protected(), middleware.CheckSession, handler.GetStatus)
+	app.Admin.Get("/kyc", middleware.Require(_roleModel.KYCReview), handler.Fetch)
+	app.Admin.Get("/kyc/:id", middleware.Require(_roleModel.KYCReview), handler.Get)
+	app.Admin.Get("/kyc/:id/documents/:document_id", middleware.Require(_roleModel.KYCReview), handler.GetDocument)
+	app.Admin.Post("/kyc/:id/review", middleware.Require(_roleModel.KYCReview), handler.Review)
+}
+
+// Submit takes multipart form with tier field and one file per document type, the field name of a file is its
+// document type
+func (k kycHandler) Submit(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	tier, err := base.TierFromString(ctx.FormValue("tier"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	uploads := make([]model.Upload, 0)
+	for _, t := range model.DocumentTypes {
+		files := form.File[t.String()]
+		if len(files) == 0 {
+			continue
+		}
+		if len(files) > 1 {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+			return
+		}
+		f, err := files[0].Open()
+		if err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+			return
+		}
+		defer f.Close()
+		upload, err := model.NewUpload(t, files[0].Size, f)
+		if err != nil {
+			ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+			return
+		}
+		uploads = append(uploads, *upload)
+	}
+
+	data, err := k.kycUsecase.Submit(ctx.Context(), *userID, tier, uploads)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (k kycHandler) GetStatus(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := k.kycUsecase.GetStatus(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// Fetch returns the review queue, pending submissions unless status query string tells otherwise
+func (k kycHandler) Fetch(ctx *fiber.Ctx) {
+	status := model.Pending
+	if s := ctx.Query("status"); s != "" {
+		st, err := model.SubmissionStatusFromString(s)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+			return
+		}
+		status = st
+	}
+
+	data, err := k.kycUsecase.FetchByStatus(ctx.Context(), status)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (k kycHandler) Get(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := k.kycUsecase.GetByID(ctx.Context(), id)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// GetDocument streams the document content with the content type detected on upload
+func (k kycHandler) GetDocument(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	documentID, err := uuid.FromString(ctx.Params("document_id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	document, content, err := k.kycUsecase.OpenDocument(ctx.Context(), id, documentID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Set(fiber.HeaderContentType, document.ContentType)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	// fasthttp closes the stream once the body is written
+	ctx.SendStream(content, int(document.Size))
+}
+
+func (k kycHandler) Review(ctx *fiber.Ctx) {
+	reviewerID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.ReviewInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	status, _ := model.SubmissionStatusFromString(input.Status)
+
+	data, err := k.kycUsecase.Review(ctx.Context(), *reviewerID, id, status, input.Note)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
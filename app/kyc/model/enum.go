@@ -0,0 +1,178 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidSubmissionStatus represent error when invalid SubmissionStatus
+var ErrInvalidSubmissionStatus = errors.New("InvalidSubmissionStatus")
+
+// SubmissionStatus is state of kyc submission, only pending submission can be reviewed
+type SubmissionStatus int
+
+const (
+	// Pending represent submission waiting in review queue
+	Pending SubmissionStatus = 1 + iota
+	// Approved represent submission accepted, the user is moved to the submitted tier
+	Approved
+	// Rejected represent submission refused, new submission is accepted after the rejection cooldown
+	Rejected
+	// ResubmissionRequired represent submission with unreadable or incomplete document, new submission is
+	// accepted right away
+	ResubmissionRequired
+)
+
+// SubmissionStatusFromString will converts a string to a SubmissionStatus, will return SubmissionStatus if string
+// is valid representation of SubmissionStatus, or error otherwise
+func SubmissionStatusFromString(s string) (res SubmissionStatus, err error) {
+	switch s {
+	case "pending":
+		res = Pending
+	case "approved":
+		res = Approved
+	case "rejected":
+		res = Rejected
+	case "resubmission_required":
+		res = ResubmissionRequired
+	default:
+		err = errors.WithMessagef(ErrInvalidSubmissionStatus, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for SubmissionStatus. With this when marshalling to json
+// SubmissionStatus will be shown as its string representation instead of int
+func (s SubmissionStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses SubmissionStatus from its string representation
+func (s *SubmissionStatus) UnmarshalText(text []byte) error {
+	st, err := SubmissionStatusFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*s = st
+	return nil
+}
+
+// String returns the string representation of SubmissionStatus
+func (s SubmissionStatus) String() string {
+	var res string
+	switch s {
+	case Pending:
+		res = "pending"
+	case Approved:
+		res = "approved"
+	case Rejected:
+		res = "rejected"
+	case ResubmissionRequired:
+		res = "resubmission_required"
+	}
+	return res
+}
+
+// Value transforms SubmissionStatus to its value for its column in database (MySQL)
+func (s SubmissionStatus) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan transforms MySQL enum column value for status column to SubmissionStatus
+func (s *SubmissionStatus) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return s.UnmarshalText(b)
+}
+
+// CanTransitionTo reports whether the submission may be reviewed into next, every review outcome is final
+func (s SubmissionStatus) CanTransitionTo(next SubmissionStatus) bool {
+	return s == Pending && (next == Approved || next == Rejected || next == ResubmissionRequired)
+}
+
+// ErrInvalidDocumentType represent error when invalid DocumentType
+var ErrInvalidDocumentType = errors.New("InvalidDocumentType")
+
+// DocumentType is kind of document attached to kyc submission
+type DocumentType int
+
+const (
+	// IDCard represent national identity card
+	IDCard DocumentType = 1 + iota
+	// Passport represent passport, accepted in place of identity card
+	Passport
+	// Selfie represent photo of the user holding its identity document
+	Selfie
+	// ProofOfAddress represent utility bill or bank statement showing the address
+	ProofOfAddress
+)
+
+// DocumentTypes lists every document type, the string representation is also the multipart field name
+var DocumentTypes = []DocumentType{IDCard, Passport, Selfie, ProofOfAddress}
+
+// DocumentTypeFromString will converts a string to a DocumentType, will return DocumentType if string is valid
+// representation of DocumentType, or error otherwise
+func DocumentTypeFromString(s string) (res DocumentType, err error) {
+	switch s {
+	case "id_card":
+		res = IDCard
+	case "passport":
+		res = Passport
+	case "selfie":
+		res = Selfie
+	case "proof_of_address":
+		res = ProofOfAddress
+	default:
+		err = errors.WithMessagef(ErrInvalidDocumentType, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for DocumentType. With this when marshalling to json
+// DocumentType will be shown as its string representation instead of int
+func (d DocumentType) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses DocumentType from its string representation
+func (d *DocumentType) UnmarshalText(text []byte) error {
+	res, err := DocumentTypeFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*d = res
+	return nil
+}
+
+// String returns the string representation of DocumentType
+func (d DocumentType) String() string {
+	var res string
+	switch d {
+	case IDCard:
+		res = "id_card"
+	case Passport:
+		res = "passport"
+	case Selfie:
+		res = "selfie"
+	case ProofOfAddress:
+		res = "proof_of_address"
+	}
+	return res
+}
+
+// Value transforms DocumentType to its value for its column in database (MySQL)
+func (d DocumentType) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan transforms MySQL enum column value for type column to DocumentType
+func (d *DocumentType) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	return d.UnmarshalText(b)
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/validator"
+	"github.com/pkg/errors"
+)
+
+// ErrNoteRequired represent error when submission is turned down without telling the user why
+var ErrNoteRequired = errors.New("note is required unless approved")
+
+type ReviewInput struct {
+	Status string  `json:"status" validate:"required,oneof=approved rejected resubmission_required"`
+	Note   *string `json:"note" validate:"omitempty,max=256"`
+}
+
+func (i ReviewInput) Validate() error {
+	if err := validator.Validate().Struct(i); err != nil {
+		return err
+	}
+	if i.Status != "approved" && (i.Note == nil || *i.Note == "") {
+		return ErrNoteRequired
+	}
+	return nil
+}
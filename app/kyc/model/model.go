@@ -0,0 +1,186 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	uuid "github.com/satori/go.uuid"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Policy limits kyc submissions
+type Policy struct {
+	// MaxDocumentSize is the largest accepted document in bytes
+	MaxDocumentSize int64
+	// RejectionCooldown is how long the user waits after rejection before submitting again
+	RejectionCooldown time.Duration
+}
+
+// allowedContentTypes are content types accepted as document, detected from the content instead of trusting the client
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// requirements lists documents needed to reach the tier, any document of each group is enough
+var requirements = map[base.Tier][][]DocumentType{
+	base.Verified: {{IDCard, Passport}, {Selfie}},
+	base.Premium:  {{IDCard, Passport}, {Selfie}, {ProofOfAddress}},
+}
+
+// Submission is request of the user to be moved to higher tier, backed by documents
+type Submission struct {
+	base.Model
+	UserID     uuid.UUID        `json:"user_id"`
+	Tier       base.Tier        `json:"tier"`
+	Status     SubmissionStatus `json:"status"`
+	ReviewedBy *uuid.UUID       `json:"reviewed_by"`
+	ReviewedAt *time.Time       `json:"reviewed_at"`
+	ReviewNote *string          `json:"review_note"`
+	Documents  Documents        `json:"documents,omitempty"`
+}
+
+// Submissions is list of submission model
+type Submissions []Submission
+
+// NewSubmission creates pending submission of the uploads, each upload gets the blob key it is stored under.
+// Returns errorcode.ErrBadParamInput when a document is too large, repeated or missing for the tier
+func NewSubmission(userID uuid.UUID, tier base.Tier, uploads []Upload, policy Policy, at time.Time) (*Submission, error) {
+	groups, ok := requirements[tier]
+	if !ok {
+		return nil, errorcode.ErrBadParamInput
+	}
+	types := map[DocumentType]bool{}
+	for _, u := range uploads {
+		if u.Size <= 0 || u.Size > policy.MaxDocumentSize || types[u.Type] {
+			return nil, errorcode.ErrBadParamInput
+		}
+		types[u.Type] = true
+	}
+	for _, group := range groups {
+		found := false
+		for _, t := range group {
+			found = found || types[t]
+		}
+		if !found {
+			return nil, errorcode.ErrBadParamInput
+		}
+	}
+
+	res := &Submission{
+		Model: base.Model{
+			ID:        uuid.NewV4(),
+			CreatedBy: userID,
+			CreatedAt: at,
+		},
+		UserID: userID,
+		Tier:   tier,
+		Status: Pending,
+	}
+	for _, u := range uploads {
+		id := uuid.NewV4()
+		res.Documents = append(res.Documents, Document{
+			ID:           id,
+			SubmissionID: res.ID,
+			Type:         u.Type,
+			ContentType:  u.ContentType,
+			Size:         u.Size,
+			BlobKey:      fmt.Sprintf("%s/%s/%s", BlobPrefix(userID), res.ID, id),
+			CreatedAt:    at,
+		})
+	}
+	return res, nil
+}
+
+// BlobPrefix is the prefix of blob keys of every document of the user
+func BlobPrefix(userID uuid.UUID) string {
+	return "kyc/" + userID.String()
+}
+
+// AcceptsNew returns error when the user may not submit again after this submission
+func (s Submission) AcceptsNew(policy Policy, now time.Time) error {
+	switch s.Status {
+	case Pending:
+		return errorcode.ErrConflict
+	case Rejected:
+		if s.ReviewedAt != nil && now.Before(s.ReviewedAt.Add(policy.RejectionCooldown)) {
+			return errorcode.ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// Review moves pending submission into the review outcome
+func (s *Submission) Review(status SubmissionStatus, reviewerID uuid.UUID, note *string, at time.Time) error {
+	if !s.Status.CanTransitionTo(status) {
+		return errorcode.ErrConflict
+	}
+	s.Status = status
+	s.ReviewedBy = &reviewerID
+	s.ReviewedAt = &at
+	s.ReviewNote = note
+	s.UpdatedBy = &reviewerID
+	s.UpdatedAt = &at
+	return nil
+}
+
+// Message returns notification telling the user the review outcome
+func (s Submission) Message(to string) notifier.Message {
+	body := fmt.Sprintf("Your identity verification for %s tier is %s.", s.Tier, s.Status)
+	if s.ReviewNote != nil {
+		body += "\n\n" + *s.ReviewNote
+	}
+	return notifier.Message{Channel: notifier.Email, To: to, Subject: "Identity verification result", Body: body}
+}
+
+// Document is file attached to submission, its content lives in blob store under BlobKey
+type Document struct {
+	ID           uuid.UUID    `json:"id"`
+	SubmissionID uuid.UUID    `json:"submission_id"`
+	Type         DocumentType `json:"type"`
+	ContentType  string       `json:"content_type"`
+	Size         int64        `json:"size"`
+	BlobKey      string       `json:"-"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// Documents is list of document model
+type Documents []Document
+
+// Upload is document content received from the user
+type Upload struct {
+	Type        DocumentType
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
+
+// NewUpload detects content type from the first bytes of content, only jpeg, png and pdf are accepted
+func NewUpload(t DocumentType, size int64, content io.Reader) (*Upload, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !allowedContentTypes[contentType] {
+		return nil, errorcode.ErrBadParamInput
+	}
+	return &Upload{
+		Type:        t,
+		ContentType: contentType,
+		Size:        size,
+		Content:     io.MultiReader(bytes.NewReader(head[:n]), content),
+	}, nil
+}
+
+// Status is kyc state of the user as seen by the user
+type Status struct {
+	Tier       base.Tier   `json:"tier"`
+	Submission *Submission `json:"submission"`
+}
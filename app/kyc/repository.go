@@ -0,0 +1,20 @@
+package kyc
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/kyc/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Repository represent the kyc's repository contract
+type Repository interface {
+	TxStore(context.Context, *sql.Tx, model.Submission) error
+	TxStoreDocument(context.Context, *sql.Tx, model.Document) error
+	GetByID(context.Context, uuid.UUID) (*model.Submission, error)
+	GetLatestByUserID(context.Context, uuid.UUID) (*model.Submission, error)
+	FetchByUserID(context.Context, uuid.UUID) (model.Submissions, error)
+	FetchByStatus(context.Context, model.SubmissionStatus) (model.Submissions, error)
+	TxReview(context.Context, *sql.Tx, model.Submission) error
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
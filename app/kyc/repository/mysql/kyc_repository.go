@@ -0,0 +1,188 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/kyc"
+	"github.com/fajardm/ewallet-example/app/kyc/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Table kyc_submissions
+	querySelectSubmission = `
+		SELECT 
+			id,
+			user_id,
+			tier,
+			status,
+			reviewed_by,
+			reviewed_at,
+			review_note,
+			created_by,
+			created_at,
+			updated_by,
+			updated_at 
+		FROM kyc_submissions
+	`
+	queryInsertSubmission = `
+		INSERT INTO kyc_submissions (
+			id,
+			user_id,
+			tier,
+			status,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+	queryReviewSubmission = `
+		UPDATE kyc_submissions SET status=?, reviewed_by=?, reviewed_at=?, review_note=?, updated_by=?, updated_at=? WHERE id=? AND status=?
+	`
+	// Table kyc_documents
+	querySelectDocuments = `
+		SELECT 
+			id,
+			submission_id,
+			type,
+			content_type,
+			size,
+			blob_key,
+			created_at 
+		FROM kyc_documents
+	`
+	queryInsertDocument = `
+		INSERT INTO kyc_documents (
+			id,
+			submission_id,
+			type,
+			content_type,
+			size,
+			blob_key,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+)
+
+type kycRepository struct {
+	db *database.MySQL
+}
+
+func NewKYCRepository(conn *database.MySQL) kyc.Repository {
+	return &kycRepository{db: conn}
+}
+
+func (k kycRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return k.db.WithTransaction(ctx, fn)
+}
+
+func (k kycRepository) TxStore(ctx context.Context, tx *sql.Tx, submission model.Submission) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertSubmission, submission.ID, submission.UserID, submission.Tier, submission.Status, submission.CreatedBy, submission.CreatedAt)
+	return
+}
+
+func (k kycRepository) TxStoreDocument(ctx context.Context, tx *sql.Tx, document model.Document) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertDocument, document.ID, document.SubmissionID, document.Type, document.ContentType, document.Size, document.BlobKey, document.CreatedAt)
+	return
+}
+
+// GetByID returns the submission along with its documents
+func (k kycRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Submission, error) {
+	return k.getWithDocuments(ctx, querySelectSubmission+" WHERE id=?", id)
+}
+
+// GetLatestByUserID returns the last submission of the user along with its documents
+func (k kycRepository) GetLatestByUserID(ctx context.Context, userID uuid.UUID) (*model.Submission, error) {
+	return k.getWithDocuments(ctx, querySelectSubmission+" WHERE user_id=? ORDER BY created_at DESC LIMIT 1", userID)
+}
+
+// FetchByUserID returns every submission of the user along with its documents, oldest first
+func (k kycRepository) FetchByUserID(ctx context.Context, userID uuid.UUID) (model.Submissions, error) {
+	list, err := k.fetchContext(ctx, querySelectSubmission+" WHERE user_id=? ORDER BY created_at ASC", userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Documents, err = k.fetchDocuments(ctx, list[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// FetchByStatus returns the oldest submissions first, so the review queue is worked in order of arrival
+func (k kycRepository) FetchByStatus(ctx context.Context, status model.SubmissionStatus) (model.Submissions, error) {
+	q := querySelectSubmission + " WHERE status=? ORDER BY created_at ASC LIMIT 100"
+	return k.fetchContext(ctx, q, status)
+}
+
+// TxReview only updates submission that is still pending, so a submission can not be reviewed twice
+func (k kycRepository) TxReview(ctx context.Context, tx *sql.Tx, submission model.Submission) (err error) {
+	res, err := tx.ExecContext(ctx, queryReviewSubmission, submission.Status, submission.ReviewedBy, submission.ReviewedAt, submission.ReviewNote, submission.UpdatedBy, submission.UpdatedAt, submission.ID, model.Pending)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected != 1 {
+		err = errorcode.ErrConflict
+		return
+	}
+	return
+}
+
+func (k kycRepository) getWithDocuments(ctx context.Context, query string, args ...interface{}) (*model.Submission, error) {
+	list, err := k.fetchContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errorcode.ErrNotFound
+	}
+	res := list[0]
+	if res.Documents, err = k.fetchDocuments(ctx, res.ID); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (k kycRepository) fetchDocuments(ctx context.Context, submissionID uuid.UUID) (model.Documents, error) {
+	rows, err := k.db.QueryContext(ctx, querySelectDocuments+" WHERE submission_id=? ORDER BY type ASC", submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Documents, 0)
+	for rows.Next() {
+		r := model.Document{}
+		err = rows.Scan(&r.ID, &r.SubmissionID, &r.Type, &r.ContentType, &r.Size, &r.BlobKey, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
+
+func (k kycRepository) fetchContext(ctx context.Context, query string, args ...interface{}) (model.Submissions, error) {
+	rows, err := k.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Submissions, 0)
+	for rows.Next() {
+		r := model.Submission{}
+		err = rows.Scan(&r.ID, &r.UserID, &r.Tier, &r.Status, &r.ReviewedBy, &r.ReviewedAt, &r.ReviewNote, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
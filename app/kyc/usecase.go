@@ -0,0 +1,20 @@
+package kyc
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/kyc/model"
+	uuid "github.com/satori/go.uuid"
+	"io"
+)
+
+// Usecase represent the kyc's usecase contract
+type Usecase interface {
+	Submit(ctx context.Context, userID uuid.UUID, tier base.Tier, uploads []model.Upload) (*model.Submission, error)
+	GetStatus(context.Context, uuid.UUID) (*model.Status, error)
+	FetchByStatus(context.Context, model.SubmissionStatus) (model.Submissions, error)
+	GetByID(context.Context, uuid.UUID) (*model.Submission, error)
+	OpenDocument(ctx context.Context, submissionID, documentID uuid.UUID) (*model.Document, io.ReadCloser, error)
+	Review(ctx context.Context, reviewerID, submissionID uuid.UUID, status model.SubmissionStatus, note *string) (*model.Submission, error)
+	PurgeDocuments(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/kyc"
+	"github.com/fajardm/ewallet-example/app/kyc/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/blob"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"time"
+)
+
+type kycUsecase struct {
+	kycRepository  kyc.Repository
+	userRepository user.Repository
+	auditor        audit.Auditor
+	policy         model.Policy
+	contextTimeout time.Duration
+}
+
+func NewKYCUsecase(kycRepository kyc.Repository, userRepository user.Repository, auditor audit.Auditor, policy model.Policy, contextTimeout time.Duration) kyc.Usecase {
+	if policy.MaxDocumentSize <= 0 {
+		policy.MaxDocumentSize = 1 << 20
+	}
+	if policy.RejectionCooldown <= 0 {
+		policy.RejectionCooldown = time.Hour * 24 * 7
+	}
+	return kycUsecase{kycRepository: kycRepository, userRepository: userRepository, auditor: auditor, policy: policy, contextTimeout: contextTimeout}
+}
+
+// Submit stores the documents in blob store and queues the submission for review. Only one submission may wait
+// in the queue per user, and the tier must be higher than the current one
+func (k kycUsecase) Submit(ctx context.Context, userID uuid.UUID, tier base.Tier, uploads []model.Upload) (*model.Submission, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	existed, err := k.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := existed.Status.CheckModify(); err != nil {
+		return nil, err
+	}
+	if tier <= existed.Tier {
+		return nil, errorcode.ErrConflict
+	}
+	latest, err := k.kycRepository.GetLatestByUserID(ctx, userID)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	if latest != nil {
+		if err := latest.AcceptsNew(k.policy, now); err != nil {
+			return nil, err
+		}
+	}
+
+	submission, err := model.NewSubmission(userID, tier, uploads, k.policy, now)
+	if err != nil {
+		return nil, err
+	}
+	for i, document := range submission.Documents {
+		if err := blob.Blob().Put(ctx, document.BlobKey, io.LimitReader(uploads[i].Content, k.policy.MaxDocumentSize)); err != nil {
+			k.deleteDocuments(submission.Documents[:i])
+			return nil, err
+		}
+	}
+
+	err = k.kycRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = k.kycRepository.TxStore(ctx, tx, *submission); err != nil {
+			return err
+		}
+		for _, document := range submission.Documents {
+			if err = k.kycRepository.TxStoreDocument(ctx, tx, document); err != nil {
+				return err
+			}
+		}
+		return
+	})
+	if err != nil {
+		k.deleteDocuments(submission.Documents)
+		return nil, err
+	}
+	return submission, nil
+}
+
+// deleteDocuments removes content of documents which never made it into database
+func (k kycUsecase) deleteDocuments(documents model.Documents) {
+	for _, document := range documents {
+		if err := blob.Blob().Delete(context.Background(), document.BlobKey); err != nil {
+			log.WithError(err).WithField("key", document.BlobKey).Warn("delete kyc document")
+		}
+	}
+}
+
+// GetStatus returns the tier of the user and its latest submission, if any
+func (k kycUsecase) GetStatus(ctx context.Context, userID uuid.UUID) (*model.Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	existed, err := k.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := k.kycRepository.GetLatestByUserID(ctx, userID)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	return &model.Status{Tier: existed.Tier, Submission: latest}, nil
+}
+
+func (k kycUsecase) FetchByStatus(ctx context.Context, status model.SubmissionStatus) (model.Submissions, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	return k.kycRepository.FetchByStatus(ctx, status)
+}
+
+func (k kycUsecase) GetByID(ctx context.Context, id uuid.UUID) (*model.Submission, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	return k.kycRepository.GetByID(ctx, id)
+}
+
+// OpenDocument returns the document with its content, the caller must close the content
+func (k kycUsecase) OpenDocument(ctx context.Context, submissionID, documentID uuid.UUID) (*model.Document, io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	submission, err := k.kycRepository.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, document := range submission.Documents {
+		if uuid.Equal(document.ID, documentID) {
+			content, err := blob.Blob().Open(ctx, document.BlobKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &document, content, nil
+		}
+	}
+	return nil, nil, errorcode.ErrNotFound
+}
+
+// Review decides pending submission, approval moves the user to the submitted tier. Reviewer can not review its
+// own submission. The user is told the outcome by email
+func (k kycUsecase) Review(ctx context.Context, reviewerID, submissionID uuid.UUID, status model.SubmissionStatus, note *string) (*model.Submission, error) {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	now := time.Now()
+	submission, err := k.kycRepository.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if uuid.Equal(submission.UserID, reviewerID) {
+		return nil, errorcode.ErrForbidden
+	}
+	existed, err := k.userRepository.GetByID(ctx, submission.UserID)
+	if err != nil {
+		return nil, err
+	}
+	before := *submission
+	if err := submission.Review(status, reviewerID, note, now); err != nil {
+		return nil, err
+	}
+
+	err = k.kycRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = k.kycRepository.TxReview(ctx, tx, *submission); err != nil {
+			return err
+		}
+		if status == model.Approved && submission.Tier > existed.Tier {
+			existed.Tier = submission.Tier
+			existed.UpdatedBy = &reviewerID
+			existed.UpdatedAt = &now
+			if err = k.userRepository.TxUpdateTier(ctx, tx, *existed); err != nil {
+				return err
+			}
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, k.auditor, audit.Event{ActorID: &reviewerID, Action: audit.KYCReview, TargetType: "kyc_submission", TargetID: submission.ID, Before: audit.Snapshot(before), After: audit.Snapshot(submission)})
+
+	// Failed delivery does not undo the review, the user also sees the outcome in the app
+	if err := notifier.Send(ctx, submission.Message(existed.Email)); err != nil {
+		log.WithError(err).WithField("submission_id", submission.ID).Warn("send kyc review result")
+	}
+	return submission, nil
+}
+
+// PurgeDocuments removes content of every document of the user, called once the user is purged after retention
+func (k kycUsecase) PurgeDocuments(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, k.contextTimeout)
+	defer cancel()
+
+	return blob.Blob().DeletePrefix(ctx, model.BlobPrefix(userID))
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"github.com/fajardm/ewallet-example/app/login"
+	"github.com/fajardm/ewallet-example/app/login/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"html/template"
+	"net/http"
+	"strconv"
+)
+
+type loginHandler struct {
+	loginUsecase login.Usecase
+}
+
+func NewLoginHandler(app *bootstrap.Bootstrap, loginUsecase login.Usecase) {
+	handler := loginHandler{loginUsecase: loginUsecase}
+	api := app.Group("/api")
+	api.Get("/users/logins", middleware.Protected(), middleware.CheckSession, handler.Fetch)
+	// The link is sent in the new sign-in alert and signed, so it works without access token. Opening the link only
+	// shows the confirmation, mail scanners following links must not sign the user out
+	api.Get("/users/logins/:id/not-me", handler.ConfirmNotMe)
+	api.Post("/users/logins/:id/not-me", handler.NotMe)
+}
+
+// notMePage asks to confirm the "this wasn't me" link, the form posts the token back to the same path
+var notMePage = template.Must(template.New("not-me").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Secure your account</title></head>
+<body>
+<p>If you did not sign in, confirm below to sign out of every device, then reset your password.</p>
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Sign out everywhere</button>
+</form>
+</body>
+</html>
+`))
+
+func (l loginHandler) Fetch(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	limit := 0
+	if s := ctx.Query("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+			return
+		}
+	}
+
+	data, err := l.loginUsecase.Fetch(ctx.Context(), *userID, limit)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// ConfirmNotMe renders the confirmation of the link in the alert, nothing is revoked yet
+func (l loginHandler) ConfirmNotMe(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	page := new(bytes.Buffer)
+	data := struct{ Action, Token string }{Action: "/api/users/logins/" + id.String() + "/not-me", Token: ctx.Query("token")}
+	if err := notMePage.Execute(page, data); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("Referrer-Policy", "no-referrer")
+	ctx.Type("html")
+	ctx.SendBytes(page.Bytes())
+}
+
+// NotMe revokes every session of the user to holder of the signed token, sent as form or json body
+func (l loginHandler) NotMe(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.NotMeInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := l.loginUsecase.NotMe(ctx.Context(), id, input.Token); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
@@ -0,0 +1,6 @@
+package model
+
+// NotMeInput is posted by the confirmation of "this wasn't me" link, as form or json
+type NotMeInput struct {
+	Token string `json:"token" form:"token"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/fajardm/ewallet-example/notifier"
+	uuid "github.com/satori/go.uuid"
+	"strings"
+	"time"
+)
+
+// Policy of login history. Alerts about sign-in from new device or country carry a "this wasn't me" link valid for
+// AlertTTL. Country is read from CountryHeader set by CDN or proxy in front of the app, left empty when not set.
+// IP is read from IPHeader set by the proxy, the connection address is used when not set
+type Policy struct {
+	BaseURL       string
+	AlertTTL      time.Duration
+	CountryHeader string
+	IPHeader      string
+}
+
+// Client is where a login attempt comes from
+type Client struct {
+	IP             string
+	UserAgent      string
+	AcceptLanguage string
+	Country        string
+}
+
+// Fingerprint identifies the device by its browser or app, it stays the same when the IP changes
+func (c Client) Fingerprint() string {
+	sum := sha256.Sum256([]byte(strings.ToLower(c.UserAgent) + "\n" + strings.ToLower(c.AcceptLanguage)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Attempt is one login attempt, Reason tells why a failed attempt was refused. UserID is nil when the identifier
+// matches no account, Identifier is nil for the two factor step where no identifier is submitted
+type Attempt struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      *uuid.UUID `json:"user_id"`
+	Identifier  *string    `json:"identifier"`
+	Success     bool       `json:"success"`
+	Reason      *string    `json:"reason"`
+	IP          string     `json:"ip"`
+	UserAgent   string     `json:"user_agent"`
+	Fingerprint string     `json:"fingerprint"`
+	Country     *string    `json:"country"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Attempts represent list of Attempt
+type Attempts []Attempt
+
+// NewAttempt records the outcome of login, failure is the error the login was refused with
+func NewAttempt(userID *uuid.UUID, identifier string, client Client, failure error, at time.Time) Attempt {
+	res := Attempt{
+		ID:          uuid.NewV4(),
+		UserID:      userID,
+		Success:     failure == nil,
+		IP:          client.IP,
+		UserAgent:   truncate(client.UserAgent, 256),
+		Fingerprint: client.Fingerprint(),
+		CreatedAt:   at,
+	}
+	if identifier != "" {
+		identifier = truncate(strings.ToLower(identifier), 255)
+		res.Identifier = &identifier
+	}
+	if failure != nil {
+		reason := truncate(failure.Error(), 64)
+		res.Reason = &reason
+	}
+	if client.Country != "" {
+		country := strings.ToUpper(truncate(client.Country, 2))
+		res.Country = &country
+	}
+	return res
+}
+
+// Alert returns notification about sign-in from unfamiliar device or country, link revokes every session
+func (a Attempt) Alert(to, link string) notifier.Message {
+	where := a.IP
+	if a.Country != nil {
+		where = fmt.Sprintf("%s (%s)", a.IP, *a.Country)
+	}
+	body := fmt.Sprintf(
+		"Your account was signed in from a new device or location.\n\nTime: %s\nDevice: %s\nFrom: %s\n\nIf this wasn't you, open the link below to sign out everywhere, then reset your password.\n%s",
+		a.CreatedAt.Format(time.RFC1123), a.UserAgent, where, link,
+	)
+	return notifier.Message{Channel: notifier.Email, To: to, Subject: "New sign-in to your account", Body: body}
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
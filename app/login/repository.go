@@ -0,0 +1,19 @@
+package login
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/login/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the login's repository contract
+type Repository interface {
+	Store(context.Context, model.Attempt) error
+	GetByID(context.Context, uuid.UUID) (*model.Attempt, error)
+	FetchByUserID(ctx context.Context, userID uuid.UUID, limit int) (model.Attempts, error)
+	FetchAllByUserID(ctx context.Context, userID uuid.UUID) (model.Attempts, error)
+	MarkNotMe(ctx context.Context, id uuid.UUID, at time.Time) error
+	// CountSuccess counts successful attempts of the user, only those matching fingerprint or country when given
+	CountSuccess(ctx context.Context, userID uuid.UUID, fingerprint, country string) (int, error)
+}
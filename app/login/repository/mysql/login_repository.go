@@ -0,0 +1,138 @@
+package mysql
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/login"
+	"github.com/fajardm/ewallet-example/app/login/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table login_attempts
+	querySelectLoginAttempt = `
+		SELECT 
+			id,
+			user_id,
+			identifier,
+			success,
+			reason,
+			ip,
+			user_agent,
+			fingerprint,
+			country,
+			created_at
+		FROM login_attempts
+	`
+	queryInsertLoginAttempt = `
+		INSERT INTO login_attempts (
+			id,
+			user_id,
+			identifier,
+			success,
+			reason,
+			ip,
+			user_agent,
+			fingerprint,
+			country,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	queryMarkLoginNotMe = `
+		UPDATE login_attempts SET not_me_at=? WHERE id=? AND not_me_at IS NULL
+	`
+	queryCountLoginSuccess = `
+		SELECT COUNT(*) FROM login_attempts WHERE user_id=? AND success=TRUE
+	`
+)
+
+type loginRepository struct {
+	db *database.MySQL
+}
+
+func NewLoginRepository(conn *database.MySQL) login.Repository {
+	return &loginRepository{db: conn}
+}
+
+func (l loginRepository) Store(ctx context.Context, a model.Attempt) (err error) {
+	_, err = l.db.ExecContext(ctx, queryInsertLoginAttempt, a.ID, a.UserID, a.Identifier, a.Success, a.Reason, a.IP, a.UserAgent, a.Fingerprint, a.Country, a.CreatedAt)
+	return
+}
+
+func (l loginRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Attempt, error) {
+	list, err := l.fetchContext(ctx, querySelectLoginAttempt+" WHERE id=?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errorcode.ErrNotFound
+	}
+	return &list[0], nil
+}
+
+// FetchByUserID returns the latest attempts of the user, newest first
+func (l loginRepository) FetchByUserID(ctx context.Context, userID uuid.UUID, limit int) (model.Attempts, error) {
+	q := querySelectLoginAttempt + " WHERE user_id=? ORDER BY created_at DESC, id DESC LIMIT ?"
+	return l.fetchContext(ctx, q, userID, limit)
+}
+
+// FetchAllByUserID returns every attempt of the user, oldest first
+func (l loginRepository) FetchAllByUserID(ctx context.Context, userID uuid.UUID) (model.Attempts, error) {
+	q := querySelectLoginAttempt + " WHERE user_id=? ORDER BY created_at ASC, id ASC"
+	return l.fetchContext(ctx, q, userID)
+}
+
+// MarkNotMe only marks attempt that is not marked yet, so the "this wasn't me" link works once
+func (l loginRepository) MarkNotMe(ctx context.Context, id uuid.UUID, at time.Time) error {
+	res, err := l.db.ExecContext(ctx, queryMarkLoginNotMe, at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrConflict
+	}
+	return nil
+}
+
+func (l loginRepository) CountSuccess(ctx context.Context, userID uuid.UUID, fingerprint, country string) (int, error) {
+	q := queryCountLoginSuccess
+	args := []interface{}{userID}
+	if fingerprint != "" {
+		q += " AND fingerprint=?"
+		args = append(args, fingerprint)
+	}
+	if country != "" {
+		q += " AND country=?"
+		args = append(args, country)
+	}
+	var total int
+	if err := l.db.QueryRowContext(ctx, q, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (l loginRepository) fetchContext(ctx context.Context, query string, args ...interface{}) (model.Attempts, error) {
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(model.Attempts, 0)
+	for rows.Next() {
+		r := model.Attempt{}
+		err = rows.Scan(&r.ID, &r.UserID, &r.Identifier, &r.Success, &r.Reason, &r.IP, &r.UserAgent, &r.Fingerprint, &r.Country, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
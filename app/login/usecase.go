@@ -0,0 +1,18 @@
+package login
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/login/model"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the login's usecase contract
+type Usecase interface {
+	Client(ip string, header func(key string) string) model.Client
+	RecordSuccess(ctx context.Context, user _userModel.User, client model.Client) error
+	RecordFailure(ctx context.Context, usernameOrEmail string, client model.Client, failure error) error
+	RecordChallengeFailure(ctx context.Context, userID uuid.UUID, client model.Client, failure error) error
+	Fetch(ctx context.Context, userID uuid.UUID, limit int) (model.Attempts, error)
+	NotMe(ctx context.Context, id uuid.UUID, token string) error
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/login"
+	"github.com/fajardm/ewallet-example/app/login/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	"github.com/fajardm/ewallet-example/token"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const notMeTokenPurpose = "login_not_me"
+
+type loginUsecase struct {
+	loginRepository login.Repository
+	userRepository  user.Repository
+	authUsecase     auth.Usecase
+	auditor         audit.Auditor
+	policy          model.Policy
+	contextTimeout  time.Duration
+}
+
+func NewLoginUsecase(loginRepository login.Repository, userRepository user.Repository, authUsecase auth.Usecase, auditor audit.Auditor, policy model.Policy, contextTimeout time.Duration) login.Usecase {
+	if policy.AlertTTL <= 0 {
+		policy.AlertTTL = time.Hour * 24 * 7
+	}
+	policy.BaseURL = strings.TrimRight(policy.BaseURL, "/")
+	return loginUsecase{loginRepository: loginRepository, userRepository: userRepository, authUsecase: authUsecase, auditor: auditor, policy: policy, contextTimeout: contextTimeout}
+}
+
+// Client describes the client of the request from its IP and headers
+func (l loginUsecase) Client(ip string, header func(key string) string) model.Client {
+	res := model.Client{IP: ip, UserAgent: header(fiber.HeaderUserAgent), AcceptLanguage: header(fiber.HeaderAcceptLanguage)}
+	if l.policy.CountryHeader != "" {
+		res.Country = header(l.policy.CountryHeader)
+	}
+	if l.policy.IPHeader != "" {
+		// X-Forwarded-For style header lists the client first
+		if forwarded := strings.TrimSpace(strings.Split(header(l.policy.IPHeader), ",")[0]); forwarded != "" {
+			res.IP = forwarded
+		}
+	}
+	return res
+}
+
+// RecordSuccess stores the login and alerts the user when it comes from a device or country never seen in its
+// earlier successful logins. The very first login is not alerted. Failed delivery does not fail the login
+func (l loginUsecase) RecordSuccess(ctx context.Context, u _userModel.User, client model.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	attempt := model.NewAttempt(&u.ID, "", client, nil, time.Now())
+	total, err := l.loginRepository.CountSuccess(ctx, u.ID, "", "")
+	if err != nil {
+		return err
+	}
+	unfamiliar := false
+	if total > 0 {
+		seen, err := l.loginRepository.CountSuccess(ctx, u.ID, attempt.Fingerprint, "")
+		if err != nil {
+			return err
+		}
+		unfamiliar = seen == 0
+		if attempt.Country != nil && !unfamiliar {
+			seen, err := l.loginRepository.CountSuccess(ctx, u.ID, "", *attempt.Country)
+			if err != nil {
+				return err
+			}
+			unfamiliar = seen == 0
+		}
+	}
+	if err := l.loginRepository.Store(ctx, attempt); err != nil {
+		return err
+	}
+	if !unfamiliar {
+		return nil
+	}
+
+	t, err := token.SignPurpose(notMeTokenPurpose, jwt.MapClaims{"sub": attempt.ID.String()}, l.policy.AlertTTL)
+	if err != nil {
+		return err
+	}
+	link := l.policy.BaseURL + "/api/users/logins/" + attempt.ID.String() + "/not-me?token=" + url.QueryEscape(t)
+	if err := notifier.Send(ctx, attempt.Alert(u.Email, link)); err != nil {
+		log.WithError(err).WithField("user_id", u.ID).Warn("send new sign-in alert")
+	}
+	return nil
+}
+
+// RecordFailure stores refused login with the submitted identifier, attempts on unknown account are kept without
+// user so guessing of accounts can be investigated
+func (l loginUsecase) RecordFailure(ctx context.Context, usernameOrEmail string, client model.Client, failure error) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	var userID *uuid.UUID
+	u, err := l.userRepository.GetByUsernameOrEmail(ctx, usernameOrEmail, usernameOrEmail)
+	if err != nil && err != errorcode.ErrNotFound {
+		return err
+	}
+	if u != nil {
+		userID = &u.ID
+	}
+	return l.loginRepository.Store(ctx, model.NewAttempt(userID, usernameOrEmail, client, failure, time.Now()))
+}
+
+// RecordChallengeFailure stores refused two factor step of the user whose password was already accepted
+func (l loginUsecase) RecordChallengeFailure(ctx context.Context, userID uuid.UUID, client model.Client, failure error) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	return l.loginRepository.Store(ctx, model.NewAttempt(&userID, "", client, failure, time.Now()))
+}
+
+// Fetch returns the latest login attempts of the user, 20 unless limit between 1 and 100 given
+func (l loginUsecase) Fetch(ctx context.Context, userID uuid.UUID, limit int) (model.Attempts, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return l.loginRepository.FetchByUserID(ctx, userID, limit)
+}
+
+// NotMe revokes every session of the user the alerted login belongs to, token is the one sent in the alert. The
+// attempt is marked before revoking, so the token is accepted once only
+func (l loginUsecase) NotMe(ctx context.Context, id uuid.UUID, t string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.contextTimeout)
+	defer cancel()
+
+	claims, err := token.ParsePurpose(notMeTokenPurpose, t)
+	if err != nil {
+		return errorcode.ErrInvalidCredential
+	}
+	if sub, _ := claims["sub"].(string); sub != id.String() {
+		return errorcode.ErrInvalidCredential
+	}
+	attempt, err := l.loginRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if attempt.UserID == nil {
+		return errorcode.ErrInvalidCredential
+	}
+	if err := l.loginRepository.MarkNotMe(ctx, id, time.Now()); err != nil {
+		if err == errorcode.ErrConflict {
+			return errorcode.ErrInvalidCredential
+		}
+		return err
+	}
+	if err := l.authUsecase.RevokeByUserID(ctx, *attempt.UserID); err != nil {
+		return err
+	}
+	audit.Record(ctx, l.auditor, audit.Event{ActorID: attempt.UserID, Action: audit.UserNotMe, TargetType: "user", TargetID: *attempt.UserID, After: audit.Snapshot(map[string]uuid.UUID{"login_attempt_id": attempt.ID})})
+	return nil
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidPurpose represent error when invalid Purpose
+var ErrInvalidPurpose = errors.New("InvalidPurpose")
+
+// Purpose scopes one time password, a code issued for one purpose can not be used for another
+type Purpose int
+
+const (
+	// VerifyEmail represent code proving ownership of email address
+	VerifyEmail Purpose = 1 + iota
+	// VerifyPhone represent code proving ownership of mobile phone
+	VerifyPhone
+	// ResetPassword represent code authorizing password reset
+	ResetPassword
+	// StepUp represent code confirming sensitive action
+	StepUp
+)
+
+// PurposeFromString will converts a string to a Purpose, will return Purpose if string is
+// valid representation of Purpose, or error otherwise
+func PurposeFromString(s string) (res Purpose, err error) {
+	switch s {
+	case "verify_email":
+		res = VerifyEmail
+	case "verify_phone":
+		res = VerifyPhone
+	case "reset_password":
+		res = ResetPassword
+	case "step_up":
+		res = StepUp
+	default:
+		err = errors.WithMessagef(ErrInvalidPurpose, "invalid value: %s", s)
+	}
+	return
+}
+
+// MarshalText is the custom marshalling for Purpose. With this when marshalling to json
+// Purpose will be shown as its string representation instead of int
+func (p Purpose) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// String returns the string representation of Purpose
+func (p Purpose) String() string {
+	var s string
+	switch p {
+	case VerifyEmail:
+		s = "verify_email"
+	case VerifyPhone:
+		s = "verify_phone"
+	case ResetPassword:
+		s = "reset_password"
+	case StepUp:
+		s = "step_up"
+	}
+	return s
+}
+
+// Subject returns the human readable purpose used in the delivered message
+func (p Purpose) Subject() string {
+	var s string
+	switch p {
+	case VerifyEmail:
+		s = "Email verification code"
+	case VerifyPhone:
+		s = "Phone verification code"
+	case ResetPassword:
+		s = "Password reset code"
+	case StepUp:
+		s = "Confirmation code"
+	}
+	return s
+}
+
+// Value transforms Purpose to its value for its column in database (MySQL)
+func (p Purpose) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Scan transforms MySQL enum column value for purpose column to Purpose
+func (p *Purpose) Scan(value interface{}) error {
+	b, ok := value.([]uint8)
+	if !ok {
+		return fmt.Errorf("expecting a []uint8 found %T, in string: %s", value, value)
+	}
+	st, err := PurposeFromString(string(b))
+	if err != nil {
+		return err
+	}
+	*p = st
+	return nil
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"crypto/rand"
+	"fmt"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// OTP is one time password delivered to email or phone, only its hash is stored
+type OTP struct {
+	ID          uuid.UUID
+	Purpose     Purpose
+	Channel     notifier.Channel
+	Destination string
+	HashedCode  []byte
+	Attempts    int
+	ExpiresAt   time.Time
+	ConsumedAt  *time.Time
+	CreatedAt   time.Time
+}
+
+// Policy limits issuing and verification of one time password per purpose and destination
+type Policy struct {
+	Length      int
+	TTL         time.Duration
+	MaxAttempts int
+	Cooldown    time.Duration
+	MaxPerHour  int
+}
+
+// NewOTP generates random numeric code, returns the otp to store and the plain code to deliver
+func NewOTP(purpose Purpose, channel notifier.Channel, destination string, policy Policy, now time.Time) (*OTP, string, error) {
+	code, err := GenerateCode(policy.Length)
+	if err != nil {
+		return nil, "", err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+	res := OTP{
+		ID:          uuid.NewV4(),
+		Purpose:     purpose,
+		Channel:     channel,
+		Destination: destination,
+		HashedCode:  hashed,
+		ExpiresAt:   now.Add(policy.TTL),
+		CreatedAt:   now,
+	}
+	return &res, code, nil
+}
+
+// Usable returns errorcode.ErrInvalidCredential when the otp is consumed or expired, and
+// errorcode.ErrTooManyAttempts when its attempts are exhausted
+func (o OTP) Usable(policy Policy, now time.Time) error {
+	if o.ConsumedAt != nil || !now.Before(o.ExpiresAt) {
+		return errorcode.ErrInvalidCredential
+	}
+	if o.Attempts >= policy.MaxAttempts {
+		return errorcode.ErrTooManyAttempts
+	}
+	return nil
+}
+
+// Match compares the code with the hash
+func (o OTP) Match(code string) bool {
+	return bcrypt.CompareHashAndPassword(o.HashedCode, []byte(code)) == nil
+}
+
+// Message builds notification delivering the code
+func (o OTP) Message(code string) notifier.Message {
+	minutes := int(o.ExpiresAt.Sub(o.CreatedAt).Minutes())
+	return notifier.Message{
+		Channel: o.Channel,
+		To:      o.Destination,
+		Subject: o.Purpose.Subject(),
+		Body:    fmt.Sprintf("%s is your %s. It expires in %d minutes, never share it with anyone.", code, strings.ToLower(o.Purpose.Subject()), minutes),
+	}
+}
+
+// GenerateCode returns random numeric code of the length
+func GenerateCode(length int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + n.Int64()))
+	}
+	return b.String(), nil
+}
+
+// NormalizeDestination trims the destination, email is also lower cased so the rate limit can not be
+// bypassed by changing letter case
+func NormalizeDestination(channel notifier.Channel, destination string) string {
+	destination = strings.TrimSpace(destination)
+	if channel == notifier.Email {
+		destination = strings.ToLower(destination)
+	}
+	return destination
+}
+
+// Sent tells when the delivered code expires and when another one may be requested
+type Sent struct {
+	Expires    int64 `json:"expires"`
+	RetryAfter int64 `json:"retry_after"`
+}
@@ -0,0 +1,17 @@
+package otp
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/otp/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the one time password's repository contract
+type Repository interface {
+	Store(context.Context, model.OTP) error
+	GetLatest(ctx context.Context, purpose model.Purpose, destination string) (*model.OTP, error)
+	CountSince(ctx context.Context, purpose model.Purpose, destination string, since time.Time) (int, error)
+	IncrementAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) error
+	Consume(ctx context.Context, id uuid.UUID, at time.Time) error
+}
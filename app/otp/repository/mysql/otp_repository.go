@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/otp"
+	"github.com/fajardm/ewallet-example/app/otp/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table one_time_passwords
+	querySelectOTP = `
+		SELECT 
+			id,
+			purpose,
+			channel,
+			destination,
+			hashed_code,
+			attempts,
+			expires_at,
+			consumed_at,
+			created_at
+		FROM one_time_passwords
+	`
+	queryInsertOTP = `
+		INSERT INTO one_time_passwords (
+			id,
+			purpose,
+			channel,
+			destination,
+			hashed_code,
+			expires_at,
+			created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	queryCountOTP = `
+		SELECT COUNT(*) FROM one_time_passwords WHERE purpose=? AND destination=? AND created_at>=?
+	`
+	queryIncrementOTPAttempts = `
+		UPDATE one_time_passwords SET attempts=attempts+1 WHERE id=? AND consumed_at IS NULL AND attempts<?
+	`
+	queryConsumeOTP = `
+		UPDATE one_time_passwords SET consumed_at=? WHERE id=? AND consumed_at IS NULL
+	`
+)
+
+type otpRepository struct {
+	db *database.MySQL
+}
+
+func NewOTPRepository(conn *database.MySQL) otp.Repository {
+	return &otpRepository{db: conn}
+}
+
+func (o otpRepository) Store(ctx context.Context, otp model.OTP) (err error) {
+	_, err = o.db.ExecContext(ctx, queryInsertOTP, otp.ID, otp.Purpose, otp.Channel, otp.Destination, otp.HashedCode, otp.ExpiresAt, otp.CreatedAt)
+	return
+}
+
+// GetLatest returns the last issued otp, issuing new otp supersedes the previous ones
+func (o otpRepository) GetLatest(ctx context.Context, purpose model.Purpose, destination string) (*model.OTP, error) {
+	q := querySelectOTP + " WHERE purpose=? AND destination=? ORDER BY created_at DESC LIMIT 1"
+	res := model.OTP{}
+	err := o.db.QueryRowContext(ctx, q, purpose, destination).Scan(&res.ID, &res.Purpose, &res.Channel, &res.Destination, &res.HashedCode, &res.Attempts, &res.ExpiresAt, &res.ConsumedAt, &res.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (o otpRepository) CountSince(ctx context.Context, purpose model.Purpose, destination string, since time.Time) (count int, err error) {
+	err = o.db.QueryRowContext(ctx, queryCountOTP, purpose, destination, since).Scan(&count)
+	return
+}
+
+// IncrementAttempts counts an attempt before the code is compared, so concurrent guesses can not exceed
+// maxAttempts. Returns errorcode.ErrTooManyAttempts when no attempt is left
+func (o otpRepository) IncrementAttempts(ctx context.Context, id uuid.UUID, maxAttempts int) error {
+	res, err := o.db.ExecContext(ctx, queryIncrementOTPAttempts, id, maxAttempts)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrTooManyAttempts
+	}
+	return nil
+}
+
+// Consume only updates otp that is not consumed yet, so a code can not be used twice
+func (o otpRepository) Consume(ctx context.Context, id uuid.UUID, at time.Time) error {
+	res, err := o.db.ExecContext(ctx, queryConsumeOTP, at, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrConflict
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package otp
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/otp/model"
+	"github.com/fajardm/ewallet-example/notifier"
+)
+
+// Usecase represent the one time password's usecase contract
+type Usecase interface {
+	Issue(ctx context.Context, purpose model.Purpose, channel notifier.Channel, destination string) (*model.Sent, error)
+	Verify(ctx context.Context, purpose model.Purpose, channel notifier.Channel, destination, code string) error
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/otp"
+	"github.com/fajardm/ewallet-example/app/otp/model"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	"time"
+)
+
+type otpUsecase struct {
+	otpRepository  otp.Repository
+	policy         model.Policy
+	contextTimeout time.Duration
+}
+
+func NewOTPUsecase(otpRepository otp.Repository, policy model.Policy, contextTimeout time.Duration) otp.Usecase {
+	if policy.Length <= 0 {
+		policy.Length = 6
+	}
+	if policy.TTL <= 0 {
+		policy.TTL = time.Minute * 10
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.Cooldown <= 0 {
+		policy.Cooldown = time.Minute
+	}
+	if policy.MaxPerHour <= 0 {
+		policy.MaxPerHour = 5
+	}
+	return otpUsecase{otpRepository: otpRepository, policy: policy, contextTimeout: contextTimeout}
+}
+
+// Issue generates and delivers new code, the previous code of the same purpose and destination stops working.
+// Returns errorcode.ErrTooManyAttempts when requested again within the cooldown or the hourly limit is reached
+func (o otpUsecase) Issue(ctx context.Context, purpose model.Purpose, channel notifier.Channel, destination string) (*model.Sent, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	destination = model.NormalizeDestination(channel, destination)
+	now := time.Now()
+
+	latest, err := o.otpRepository.GetLatest(ctx, purpose, destination)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	if latest != nil && now.Before(latest.CreatedAt.Add(o.policy.Cooldown)) {
+		return nil, errorcode.ErrTooManyAttempts
+	}
+	count, err := o.otpRepository.CountSince(ctx, purpose, destination, now.Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if count >= o.policy.MaxPerHour {
+		return nil, errorcode.ErrTooManyAttempts
+	}
+
+	res, code, err := model.NewOTP(purpose, channel, destination, o.policy, now)
+	if err != nil {
+		return nil, err
+	}
+	// Stored before sending, so failed delivery still counts toward the limit and can not be used to flood the gateway
+	if err := o.otpRepository.Store(ctx, *res); err != nil {
+		return nil, err
+	}
+	if err := notifier.Send(ctx, res.Message(code)); err != nil {
+		return nil, err
+	}
+	return &model.Sent{Expires: res.ExpiresAt.Unix(), RetryAfter: now.Add(o.policy.Cooldown).Unix()}, nil
+}
+
+// Verify consumes the latest code of the purpose and destination. Every attempt is counted, the code stops
+// working after MaxAttempts wrong guesses
+func (o otpUsecase) Verify(ctx context.Context, purpose model.Purpose, channel notifier.Channel, destination, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.contextTimeout)
+	defer cancel()
+
+	destination = model.NormalizeDestination(channel, destination)
+	now := time.Now()
+
+	latest, err := o.otpRepository.GetLatest(ctx, purpose, destination)
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrInvalidCredential
+	}
+	if err != nil {
+		return err
+	}
+	if err := latest.Usable(o.policy, now); err != nil {
+		return err
+	}
+	if err := o.otpRepository.IncrementAttempts(ctx, latest.ID, o.policy.MaxAttempts); err != nil {
+		return err
+	}
+	if !latest.Match(code) {
+		if latest.Attempts+1 >= o.policy.MaxAttempts {
+			return errorcode.ErrTooManyAttempts
+		}
+		return errorcode.ErrInvalidCredential
+	}
+	if err := o.otpRepository.Consume(ctx, latest.ID, now); err != nil {
+		if err == errorcode.ErrConflict {
+			return errorcode.ErrInvalidCredential
+		}
+		return err
+	}
+	return nil
+}
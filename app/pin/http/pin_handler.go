@@ -0,0 +1,132 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/pin"
+	"github.com/fajardm/ewallet-example/app/pin/model"
+	"github.com/fajardm/ewallet-example/app/twofactor"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	"net/http"
+)
+
+type pinHandler struct {
+	pinUsecase       pin.Usecase
+	twoFactorUsecase twofactor.Usecase
+}
+
+func NewPINHandler(app *bootstrap.Bootstrap, pinUsecase pin.Usecase, twoFactorUsecase twofactor.Usecase) {
+	handler := pinHandler{pinUsecase: pinUsecase, twoFactorUsecase: twoFactorUsecase}
+	api := app.Group("/api")
+	api.Post("/users/pin", middleware.Protected(), middleware.CheckSession, handler.Set)
+	api.Put("/users/pin", middleware.Protected(), middleware.CheckSession, handler.Change)
+	api.Post("/users/pin/reset", middleware.Protected(), middleware.CheckSession, handler.Reset)
+	api.Post("/users/pin/verify", middleware.Protected(), middleware.CheckSession, handler.Verify)
+}
+
+func (p pinHandler) Set(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.SetInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := p.pinUsecase.Set(ctx.Context(), *userID, input.Password, input.PIN); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (p pinHandler) Change(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.ChangeInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := p.pinUsecase.Change(ctx.Context(), *userID, input.OldPIN, input.NewPIN); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (p pinHandler) Reset(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.ResetInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	// Password alone is not enough to reset pin when two factor is enabled
+	enabled, err := p.twoFactorUsecase.Enabled(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	if enabled {
+		if err := p.twoFactorUsecase.Verify(ctx.Context(), *userID, input.Code); err != nil {
+			ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+			return
+		}
+	}
+
+	if err := p.pinUsecase.Reset(ctx.Context(), *userID, input.Password, input.NewPIN); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (p pinHandler) Verify(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.VerifyInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	data, err := p.pinUsecase.Verify(ctx.Context(), *userID, input.PIN)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
@@ -0,0 +1,40 @@
+package model
+
+import "github.com/fajardm/ewallet-example/validator"
+
+type SetInput struct {
+	PIN      string `json:"pin" validate:"required,len=6,numeric"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (s SetInput) Validate() error {
+	return validator.Validate().Struct(s)
+}
+
+type ChangeInput struct {
+	OldPIN string `json:"old_pin" validate:"required,len=6,numeric"`
+	NewPIN string `json:"new_pin" validate:"required,len=6,numeric,nefield=OldPIN"`
+}
+
+func (c ChangeInput) Validate() error {
+	return validator.Validate().Struct(c)
+}
+
+type ResetInput struct {
+	Password string `json:"password" validate:"required"`
+	NewPIN   string `json:"new_pin" validate:"required,len=6,numeric"`
+	// Code is required when two factor is enabled
+	Code string `json:"code" validate:"max=16"`
+}
+
+func (r ResetInput) Validate() error {
+	return validator.Validate().Struct(r)
+}
+
+type VerifyInput struct {
+	PIN string `json:"pin" validate:"required,len=6,numeric"`
+}
+
+func (v VerifyInput) Validate() error {
+	return validator.Validate().Struct(v)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+// PIN is the transaction pin of a user, separated from the password
+type PIN struct {
+	UserID         uuid.UUID
+	HashedPIN      []byte
+	FailedAttempts int
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      *time.Time
+}
+
+// Policy limits pin verification attempts
+type Policy struct {
+	MaxAttempts  int
+	LockDuration time.Duration
+	StepUpTTL    time.Duration
+}
+
+// NewPIN creates hashed pin of the user
+func NewPIN(userID uuid.UUID, pin string, now time.Time) (*PIN, error) {
+	hashed, err := GeneratePIN(pin)
+	if err != nil {
+		return nil, err
+	}
+	return &PIN{UserID: userID, HashedPIN: hashed, CreatedAt: now}, nil
+}
+
+// Locked reports whether verification is locked out at the time
+func (p PIN) Locked(now time.Time) bool {
+	return p.LockedUntil != nil && now.Before(*p.LockedUntil)
+}
+
+// Match compares the pin, counting the attempt is left to the repository so it stays atomic
+func (p PIN) Match(pin string) bool {
+	return bcrypt.CompareHashAndPassword(p.HashedPIN, []byte(pin)) == nil
+}
+
+// Replace sets new pin and clears the lockout
+func (p *PIN) Replace(pin string, now time.Time) error {
+	hashed, err := GeneratePIN(pin)
+	if err != nil {
+		return err
+	}
+	p.HashedPIN = hashed
+	p.FailedAttempts = 0
+	p.LockedUntil = nil
+	p.UpdatedAt = &now
+	return nil
+}
+
+// GeneratePIN hashes the pin
+func GeneratePIN(pin string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+}
+
+// StepUp is short lived token proving recent pin verification, required by money movement
+type StepUp struct {
+	Token   string `json:"step_up_token"`
+	Expires int64  `json:"expires"`
+}
@@ -0,0 +1,18 @@
+package pin
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/pin/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the pin's repository contract
+type Repository interface {
+	Store(context.Context, model.PIN) error
+	GetByUserID(context.Context, uuid.UUID) (*model.PIN, error)
+	Update(context.Context, model.PIN) error
+	IncrementAttempts(ctx context.Context, userID uuid.UUID, maxAttempts int, at time.Time) error
+	ResetAttempts(ctx context.Context, userID uuid.UUID, at time.Time) error
+	LockExhausted(ctx context.Context, userID uuid.UUID, maxAttempts int, until, at time.Time) (bool, error)
+}
@@ -0,0 +1,118 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/pin"
+	"github.com/fajardm/ewallet-example/app/pin/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table user_pins
+	querySelectPIN = `
+		SELECT 
+			user_id,
+			hashed_pin,
+			failed_attempts,
+			locked_until,
+			created_at,
+			updated_at
+		FROM user_pins
+	`
+	queryInsertPIN = `
+		INSERT INTO user_pins (
+			user_id,
+			hashed_pin,
+			created_at
+		) VALUES (?, ?, ?)
+	`
+	queryUpdatePIN = `
+		UPDATE user_pins SET 
+			hashed_pin=?,
+			failed_attempts=?,
+			locked_until=?,
+			updated_at=?
+		WHERE user_id=?
+	`
+	queryIncrementPINAttempts = `
+		UPDATE user_pins SET failed_attempts=failed_attempts+1, updated_at=?
+		WHERE user_id=? AND failed_attempts<? AND (locked_until IS NULL OR locked_until<=?)
+	`
+	queryResetPINAttempts = `
+		UPDATE user_pins SET failed_attempts=0, locked_until=NULL, updated_at=? WHERE user_id=?
+	`
+	queryLockPIN = `
+		UPDATE user_pins SET failed_attempts=0, locked_until=?, updated_at=? WHERE user_id=? AND failed_attempts>=?
+	`
+)
+
+type pinRepository struct {
+	db *database.MySQL
+}
+
+func NewPINRepository(conn *database.MySQL) pin.Repository {
+	return &pinRepository{db: conn}
+}
+
+func (p pinRepository) Store(ctx context.Context, pin model.PIN) (err error) {
+	_, err = p.db.ExecContext(ctx, queryInsertPIN, pin.UserID, pin.HashedPIN, pin.CreatedAt)
+	return
+}
+
+func (p pinRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.PIN, error) {
+	q := querySelectPIN + " WHERE user_id=?"
+	res := model.PIN{}
+	err := p.db.QueryRowContext(ctx, q, userID).Scan(&res.UserID, &res.HashedPIN, &res.FailedAttempts, &res.LockedUntil, &res.CreatedAt, &res.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (p pinRepository) Update(ctx context.Context, pin model.PIN) (err error) {
+	_, err = p.db.ExecContext(ctx, queryUpdatePIN, pin.HashedPIN, pin.FailedAttempts, pin.LockedUntil, pin.UpdatedAt, pin.UserID)
+	return
+}
+
+// IncrementAttempts counts an attempt before the pin is compared, so concurrent guesses can not exceed
+// maxAttempts. Returns errorcode.ErrTooManyAttempts when the pin is locked or no attempt is left
+func (p pinRepository) IncrementAttempts(ctx context.Context, userID uuid.UUID, maxAttempts int, at time.Time) error {
+	res, err := p.db.ExecContext(ctx, queryIncrementPINAttempts, at, userID, maxAttempts, at)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrTooManyAttempts
+	}
+	return nil
+}
+
+// ResetAttempts clears the counted attempts after the pin matched
+func (p pinRepository) ResetAttempts(ctx context.Context, userID uuid.UUID, at time.Time) (err error) {
+	_, err = p.db.ExecContext(ctx, queryResetPINAttempts, at, userID)
+	return
+}
+
+// LockExhausted locks the pin until the given time when its attempts are used up, reports whether it is locked
+func (p pinRepository) LockExhausted(ctx context.Context, userID uuid.UUID, maxAttempts int, until, at time.Time) (bool, error) {
+	res, err := p.db.ExecContext(ctx, queryLockPIN, until, at, userID, maxAttempts)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,15 @@
+package pin
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/pin/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the pin's usecase contract
+type Usecase interface {
+	Set(ctx context.Context, userID uuid.UUID, password, pin string) error
+	Change(ctx context.Context, userID uuid.UUID, oldPIN, newPIN string) error
+	Reset(ctx context.Context, userID uuid.UUID, password, newPIN string) error
+	Verify(ctx context.Context, userID uuid.UUID, pin string) (*model.StepUp, error)
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/pin"
+	"github.com/fajardm/ewallet-example/app/pin/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type pinUsecase struct {
+	pinRepository  pin.Repository
+	userUsecase    user.Usecase
+	policy         model.Policy
+	contextTimeout time.Duration
+}
+
+func NewPINUsecase(pinRepository pin.Repository, userUsecase user.Usecase, policy model.Policy, contextTimeout time.Duration) pin.Usecase {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 5
+	}
+	if policy.LockDuration <= 0 {
+		policy.LockDuration = time.Minute * 15
+	}
+	if policy.StepUpTTL <= 0 {
+		policy.StepUpTTL = time.Minute * 5
+	}
+	return pinUsecase{pinRepository: pinRepository, userUsecase: userUsecase, policy: policy, contextTimeout: contextTimeout}
+}
+
+// Set creates the first pin of the user, confirmed by the password. Wrong password counts toward the login lockout
+func (p pinUsecase) Set(ctx context.Context, userID uuid.UUID, password, newPIN string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	if err := p.userUsecase.CheckPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	existed, err := p.pinRepository.GetByUserID(ctx, userID)
+	if err != nil && err != errorcode.ErrNotFound {
+		return err
+	}
+	if existed != nil {
+		return errorcode.ErrConflict
+	}
+
+	res, err := model.NewPIN(userID, newPIN, time.Now())
+	if err != nil {
+		return err
+	}
+	return p.pinRepository.Store(ctx, *res)
+}
+
+// Change replaces the pin after verifying the current one, failed verification counts toward lockout
+func (p pinUsecase) Change(ctx context.Context, userID uuid.UUID, oldPIN, newPIN string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	current, err := p.verify(ctx, userID, oldPIN)
+	if err != nil {
+		return err
+	}
+	if err := current.Replace(newPIN, time.Now()); err != nil {
+		return err
+	}
+	return p.pinRepository.Update(ctx, *current)
+}
+
+// Reset replaces forgotten pin using the password, it also lifts the lockout. Wrong password counts toward the login
+// lockout
+func (p pinUsecase) Reset(ctx context.Context, userID uuid.UUID, password, newPIN string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	if err := p.userUsecase.CheckPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	current, err := p.pinRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := current.Replace(newPIN, time.Now()); err != nil {
+		return err
+	}
+	return p.pinRepository.Update(ctx, *current)
+}
+
+// Verify checks the pin and issues step up token authorizing one money movement
+func (p pinUsecase) Verify(ctx context.Context, userID uuid.UUID, pin string) (*model.StepUp, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.contextTimeout)
+	defer cancel()
+
+	if _, err := p.verify(ctx, userID, pin); err != nil {
+		return nil, err
+	}
+	t, err := session.IssueStepUp(ctx, userID, p.policy.StepUpTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StepUp{Token: t, Expires: time.Now().Add(p.policy.StepUpTTL).Unix()}, nil
+}
+
+// verify counts the attempt before comparing the pin, using up MaxAttempts locks the pin for LockDuration
+func (p pinUsecase) verify(ctx context.Context, userID uuid.UUID, pin string) (*model.PIN, error) {
+	current, err := p.pinRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if current.Locked(now) {
+		return nil, errorcode.ErrTooManyAttempts
+	}
+	if err := p.pinRepository.IncrementAttempts(ctx, userID, p.policy.MaxAttempts, now); err != nil {
+		return nil, err
+	}
+	if !current.Match(pin) {
+		locked, err := p.pinRepository.LockExhausted(ctx, userID, p.policy.MaxAttempts, now.Add(p.policy.LockDuration), now)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			return nil, errorcode.ErrTooManyAttempts
+		}
+		return nil, errorcode.ErrInvalidCredential
+	}
+	if err := p.pinRepository.ResetAttempts(ctx, userID, now); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
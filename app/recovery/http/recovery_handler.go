@@ -0,0 +1,61 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/recovery"
+	"github.com/fajardm/ewallet-example/app/recovery/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	"github.com/gofiber/fiber"
+	"net/http"
+)
+
+type recoveryHandler struct {
+	recoveryUsecase recovery.Usecase
+}
+
+func NewRecoveryHandler(app *bootstrap.Bootstrap, recoveryUsecase recovery.Usecase) {
+	handler := recoveryHandler{recoveryUsecase: recoveryUsecase}
+	api := app.Group("/api")
+	api.Post("/users/password/forgot", handler.Forgot)
+	api.Post("/users/password/reset", handler.Reset)
+}
+
+func (r recoveryHandler) Forgot(ctx *fiber.Ctx) {
+	input := new(model.ForgotInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	if input.Channel == "" {
+		input.Channel = notifier.Email
+	}
+
+	r.recoveryUsecase.Forgot(input.UsernameOrEmail, input.Channel)
+	ctx.Status(http.StatusAccepted).JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (r recoveryHandler) Reset(ctx *fiber.Ctx) {
+	input := new(model.ResetInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	if input.Channel == "" {
+		input.Channel = notifier.Email
+	}
+
+	if err := r.recoveryUsecase.Reset(ctx.Context(), input.UsernameOrEmail, input.Channel, input.Code, input.Password); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"github.com/fajardm/ewallet-example/notifier"
+	"github.com/fajardm/ewallet-example/password"
+	"github.com/fajardm/ewallet-example/validator"
+)
+
+type ForgotInput struct {
+	UsernameOrEmail string `json:"username_or_email" validate:"required,max=128"`
+	// Channel is where the reset code is sent, email when empty
+	Channel notifier.Channel `json:"channel" validate:"omitempty,oneof=email sms"`
+}
+
+func (f ForgotInput) Validate() error {
+	return validator.Validate().Struct(f)
+}
+
+type ResetInput struct {
+	UsernameOrEmail string           `json:"username_or_email" validate:"required,max=128"`
+	Channel         notifier.Channel `json:"channel" validate:"omitempty,oneof=email sms"`
+	Code            string           `json:"code" validate:"required,max=10,numeric"`
+	Password        string           `json:"password" validate:"required"`
+}
+
+func (r ResetInput) Validate() error {
+	if err := validator.Validate().Struct(r); err != nil {
+		return err
+	}
+	return password.Check(r.Password)
+}
@@ -0,0 +1,12 @@
+package recovery
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/notifier"
+)
+
+// Usecase represent the account recovery's usecase contract
+type Usecase interface {
+	Forgot(usernameOrEmail string, channel notifier.Channel)
+	Reset(ctx context.Context, usernameOrEmail string, channel notifier.Channel, code, password string) error
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/otp"
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	"github.com/fajardm/ewallet-example/app/recovery"
+	"github.com/fajardm/ewallet-example/app/user"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+type recoveryUsecase struct {
+	userRepository user.Repository
+	otpUsecase     otp.Usecase
+	authUsecase    auth.Usecase
+	auditor        audit.Auditor
+	contextTimeout time.Duration
+}
+
+func NewRecoveryUsecase(userRepository user.Repository, otpUsecase otp.Usecase, authUsecase auth.Usecase, auditor audit.Auditor, contextTimeout time.Duration) recovery.Usecase {
+	return recoveryUsecase{userRepository: userRepository, otpUsecase: otpUsecase, authUsecase: authUsecase, auditor: auditor, contextTimeout: contextTimeout}
+}
+
+// Forgot sends reset code to the email or mobile phone of the user in the background and returns right away. Unknown,
+// closed and rate limited accounts as well as delivery failures look the same to the caller, so neither the
+// response nor its timing tells whether the account exists
+func (r recoveryUsecase) Forgot(usernameOrEmail string, channel notifier.Channel) {
+	go r.sendResetCode(usernameOrEmail, channel)
+}
+
+// sendResetCode issues and delivers the reset code, failures are only logged
+func (r recoveryUsecase) sendResetCode(usernameOrEmail string, channel notifier.Channel) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.contextTimeout)
+	defer cancel()
+
+	u, err := r.userRepository.GetByUsernameOrEmail(ctx, usernameOrEmail, usernameOrEmail)
+	if err == errorcode.ErrNotFound {
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("find user for password reset")
+		return
+	}
+	if u.Status == base.Closed {
+		return
+	}
+	to := destination(*u, channel)
+	if to == "" {
+		log.WithField("user_id", u.ID).Warn("password reset requested on channel without destination")
+		return
+	}
+
+	_, err = r.otpUsecase.Issue(ctx, _otpModel.ResetPassword, channel, to)
+	if err != nil && err != errorcode.ErrTooManyAttempts {
+		log.WithError(err).WithField("user_id", u.ID).Error("send password reset code")
+	}
+}
+
+// Reset consumes the reset code, replaces the password and revokes every session of the user. Unknown
+// account fails the same way as wrong code, and so does a code locked after too many wrong attempts, unknown
+// accounts have no code to lock
+func (r recoveryUsecase) Reset(ctx context.Context, usernameOrEmail string, channel notifier.Channel, code, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	u, err := r.userRepository.GetByUsernameOrEmail(ctx, usernameOrEmail, usernameOrEmail)
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrInvalidCredential
+	}
+	if err != nil {
+		return err
+	}
+	if u.Status == base.Closed {
+		return errorcode.ErrInvalidCredential
+	}
+	err = r.otpUsecase.Verify(ctx, _otpModel.ResetPassword, channel, destination(*u, channel), code)
+	if err == errorcode.ErrTooManyAttempts {
+		return errorcode.ErrInvalidCredential
+	}
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := _userModel.GeneratePassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	u.HashedPassword = hashedPassword
+	u.UpdatedBy = &u.ID
+	u.UpdatedAt = &now
+	if err := r.userRepository.UpdateHashedPassword(ctx, *u); err != nil {
+		return err
+	}
+	audit.Record(ctx, r.auditor, audit.Event{ActorID: &u.ID, Action: audit.UserPasswordReset, TargetType: "user", TargetID: u.ID})
+	return r.authUsecase.RevokeByUserID(ctx, u.ID)
+}
+
+func destination(u _userModel.User, channel notifier.Channel) string {
+	if channel == notifier.SMS {
+		return u.MobilePhone
+	}
+	return u.Email
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/role"
+	"github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+)
+
+type roleHandler struct {
+	roleUsecase role.Usecase
+}
+
+func NewRoleHandler(app *bootstrap.Bootstrap, roleUsecase role.Usecase) {
+	handler := roleHandler{roleUsecase: roleUsecase}
+	app.Admin.Get("/users/:id/roles", middleware.Require(model.UsersRead), handler.Get)
+	app.Admin.Post("/users/:id/roles", middleware.Require(model.RolesManage), handler.Assign)
+	app.Admin.Delete("/users/:id/roles/:role", middleware.Require(model.RolesManage), handler.Revoke)
+}
+
+func (r roleHandler) Get(ctx *fiber.Ctx) {
+	userID, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := r.roleUsecase.GetGrant(ctx.Context(), userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (r roleHandler) Assign(ctx *fiber.Ctx) {
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	userID, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	input := new(model.AssignInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := r.roleUsecase.Assign(ctx.Context(), *actorID, userID, input.Role); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (r roleHandler) Revoke(ctx *fiber.Ctx) {
+	actorID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	userID, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := r.roleUsecase.Revoke(ctx.Context(), *actorID, userID, ctx.Params("role")); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
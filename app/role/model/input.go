@@ -0,0 +1,11 @@
+package model
+
+import "github.com/fajardm/ewallet-example/validator"
+
+type AssignInput struct {
+	Role string `json:"role" validate:"required,max=32"`
+}
+
+func (a AssignInput) Validate() error {
+	return validator.Validate().Struct(a)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Roles, their permissions are stored in role_permissions
+const (
+	Admin    = "admin"
+	Support  = "support"
+	Customer = "customer"
+	Merchant = "merchant"
+)
+
+// Permissions checked by middleware.Require
+const (
+	// AdminAccess lets the user into /api/admin
+	AdminAccess        = "admin:access"
+	UsersRead          = "users:read"
+	UsersManage        = "users:manage"
+	UsersUnlock        = "users:unlock"
+	BalancesManage     = "balances:manage"
+	BalancesTransfer   = "balances:transfer"
+	AdjustmentsPropose = "adjustments:propose"
+	AdjustmentsReview  = "adjustments:review"
+	RolesManage        = "roles:manage"
+	KYCReview          = "kyc:review"
+	AuditRead          = "audit:read"
+)
+
+// UserRole is a role granted to the user
+type UserRole struct {
+	UserID    uuid.UUID
+	Role      string
+	CreatedBy uuid.UUID
+	CreatedAt time.Time
+}
+
+// Grant is the roles of the user and the permissions they give, embedded in access token
+type Grant struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// HasRole reports whether the grant contains the role
+func (g Grant) HasRole(role string) bool {
+	for _, r := range g.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/role/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Repository represent the role's repository contract
+type Repository interface {
+	GetGrantByUserID(context.Context, uuid.UUID) (*model.Grant, error)
+	Exists(ctx context.Context, role string) (bool, error)
+	CountUsers(ctx context.Context, role string) (int, error)
+	TxAssign(context.Context, *sql.Tx, model.UserRole) error
+	Revoke(ctx context.Context, userID uuid.UUID, role string) error
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/role"
+	"github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// Table roles
+	queryCountRole = `
+		SELECT COUNT(*) FROM roles WHERE name=?
+	`
+	// Table user_roles
+	querySelectUserRoles = `
+		SELECT role FROM user_roles WHERE user_id=? ORDER BY role ASC
+	`
+	queryCountUserRoles = `
+		SELECT COUNT(*) FROM user_roles WHERE role=?
+	`
+	queryInsertUserRole = `
+		INSERT INTO user_roles (
+			user_id,
+			role,
+			created_by,
+			created_at
+		) VALUES (?, ?, ?, ?)
+	`
+	queryDeleteUserRole = `
+		DELETE FROM user_roles WHERE user_id=? AND role=?
+	`
+	// Table role_permissions
+	querySelectUserPermissions = `
+		SELECT DISTINCT role_permissions.permission 
+		FROM role_permissions 
+		INNER JOIN user_roles ON user_roles.role = role_permissions.role 
+		WHERE user_roles.user_id=? 
+		ORDER BY role_permissions.permission ASC
+	`
+)
+
+type roleRepository struct {
+	db *database.MySQL
+}
+
+func NewRoleRepository(conn *database.MySQL) role.Repository {
+	return &roleRepository{db: conn}
+}
+
+func (r roleRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return r.db.WithTransaction(ctx, fn)
+}
+
+func (r roleRepository) GetGrantByUserID(ctx context.Context, userID uuid.UUID) (*model.Grant, error) {
+	roles, err := r.fetchStrings(ctx, querySelectUserRoles, userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := r.fetchStrings(ctx, querySelectUserPermissions, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Grant{Roles: roles, Permissions: permissions}, nil
+}
+
+func (r roleRepository) Exists(ctx context.Context, role string) (bool, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, queryCountRole, role).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r roleRepository) CountUsers(ctx context.Context, role string) (count int, err error) {
+	err = r.db.QueryRowContext(ctx, queryCountUserRoles, role).Scan(&count)
+	return
+}
+
+func (r roleRepository) TxAssign(ctx context.Context, tx *sql.Tx, userRole model.UserRole) (err error) {
+	_, err = tx.ExecContext(ctx, queryInsertUserRole, userRole.UserID, userRole.Role, userRole.CreatedBy, userRole.CreatedAt)
+	return
+}
+
+func (r roleRepository) Revoke(ctx context.Context, userID uuid.UUID, role string) error {
+	res, err := r.db.ExecContext(ctx, queryDeleteUserRole, userID, role)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrNotFound
+	}
+	return nil
+}
+
+func (r roleRepository) fetchStrings(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]string, 0)
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
@@ -0,0 +1,14 @@
+package role
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/role/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the role's usecase contract
+type Usecase interface {
+	GetGrant(context.Context, uuid.UUID) (*model.Grant, error)
+	Assign(ctx context.Context, actorID, userID uuid.UUID, role string) error
+	Revoke(ctx context.Context, actorID, userID uuid.UUID, role string) error
+}
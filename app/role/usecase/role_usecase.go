@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/auth"
+	"github.com/fajardm/ewallet-example/app/role"
+	"github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/audit"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+type roleUsecase struct {
+	roleRepository role.Repository
+	userRepository user.Repository
+	authUsecase    auth.Usecase
+	auditor        audit.Auditor
+	contextTimeout time.Duration
+}
+
+func NewRoleUsecase(roleRepository role.Repository, userRepository user.Repository, authUsecase auth.Usecase, auditor audit.Auditor, contextTimeout time.Duration) role.Usecase {
+	return roleUsecase{roleRepository: roleRepository, userRepository: userRepository, authUsecase: authUsecase, auditor: auditor, contextTimeout: contextTimeout}
+}
+
+func (r roleUsecase) GetGrant(ctx context.Context, userID uuid.UUID) (*model.Grant, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	if _, err := r.userRepository.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return r.roleRepository.GetGrantByUserID(ctx, userID)
+}
+
+// Assign grants the role to the user, it is embedded in the next access token issued on login or refresh
+func (r roleUsecase) Assign(ctx context.Context, actorID, userID uuid.UUID, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	exists, err := r.roleRepository.Exists(ctx, role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errorcode.ErrBadParamInput
+	}
+	grant, err := r.GetGrant(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if grant.HasRole(role) {
+		return errorcode.ErrConflict
+	}
+
+	userRole := model.UserRole{UserID: userID, Role: role, CreatedBy: actorID, CreatedAt: time.Now()}
+	err = r.roleRepository.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return r.roleRepository.TxAssign(ctx, tx, userRole)
+	})
+	if err != nil {
+		return err
+	}
+	after := append(append([]string{}, grant.Roles...), role)
+	audit.Record(ctx, r.auditor, audit.Event{ActorID: &actorID, Action: audit.RoleAssign, TargetType: "user", TargetID: userID, Before: audit.Snapshot(map[string][]string{"roles": grant.Roles}), After: audit.Snapshot(map[string][]string{"roles": after})})
+	return nil
+}
+
+// Revoke takes the role away and logs the user out everywhere, so access tokens carrying the role stop working
+// right away. The last admin can not be revoked
+func (r roleUsecase) Revoke(ctx context.Context, actorID, userID uuid.UUID, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.contextTimeout)
+	defer cancel()
+
+	grant, err := r.GetGrant(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !grant.HasRole(role) {
+		return errorcode.ErrNotFound
+	}
+	if role == model.Admin {
+		count, err := r.roleRepository.CountUsers(ctx, model.Admin)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return errorcode.ErrForbidden
+		}
+	}
+
+	if err := r.roleRepository.Revoke(ctx, userID, role); err != nil {
+		return err
+	}
+	after := make([]string, 0, len(grant.Roles))
+	for _, r := range grant.Roles {
+		if r != role {
+			after = append(after, r)
+		}
+	}
+	audit.Record(ctx, r.auditor, audit.Event{ActorID: &actorID, Action: audit.RoleRevoke, TargetType: "user", TargetID: userID, Before: audit.Snapshot(map[string][]string{"roles": grant.Roles}), After: audit.Snapshot(map[string][]string{"roles": after})})
+	return r.authUsecase.RevokeByUserID(ctx, userID)
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/twofactor"
+	"github.com/fajardm/ewallet-example/app/twofactor/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	"net/http"
+)
+
+type twoFactorHandler struct {
+	twoFactorUsecase twofactor.Usecase
+}
+
+func NewTwoFactorHandler(app *bootstrap.Bootstrap, twoFactorUsecase twofactor.Usecase) {
+	handler := twoFactorHandler{twoFactorUsecase: twoFactorUsecase}
+	api := app.Group("/api")
+	api.Post("/users/2fa/enroll", middleware.Protected(), middleware.CheckSession, handler.Enroll)
+	api.Get("/users/2fa/qr", middleware.Protected(), middleware.CheckSession, handler.QRCode)
+	api.Post("/users/2fa/enable", middleware.Protected(), middleware.CheckSession, handler.Enable)
+	api.Post("/users/2fa/disable", middleware.Protected(), middleware.CheckSession, handler.Disable)
+}
+
+func (t twoFactorHandler) Enroll(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := t.twoFactorUsecase.Enroll(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (t twoFactorHandler) QRCode(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := t.twoFactorUsecase.QRCode(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Set(fiber.HeaderContentType, "image/png")
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.SendBytes(data)
+}
+
+func (t twoFactorHandler) Enable(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.CodeInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	codes, err := t.twoFactorUsecase.Enable(ctx.Context(), *userID, input.Code)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": fiber.Map{"recovery_codes": codes}})
+}
+
+func (t twoFactorHandler) Disable(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.DisableInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := t.twoFactorUsecase.Disable(ctx.Context(), *userID, input.Password, input.Code); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
@@ -0,0 +1,29 @@
+package model
+
+import "github.com/fajardm/ewallet-example/validator"
+
+type CodeInput struct {
+	Code string `json:"code" validate:"required,max=16"`
+}
+
+func (c CodeInput) Validate() error {
+	return validator.Validate().Struct(c)
+}
+
+type DisableInput struct {
+	Code     string `json:"code" validate:"required,max=16"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (d DisableInput) Validate() error {
+	return validator.Validate().Struct(d)
+}
+
+type ChallengeInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=16"`
+}
+
+func (c ChallengeInput) Validate() error {
+	return validator.Validate().Struct(c)
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"github.com/fajardm/ewallet-example/token"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	uuid "github.com/satori/go.uuid"
+	"strings"
+	"time"
+)
+
+const (
+	period = 30
+	// skew accepts code of the previous and next period to tolerate clock drift
+	skew = 1
+	// recoveryCodeCount is number of recovery codes given when enabling two factor
+	recoveryCodeCount = 10
+)
+
+// TwoFactor is TOTP (RFC 6238) enrollment of a user, it is pending until EnabledAt is set
+type TwoFactor struct {
+	UserID       uuid.UUID
+	KeyURL       string
+	LastUsedStep int64
+	EnabledAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+}
+
+// NewTwoFactor generates new secret for the account
+func NewTwoFactor(userID uuid.UUID, issuer, account string, now time.Time) (*TwoFactor, error) {
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: issuer, AccountName: account, Period: period})
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactor{UserID: userID, KeyURL: key.URL(), CreatedAt: now}, nil
+}
+
+// Enabled reports whether the enrollment has been confirmed
+func (t TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// Key returns the TOTP key holding the secret
+func (t TwoFactor) Key() (*otp.Key, error) {
+	return otp.NewKeyFromURL(t.KeyURL)
+}
+
+// Validate checks the code against the previous, current and next period. Each period can only be used once,
+// so accepted code moves LastUsedStep forward and the caller must persist it
+func (t *TwoFactor) Validate(code string, now time.Time) (bool, error) {
+	key, err := t.Key()
+	if err != nil {
+		return false, err
+	}
+	current := now.Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		if step <= t.LastUsedStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(key.Secret(), time.Unix(step*period, 0), totp.ValidateOpts{Period: period})
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			t.LastUsedStep = step
+			t.UpdatedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Enrollment is given to the user to register the secret into authenticator app
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// RecoveryCode is single use code replacing TOTP code when the authenticator is lost, only its hash is persisted
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RecoveryCodes is list of recovery code model
+type RecoveryCodes []RecoveryCode
+
+// NewRecoveryCodes generates recovery codes of the user and returns their raw values
+func NewRecoveryCodes(userID uuid.UUID, now time.Time) (RecoveryCodes, []string, error) {
+	codes := make(RecoveryCodes, 0, recoveryCodeCount)
+	raws := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		raw = raw[:4] + "-" + raw[4:]
+		codes = append(codes, RecoveryCode{
+			ID:        uuid.NewV4(),
+			UserID:    userID,
+			CodeHash:  HashRecoveryCode(raw),
+			CreatedAt: now,
+		})
+		raws = append(raws, raw)
+	}
+	return codes, raws, nil
+}
+
+// HashRecoveryCode normalizes and hashes the recovery code, so it is not case nor dash sensitive
+func HashRecoveryCode(code string) string {
+	return token.Hash(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", "")))
+}
+
+// Challenge is returned by login instead of token when two factor is enabled
+type Challenge struct {
+	Required bool   `json:"two_factor_required"`
+	Token    string `json:"challenge_token"`
+	Expires  int64  `json:"expires"`
+}
+
+// ChallengeState is kept in session store under the challenge token until login is completed
+type ChallengeState struct {
+	UserID     uuid.UUID `json:"user_id"`
+	DeviceName string    `json:"device_name"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
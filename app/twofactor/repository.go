@@ -0,0 +1,22 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/twofactor/model"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+// Repository represent the two factor's repository contract
+type Repository interface {
+	GetByUserID(context.Context, uuid.UUID) (*model.TwoFactor, error)
+	Save(context.Context, model.TwoFactor) error
+	TxUpdate(context.Context, *sql.Tx, model.TwoFactor) error
+	UseStep(context.Context, model.TwoFactor) error
+	TxDelete(context.Context, *sql.Tx, uuid.UUID) error
+	TxStoreRecoveryCodes(context.Context, *sql.Tx, model.RecoveryCodes) error
+	TxDeleteRecoveryCodesByUserID(context.Context, *sql.Tx, uuid.UUID) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, at time.Time) error
+	WithTransaction(context.Context, func(tx *sql.Tx) error) error
+}
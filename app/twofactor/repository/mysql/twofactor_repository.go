@@ -0,0 +1,163 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/app/twofactor"
+	"github.com/fajardm/ewallet-example/app/twofactor/model"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+const (
+	// Table user_two_factors
+	querySelectTwoFactor = `
+		SELECT 
+			user_id,
+			key_url,
+			last_used_step,
+			enabled_at,
+			created_at,
+			updated_at
+		FROM user_two_factors
+	`
+	queryUpsertTwoFactor = `
+		INSERT INTO user_two_factors (
+			user_id,
+			key_url,
+			last_used_step,
+			enabled_at,
+			created_at
+		) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE 
+			key_url=VALUES(key_url),
+			last_used_step=VALUES(last_used_step),
+			enabled_at=VALUES(enabled_at),
+			created_at=VALUES(created_at),
+			updated_at=NULL
+	`
+	queryUpdateTwoFactor = `
+		UPDATE user_two_factors SET 
+			last_used_step=?,
+			enabled_at=?,
+			updated_at=?
+		WHERE user_id=?
+	`
+	queryUseStep = `
+		UPDATE user_two_factors SET 
+			last_used_step=?,
+			updated_at=?
+		WHERE user_id=? AND last_used_step<?
+	`
+	queryDeleteTwoFactor = `
+		DELETE FROM user_two_factors WHERE user_id=?
+	`
+	// Table user_recovery_codes
+	queryInsertRecoveryCode = `
+		INSERT INTO user_recovery_codes (
+			id,
+			user_id,
+			code_hash,
+			created_at
+		) VALUES (?, ?, ?, ?)
+	`
+	queryUseRecoveryCode = `
+		UPDATE user_recovery_codes SET 
+			used_at=?
+		WHERE user_id=? AND code_hash=? AND used_at IS NULL
+	`
+	queryDeleteRecoveryCodesByUserID = `
+		DELETE FROM user_recovery_codes WHERE user_id=?
+	`
+)
+
+type twoFactorRepository struct {
+	db *database.MySQL
+}
+
+func NewTwoFactorRepository(conn *database.MySQL) twofactor.Repository {
+	return &twoFactorRepository{db: conn}
+}
+
+func (t twoFactorRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return t.db.WithTransaction(ctx, fn)
+}
+
+func (t twoFactorRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.TwoFactor, error) {
+	q := querySelectTwoFactor + " WHERE user_id=?"
+	res := model.TwoFactor{}
+	err := t.db.QueryRowContext(ctx, q, userID).Scan(&res.UserID, &res.KeyURL, &res.LastUsedStep, &res.EnabledAt, &res.CreatedAt, &res.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Save stores the enrollment, replacing pending enrollment of the user
+func (t twoFactorRepository) Save(ctx context.Context, tf model.TwoFactor) (err error) {
+	_, err = t.db.ExecContext(ctx, queryUpsertTwoFactor, tf.UserID, tf.KeyURL, tf.LastUsedStep, tf.EnabledAt, tf.CreatedAt)
+	return
+}
+
+func (t twoFactorRepository) TxUpdate(ctx context.Context, tx *sql.Tx, tf model.TwoFactor) (err error) {
+	_, err = tx.ExecContext(ctx, queryUpdateTwoFactor, tf.LastUsedStep, tf.EnabledAt, tf.UpdatedAt, tf.UserID)
+	return
+}
+
+// UseStep moves last used step forward only, so concurrent requests can not reuse the same code
+func (t twoFactorRepository) UseStep(ctx context.Context, tf model.TwoFactor) (err error) {
+	res, err := t.db.ExecContext(ctx, queryUseStep, tf.LastUsedStep, tf.UpdatedAt, tf.UserID, tf.LastUsedStep)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected != 1 {
+		err = errorcode.ErrConflict
+		return
+	}
+	return
+}
+
+func (t twoFactorRepository) TxDelete(ctx context.Context, tx *sql.Tx, userID uuid.UUID) (err error) {
+	_, err = tx.ExecContext(ctx, queryDeleteTwoFactor, userID)
+	return
+}
+
+func (t twoFactorRepository) TxStoreRecoveryCodes(ctx context.Context, tx *sql.Tx, codes model.RecoveryCodes) (err error) {
+	for _, c := range codes {
+		if _, err = tx.ExecContext(ctx, queryInsertRecoveryCode, c.ID, c.UserID, c.CodeHash, c.CreatedAt); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (t twoFactorRepository) TxDeleteRecoveryCodesByUserID(ctx context.Context, tx *sql.Tx, userID uuid.UUID) (err error) {
+	_, err = tx.ExecContext(ctx, queryDeleteRecoveryCodesByUserID, userID)
+	return
+}
+
+// UseRecoveryCode marks unused recovery code as used, returns errorcode.ErrNotFound when no such code
+func (t twoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, at time.Time) (err error) {
+	res, err := t.db.ExecContext(ctx, queryUseRecoveryCode, at, userID, hash)
+	if err != nil {
+		return
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected != 1 {
+		err = errorcode.ErrNotFound
+		return
+	}
+	return
+}
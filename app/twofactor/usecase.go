@@ -0,0 +1,19 @@
+package twofactor
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/twofactor/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the two factor's usecase contract
+type Usecase interface {
+	Enroll(context.Context, uuid.UUID) (*model.Enrollment, error)
+	QRCode(context.Context, uuid.UUID) ([]byte, error)
+	Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Disable(ctx context.Context, userID uuid.UUID, password, code string) error
+	Enabled(context.Context, uuid.UUID) (bool, error)
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+	Challenge(ctx context.Context, userID uuid.UUID, deviceName string) (*model.Challenge, error)
+	CompleteChallenge(ctx context.Context, challengeToken, code string) (*model.ChallengeState, error)
+}
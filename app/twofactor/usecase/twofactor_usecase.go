@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/fajardm/ewallet-example/app/twofactor"
+	"github.com/fajardm/ewallet-example/app/twofactor/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	"image/png"
+	"time"
+)
+
+const (
+	challengeTTL   = time.Minute * 5
+	maxAttempts    = 5
+	attemptsWindow = time.Minute * 15
+	qrCodeSize     = 256
+)
+
+type twoFactorUsecase struct {
+	twoFactorRepository twofactor.Repository
+	userRepository      user.Repository
+	issuer              string
+	contextTimeout      time.Duration
+}
+
+func NewTwoFactorUsecase(twoFactorRepository twofactor.Repository, userRepository user.Repository, issuer string, contextTimeout time.Duration) twofactor.Usecase {
+	return twoFactorUsecase{twoFactorRepository: twoFactorRepository, userRepository: userRepository, issuer: issuer, contextTimeout: contextTimeout}
+}
+
+// Enroll generates new secret, the enrollment stays pending until confirmed by Enable
+func (t twoFactorUsecase) Enroll(ctx context.Context, userID uuid.UUID) (*model.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	existed, err := t.twoFactorRepository.GetByUserID(ctx, userID)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	if existed != nil && existed.Enabled() {
+		return nil, errorcode.ErrConflict
+	}
+
+	u, err := t.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tf, err := model.NewTwoFactor(userID, t.issuer, u.Email, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := t.twoFactorRepository.Save(ctx, *tf); err != nil {
+		return nil, err
+	}
+
+	key, err := tf.Key()
+	if err != nil {
+		return nil, err
+	}
+	return &model.Enrollment{Secret: key.Secret(), URI: tf.KeyURL}, nil
+}
+
+// QRCode renders provisioning uri of pending enrollment as PNG
+func (t twoFactorUsecase) QRCode(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	tf, err := t.twoFactorRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, errorcode.ErrNotFound
+	}
+
+	key, err := tf.Key()
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Enable confirms pending enrollment with a code and returns recovery codes, they are shown only once
+func (t twoFactorUsecase) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	tf, err := t.twoFactorRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, errorcode.ErrConflict
+	}
+
+	now := time.Now()
+	valid, err := tf.Validate(code, now)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, errorcode.ErrInvalidCredential
+	}
+	tf.EnabledAt = &now
+
+	codes, raws, err := model.NewRecoveryCodes(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	err = t.twoFactorRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = t.twoFactorRepository.TxUpdate(ctx, tx, *tf); err != nil {
+			return err
+		}
+		if err = t.twoFactorRepository.TxDeleteRecoveryCodesByUserID(ctx, tx, userID); err != nil {
+			return err
+		}
+		if err = t.twoFactorRepository.TxStoreRecoveryCodes(ctx, tx, codes); err != nil {
+			return err
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// Disable removes two factor, requires both the password and a current code
+func (t twoFactorUsecase) Disable(ctx context.Context, userID uuid.UUID, password, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	u, err := t.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if valid, _ := u.ValidatePassword(password); !valid {
+		return errorcode.ErrInvalidCredential
+	}
+	if err := t.verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return t.twoFactorRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = t.twoFactorRepository.TxDeleteRecoveryCodesByUserID(ctx, tx, userID); err != nil {
+			return err
+		}
+		if err = t.twoFactorRepository.TxDelete(ctx, tx, userID); err != nil {
+			return err
+		}
+		return
+	})
+}
+
+func (t twoFactorUsecase) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	tf, err := t.twoFactorRepository.GetByUserID(ctx, userID)
+	if err == errorcode.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled(), nil
+}
+
+// Verify checks TOTP or recovery code of the user, used to confirm sensitive actions
+func (t twoFactorUsecase) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	return t.verify(ctx, userID, code)
+}
+
+// Challenge starts second step of login, the returned token identifies the pending login
+func (t twoFactorUsecase) Challenge(ctx context.Context, userID uuid.UUID, deviceName string) (*model.Challenge, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	raw, err := token.NewOpaque()
+	if err != nil {
+		return nil, err
+	}
+	state := model.ChallengeState{UserID: userID, DeviceName: deviceName, ExpiresAt: time.Now().Add(challengeTTL)}
+	if err := t.saveChallenge(ctx, raw, state); err != nil {
+		return nil, err
+	}
+	return &model.Challenge{Required: true, Token: raw, Expires: state.ExpiresAt.Unix()}, nil
+}
+
+// CompleteChallenge verifies the code of pending login. Wrong codes count toward the lockout of the user rather than
+// the challenge, so logging in again does not give fresh attempts. The challenge is dropped on success or once the
+// user is locked out. A wrong code returns the state along with the error, so the failure can be recorded for the user
+func (t twoFactorUsecase) CompleteChallenge(ctx context.Context, challengeToken, code string) (*model.ChallengeState, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.contextTimeout)
+	defer cancel()
+
+	key := challengeKey(challengeToken)
+	value, err := session.Session().Get(ctx, key)
+	if err == errorcode.ErrNotFound {
+		return nil, errorcode.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := model.ChallengeState{}
+	if err := json.Unmarshal(value, &state); err != nil {
+		return nil, err
+	}
+
+	switch err := t.verify(ctx, state.UserID, code); err {
+	case nil:
+	case errorcode.ErrInvalidCredential:
+		return &state, err
+	case errorcode.ErrTooManyAttempts:
+		if err := session.Session().Delete(ctx, key); err != nil {
+			return nil, err
+		}
+		return &state, err
+	default:
+		return nil, err
+	}
+	if err := session.Session().Delete(ctx, key); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// verify accepts current TOTP code or unused recovery code. Every attempt is counted per user before comparing, so
+// concurrent guesses can not pass maxAttempts, using them up refuses every code until attemptsWindow of the first
+// attempt ends. A valid code starts the count over
+func (t twoFactorUsecase) verify(ctx context.Context, userID uuid.UUID, code string) error {
+	tf, err := t.twoFactorRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled() {
+		return errorcode.ErrNotFound
+	}
+
+	key := attemptsKey(userID)
+	attempts, err := session.Session().Increment(ctx, key, attemptsWindow)
+	if err != nil {
+		return err
+	}
+	if attempts > maxAttempts {
+		return errorcode.ErrTooManyAttempts
+	}
+	if err := t.validate(ctx, *tf, code); err != nil {
+		return err
+	}
+	return session.Session().Delete(ctx, key)
+}
+
+func (t twoFactorUsecase) validate(ctx context.Context, tf model.TwoFactor, code string) error {
+	userID := tf.UserID
+	now := time.Now()
+	valid, err := tf.Validate(code, now)
+	if err != nil {
+		return err
+	}
+	if valid {
+		err := t.twoFactorRepository.UseStep(ctx, tf)
+		if err == errorcode.ErrConflict {
+			return errorcode.ErrInvalidCredential
+		}
+		return err
+	}
+
+	err = t.twoFactorRepository.UseRecoveryCode(ctx, userID, model.HashRecoveryCode(code), now)
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrInvalidCredential
+	}
+	return err
+}
+
+func (t twoFactorUsecase) saveChallenge(ctx context.Context, raw string, state model.ChallengeState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(state.ExpiresAt)
+	if ttl <= 0 {
+		return errorcode.ErrUnauthorized
+	}
+	return session.Session().Set(ctx, challengeKey(raw), value, ttl)
+}
+
+func challengeKey(raw string) string {
+	return "two_factor_challenge:" + token.Hash(raw)
+}
+
+func attemptsKey(userID uuid.UUID) string {
+	return "two_factor_attempts:" + userID.String()
+}
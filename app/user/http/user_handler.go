@@ -1,36 +1,48 @@
 package http
 
 import (
-	"fmt"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/auth"
+	_authModel "github.com/fajardm/ewallet-example/app/auth/model"
+	"github.com/fajardm/ewallet-example/app/login"
+	_loginModel "github.com/fajardm/ewallet-example/app/login/model"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/app/twofactor"
+	_twoFactorModel "github.com/fajardm/ewallet-example/app/twofactor/model"
 	"github.com/fajardm/ewallet-example/app/user"
 	"github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/app/verification"
 	"github.com/fajardm/ewallet-example/bootstrap"
 	"github.com/fajardm/ewallet-example/errorcode"
 	"github.com/fajardm/ewallet-example/middleware"
-	"github.com/fajardm/ewallet-example/session"
 	"github.com/fajardm/ewallet-example/validator"
 	"github.com/gofiber/fiber"
 	uuid "github.com/satori/go.uuid"
-	"github.com/spf13/viper"
+	log "github.com/sirupsen/logrus"
 	"net/http"
-	"time"
 )
 
 type userHandler struct {
-	userUsecase user.Usecase
+	userUsecase         user.Usecase
+	authUsecase         auth.Usecase
+	twoFactorUsecase    twofactor.Usecase
+	verificationUsecase verification.Usecase
+	loginUsecase        login.Usecase
 }
 
-func NewUserHandler(app *bootstrap.Bootstrap, userUsecase user.Usecase) {
-	handler := userHandler{userUsecase: userUsecase}
+func NewUserHandler(app *bootstrap.Bootstrap, userUsecase user.Usecase, authUsecase auth.Usecase, twoFactorUsecase twofactor.Usecase, verificationUsecase verification.Usecase, loginUsecase login.Usecase) {
+	handler := userHandler{userUsecase: userUsecase, authUsecase: authUsecase, twoFactorUsecase: twoFactorUsecase, verificationUsecase: verificationUsecase, loginUsecase: loginUsecase}
 	api := app.Group("/api")
 	api.Post("/users/login", handler.Login)
+	api.Post("/users/login/2fa", handler.CompleteLogin)
 	api.Delete("/users/logout", middleware.Protected(), middleware.CheckSession, handler.Logout)
 	api.Post("/users", handler.Store)
 	api.Get("/users", middleware.Protected(), middleware.CheckSession, handler.Get)
-	api.Put("/users", middleware.Protected(), middleware.CheckSession, handler.Update)
-	api.Delete("/users", middleware.Protected(), middleware.CheckSession, handler.Delete)
+	api.Patch("/users", middleware.Protected(), middleware.CheckSession, handler.Update)
+	api.Put("/users/password", middleware.Protected(), middleware.CheckSession, handler.ChangePassword)
+	api.Delete("/users", middleware.Protected(), middleware.CheckSession, middleware.StepUp, handler.Close)
+	app.Admin.Get("/users", middleware.Require(_roleModel.UsersRead), handler.Search)
+	app.Admin.Get("/users/:id", middleware.Require(_roleModel.UsersRead), handler.GetDetail)
+	app.Admin.Post("/users/:id/unlock", middleware.Require(_roleModel.UsersUnlock), handler.Unlock)
 }
 
 func (u userHandler) Login(ctx *fiber.Ctx) {
@@ -38,6 +50,7 @@ func (u userHandler) Login(ctx *fiber.Ctx) {
 	type Input struct {
 		UsernameOrEmail string `json:"username_or_email" validate:"required"`
 		Password        string `json:"password" validate:"required"`
+		DeviceName      string `json:"device_name" validate:"max=64"`
 	}
 	input := new(Input)
 	if err := ctx.BodyParser(input); err != nil {
@@ -51,33 +64,84 @@ func (u userHandler) Login(ctx *fiber.Ctx) {
 		return
 	}
 
-	user, err := u.userUsecase.Login(ctx.Context(), input.UsernameOrEmail, input.UsernameOrEmail, input.Password)
+	client := u.client(ctx)
+	user, err := u.userUsecase.Login(ctx.Context(), input.UsernameOrEmail, input.UsernameOrEmail, input.Password, client.IP)
 	if err != nil {
+		if err := u.loginUsecase.RecordFailure(ctx.Context(), input.UsernameOrEmail, client, err); err != nil {
+			log.WithError(err).Warn("record failed login")
+		}
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
 
-	// Create token
-	token := jwt.New(jwt.SigningMethodHS256)
+	// Second step is required before issuing token when two factor is enabled
+	enabled, err := u.twoFactorUsecase.Enabled(ctx.Context(), user.ID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	if enabled {
+		challenge, err := u.twoFactorUsecase.Challenge(ctx.Context(), user.ID, input.DeviceName)
+		if err != nil {
+			ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+			return
+		}
+		ctx.JSON(fiber.Map{"status": "success", "data": challenge})
+		return
+	}
+
+	u.issueToken(ctx, *user, input.DeviceName)
+}
+
+func (u userHandler) CompleteLogin(ctx *fiber.Ctx) {
+	input := new(_twoFactorModel.ChallengeInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
 
-	// Set claims
-	claims := token.Claims.(jwt.MapClaims)
-	claims["user_id"] = user.ID.String()
-	claims["username"] = user.Username
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
+	state, err := u.twoFactorUsecase.CompleteChallenge(ctx.Context(), input.ChallengeToken, input.Code)
+	if err != nil {
+		if state != nil {
+			if err := u.loginUsecase.RecordChallengeFailure(ctx.Context(), state.UserID, u.client(ctx), err); err != nil {
+				log.WithError(err).Warn("record failed login")
+			}
+		}
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	user, err := u.userUsecase.GetByID(ctx.Context(), state.UserID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	u.issueToken(ctx, *user, state.DeviceName)
+}
 
-	// Generate encoded token and send it as response.
-	t, err := token.SignedString([]byte(viper.GetString("APP_SECRET")))
+// issueToken creates session with its access and refresh token as the login response
+func (u userHandler) issueToken(ctx *fiber.Ctx, user model.User, deviceName string) {
+	client := u.client(ctx)
+	device := _authModel.Device{Name: deviceName, UserAgent: client.UserAgent, IP: client.IP}
+	token, err := u.authUsecase.IssueToken(ctx.Context(), user, device)
 	if err != nil {
-		ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
+	if err := u.loginUsecase.RecordSuccess(ctx.Context(), user, client); err != nil {
+		log.WithError(err).Warn("record login")
+	}
 
-	session := session.Session().Get(ctx)
-	session.Set(fmt.Sprintf("%s:token", user.ID.String()), t)
-	session.Save()
+	ctx.JSON(fiber.Map{"status": "success", "data": token})
+}
 
-	ctx.JSON(fiber.Map{"status": "success", "data": fiber.Map{"token": t, "expires": claims["exp"]}})
+// client describes the client of the request for login history
+func (u userHandler) client(ctx *fiber.Ctx) _loginModel.Client {
+	return u.loginUsecase.Client(ctx.IP(), func(key string) string { return ctx.Get(key) })
 }
 
 func (u userHandler) Logout(ctx *fiber.Ctx) {
@@ -86,10 +150,16 @@ func (u userHandler) Logout(ctx *fiber.Ctx) {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
+	sessionID, err := middleware.GetSessionID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
 
-	session := session.Session().Get(ctx)
-	session.Delete(fmt.Sprintf("%s:token", userID.String()))
-	session.Save()
+	if err := u.authUsecase.RevokeSession(ctx.Context(), *userID, *sessionID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
 
 	ctx.JSON(fiber.Map{"status": "success", "data": true})
 }
@@ -113,6 +183,14 @@ func (u userHandler) Store(ctx *fiber.Ctx) {
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
+
+	// Failed delivery does not fail the registration, the user can ask to resend
+	if _, err := u.verificationUsecase.SendEmail(ctx.Context(), user.ID); err != nil {
+		log.WithError(err).WithField("user_id", user.ID).Warn("send email verification")
+	}
+	if _, err := u.verificationUsecase.SendPhone(ctx.Context(), user.ID); err != nil {
+		log.WithError(err).WithField("user_id", user.ID).Warn("send phone verification")
+	}
 	ctx.Status(http.StatusCreated).JSON(fiber.Map{"status": "success", "data": user})
 }
 
@@ -130,6 +208,7 @@ func (u userHandler) Get(ctx *fiber.Ctx) {
 	ctx.JSON(fiber.Map{"status": "success", "data": user})
 }
 
+// Update changes only the fields given in the body, omitted fields are kept
 func (u userHandler) Update(ctx *fiber.Ctx) {
 	id, err := middleware.GetUserID(ctx)
 	if err != nil {
@@ -138,64 +217,147 @@ func (u userHandler) Update(ctx *fiber.Ctx) {
 	}
 
 	// Binds input
-	type Input struct {
-		Email    string `json:"email" validate:"required,email,max=128"`
-		Password string `json:"password" validate:"required,max=10"`
-	}
-	input := new(Input)
+	input := new(model.UpdateInput)
 	if err := ctx.BodyParser(input); err != nil {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
 
-	// Validate input
-	if err := validator.Validate().Struct(input); err != nil {
+	// Updating data
+	data, err := u.userUsecase.Update(ctx.Context(), *id, *input)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// ChangePassword replaces the password and revokes every session but the current one
+func (u userHandler) ChangePassword(ctx *fiber.Ctx) {
+	id, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	sessionID, err := middleware.GetSessionID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	// Binds input
+	input := new(model.PasswordInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
 		return
 	}
 
-	// Preparing user model
-	hashedPassword, err := model.GeneratePassword(input.Password)
+	if err := u.userUsecase.ChangePassword(ctx.Context(), *id, input.CurrentPassword, input.NewPassword); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	if err := u.authUsecase.RevokeOtherSessions(ctx.Context(), *id, *sessionID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+// Close settles and closes the account of the user, optional body holds the payout account receiving the money left
+func (u userHandler) Close(ctx *fiber.Ctx) {
+	id, err := middleware.GetUserID(ctx)
 	if err != nil {
-		ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
-	now := time.Now()
-	userModel := model.User{
-		Model: base.Model{
-			ID:        *id,
-			UpdatedBy: &uuid.UUID{},
-			UpdatedAt: &now,
-		},
-		Email:          input.Email,
-		HashedPassword: hashedPassword,
+
+	// Binds input
+	input := new(model.CloseInput)
+	if len(ctx.Fasthttp.Request.Body()) > 0 {
+		if err := ctx.BodyParser(input); err != nil {
+			ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+			return
+		}
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
 	}
 
-	// Updating data
-	if err := u.userUsecase.Update(ctx.Context(), userModel); err != nil {
+	// Close account
+	if err := u.userUsecase.Close(ctx.Context(), *id, input.PayoutAccount); err != nil {
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
+	if err := u.authUsecase.RevokeByUserID(ctx.Context(), *id); err != nil {
+		log.WithError(err).WithField("user_id", id).Warn("revoke sessions of closed account")
+	}
 
-	// Resolve new data
-	data, err := u.userUsecase.GetByID(ctx.Context(), *id)
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+// Search finds users by q, status, created_from, created_to (YYYY-MM-DD), sort, page and per_page query string
+func (u userHandler) Search(ctx *fiber.Ctx) {
+	input := model.SearchInput{
+		Query:       ctx.Query("q"),
+		Status:      ctx.Query("status"),
+		CreatedFrom: ctx.Query("created_from"),
+		CreatedTo:   ctx.Query("created_to"),
+		Sort:        ctx.Query("sort"),
+		Page:        ctx.Query("page"),
+		PerPage:     ctx.Query("per_page"),
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+	search, err := input.NewSearch()
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	data, err := u.userUsecase.Search(ctx.Context(), *search)
 	if err != nil {
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (u userHandler) GetDetail(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
 
+	data, err := u.userUsecase.GetDetail(ctx.Context(), id)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
 	ctx.JSON(fiber.Map{"status": "success", "data": data})
 }
 
-func (u userHandler) Delete(ctx *fiber.Ctx) {
-	id, err := middleware.GetUserID(ctx)
+func (u userHandler) Unlock(ctx *fiber.Ctx) {
+	id, err := uuid.FromString(ctx.Params("id"))
 	if err != nil {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
 
-	// Delete user
-	if err := u.userUsecase.Delete(ctx.Context(), *id); err != nil {
+	if err := u.userUsecase.Unlock(ctx.Context(), id); err != nil {
 		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
 		return
 	}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/password"
 	"github.com/fajardm/ewallet-example/validator"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"time"
 )
@@ -11,11 +13,14 @@ type Input struct {
 	Username    string `json:"username" validate:"required,max=45"`
 	Email       string `json:"email" validate:"required,email,max=128"`
 	MobilePhone string `json:"mobile_phone" validate:"required,max=13"`
-	Password    string `json:"password" validate:"required,max=10"`
+	Password    string `json:"password" validate:"required"`
 }
 
 func (i Input) Validate() error {
-	return validator.Validate().Struct(i)
+	if err := validator.Validate().Struct(i); err != nil {
+		return err
+	}
+	return password.Check(i.Password)
 }
 
 func (i Input) NewUser() (*User, error) {
@@ -33,6 +38,59 @@ func (i Input) NewUser() (*User, error) {
 		Username:       i.Username,
 		Email:          i.Email,
 		MobilePhone:    i.MobilePhone,
+		Status:         base.Active,
+		Tier:           base.Basic,
 		HashedPassword: hashedPassword,
 	}, nil
 }
+
+// CloseInput is request to close the account, PayoutAccount receives the money left in the main balance
+type CloseInput struct {
+	PayoutAccount string `json:"payout_account" validate:"max=64"`
+}
+
+func (i CloseInput) Validate() error {
+	return validator.Validate().Struct(i)
+}
+
+// UpdateInput is partial profile update, only the given fields are changed
+type UpdateInput struct {
+	Username    *string `json:"username" validate:"omitempty,min=1,max=45"`
+	Email       *string `json:"email" validate:"omitempty,email,max=128"`
+	MobilePhone *string `json:"mobile_phone" validate:"omitempty,min=1,max=13"`
+}
+
+func (i UpdateInput) Validate() error {
+	if i.Username == nil && i.Email == nil && i.MobilePhone == nil {
+		return errors.New("at least one of username, email or mobile_phone is required")
+	}
+	return validator.Validate().Struct(i)
+}
+
+// Apply copies the given fields into the user, verification of a changed email or mobile phone is reset by repository
+func (i UpdateInput) Apply(u *User, at time.Time) {
+	if i.Username != nil {
+		u.Username = *i.Username
+	}
+	if i.Email != nil {
+		u.Email = *i.Email
+	}
+	if i.MobilePhone != nil {
+		u.MobilePhone = *i.MobilePhone
+	}
+	u.UpdatedBy = &u.ID
+	u.UpdatedAt = &at
+}
+
+// PasswordInput is request to change password, the current password proves the owner is changing it
+type PasswordInput struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+func (i PasswordInput) Validate() error {
+	if err := validator.Validate().Struct(i); err != nil {
+		return err
+	}
+	return password.Check(i.NewPassword)
+}
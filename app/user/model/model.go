@@ -2,30 +2,85 @@ package model
 
 import (
 	"github.com/fajardm/ewallet-example/app/base"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/fajardm/ewallet-example/password"
+	"strings"
+	"time"
 )
 
 // User is user model
 type User struct {
 	base.Model
-	Username       string `json:"username"`
-	Email          string `json:"email"`
-	MobilePhone    string `json:"mobile_phone"`
-	HashedPassword []byte `json:"-"`
+	Username        string             `json:"username"`
+	Email           string             `json:"email"`
+	MobilePhone     string             `json:"mobile_phone"`
+	Status          base.AccountStatus `json:"status"`
+	Tier            base.Tier          `json:"tier"`
+	EmailVerifiedAt *time.Time         `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time         `json:"phone_verified_at"`
+	HashedPassword  []byte             `json:"-"`
+	DeletedAt       *time.Time         `json:"deleted_at,omitempty"`
+}
+
+// EmailVerified reports whether the current email is proven to belong to the user
+func (u User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// PhoneVerified reports whether the current mobile phone is proven to belong to the user
+func (u User) PhoneVerified() bool {
+	return u.PhoneVerifiedAt != nil
+}
+
+// Deleted reports whether the account was closed and its personal data anonymised
+func (u User) Deleted() bool {
+	return u.DeletedAt != nil
+}
+
+// Anonymize closes the account and replaces personal data with values derived from the id, which stay unique
+// but can not be linked back to the person
+func (u *User) Anonymize(at time.Time) {
+	id := strings.ReplaceAll(u.ID.String(), "-", "")
+	u.Username = "deleted_" + id
+	u.Email = id + "@deleted.invalid"
+	u.MobilePhone = "x" + id[:12]
+	u.HashedPassword = []byte{}
+	u.EmailVerifiedAt = nil
+	u.PhoneVerifiedAt = nil
+	u.Status = base.Closed
+	u.UpdatedBy = &u.ID
+	u.UpdatedAt = &at
+	u.DeletedAt = &at
+}
+
+// ChangedFields returns json names of the profile fields that differ from before
+func (u User) ChangedFields(before User) []string {
+	res := make([]string, 0)
+	if u.Username != before.Username {
+		res = append(res, "username")
+	}
+	if u.Email != before.Email {
+		res = append(res, "email")
+	}
+	if u.MobilePhone != before.MobilePhone {
+		res = append(res, "mobile_phone")
+	}
+	return res
 }
 
 // Users represent list of User
 type Users []User
 
-// ValidatePassword will check if passwords are matched
-func (u User) ValidatePassword(password string) (bool, error) {
-	if err := bcrypt.CompareHashAndPassword(u.HashedPassword, []byte(password)); err != nil {
-		return false, err
-	}
-	return true, nil
+// ValidatePassword will check if passwords are matched, both argon2id and legacy bcrypt hashes are accepted
+func (u User) ValidatePassword(plain string) (bool, error) {
+	return password.Compare(u.HashedPassword, plain)
+}
+
+// NeedsRehash reports whether the stored hash should be upgraded to the current argon2id params
+func (u User) NeedsRehash() bool {
+	return password.NeedsRehash(u.HashedPassword)
 }
 
 // GeneratePassword will generate a hashed password for us based on the user input
-func GeneratePassword(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func GeneratePassword(plain string) ([]byte, error) {
+	return password.Hash(plain)
 }
@@ -0,0 +1,111 @@
+package model
+
+import (
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
+	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/validator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// SearchInput is query string of admin user search, every field is optional
+type SearchInput struct {
+	Query       string `validate:"max=64"`
+	Status      string `validate:"omitempty,oneof=active debit_frozen frozen closed"`
+	CreatedFrom string `validate:"omitempty,len=10"`
+	CreatedTo   string `validate:"omitempty,len=10"`
+	Sort        string `validate:"omitempty,oneof=created_at -created_at username -username email -email"`
+	Page        string `validate:"omitempty,numeric"`
+	PerPage     string `validate:"omitempty,numeric"`
+}
+
+func (s SearchInput) Validate() error {
+	return validator.Validate().Struct(s)
+}
+
+// NewSearch parses the input, sorted by newest first and 20 users per page unless told otherwise
+func (s SearchInput) NewSearch() (*Search, error) {
+	res := &Search{Query: strings.TrimSpace(s.Query), Sort: "created_at", Desc: true, Page: 1, PerPage: 20}
+	if s.Status != "" {
+		status, err := base.AccountStatusFromString(s.Status)
+		if err != nil {
+			return nil, err
+		}
+		res.Status = &status
+	}
+	if s.CreatedFrom != "" {
+		from, err := time.ParseInLocation(dateLayout, s.CreatedFrom, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		res.CreatedFrom = &from
+	}
+	if s.CreatedTo != "" {
+		to, err := time.ParseInLocation(dateLayout, s.CreatedTo, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		// The whole day is included
+		to = to.AddDate(0, 0, 1)
+		res.CreatedBefore = &to
+	}
+	if s.Sort != "" {
+		res.Desc = strings.HasPrefix(s.Sort, "-")
+		res.Sort = strings.TrimPrefix(s.Sort, "-")
+	}
+	if s.Page != "" {
+		page, err := strconv.Atoi(s.Page)
+		if err != nil {
+			return nil, err
+		}
+		if page > 1 {
+			res.Page = page
+		}
+	}
+	if s.PerPage != "" {
+		perPage, err := strconv.Atoi(s.PerPage)
+		if err != nil {
+			return nil, err
+		}
+		if perPage > 0 && perPage <= 100 {
+			res.PerPage = perPage
+		}
+	}
+	return res, nil
+}
+
+// Search is criteria of admin user search. Query matches prefix of username, email, mobile phone or id
+type Search struct {
+	Query         string
+	Status        *base.AccountStatus
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	Sort          string
+	Desc          bool
+	Page          int
+	PerPage       int
+}
+
+// Offset is number of users skipped before the page
+func (s Search) Offset() int {
+	return (s.Page - 1) * s.PerPage
+}
+
+// SearchResult is one page of users found
+type SearchResult struct {
+	Users   Users `json:"users"`
+	Page    int   `json:"page"`
+	PerPage int   `json:"per_page"`
+	Total   int   `json:"total"`
+}
+
+// Detail is the user as seen by operator, along with its balances and recent history of the main balance
+type Detail struct {
+	User      User                           `json:"user"`
+	Roles     []string                       `json:"roles"`
+	Balances  _balanceModel.Balances         `json:"balances"`
+	Histories _balanceModel.BalanceHistories `json:"histories"`
+}
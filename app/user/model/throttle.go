@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// LoginPolicy limits failed logins per account and per IP
+type LoginPolicy struct {
+	// MaxFailures locks the account out for LockDuration
+	MaxFailures  int
+	LockDuration time.Duration
+	// BackoffAfter is the number of failures allowed before each next attempt has to wait, the wait
+	// starts from BackoffBase and doubles on every failure up to BackoffMax
+	BackoffAfter int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	// IPMaxFailures and IPBackoffAfter apply to the IP, they are higher than the account ones since many
+	// users may share one address
+	IPMaxFailures  int
+	IPBackoffAfter int
+}
+
+// Block tells how long login is refused after the count of failures within the window. Waiting starts after
+// backoffAfter failures, reaching maxFailures locks out for LockDuration and reports the count must start over
+func (p LoginPolicy) Block(failures int64, maxFailures, backoffAfter int) (wait time.Duration, locked bool) {
+	if failures >= int64(maxFailures) {
+		return p.LockDuration, true
+	}
+	if failures > int64(backoffAfter) {
+		wait = p.BackoffBase << uint(failures-int64(backoffAfter)-1)
+		if wait <= 0 || wait > p.BackoffMax {
+			wait = p.BackoffMax
+		}
+		return wait, false
+	}
+	return 0, false
+}
+
+// Window is how long failures are counted from the first one
+func (p LoginPolicy) Window() time.Duration {
+	if p.BackoffMax > p.LockDuration {
+		return p.BackoffMax
+	}
+	return p.LockDuration
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"github.com/fajardm/ewallet-example/app/user/model"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 // Repository represent the user's repository contract
@@ -12,7 +13,16 @@ type Repository interface {
 	TxStore(context.Context, *sql.Tx, model.User) error
 	GetByID(context.Context, uuid.UUID) (*model.User, error)
 	GetByUsernameOrEmail(context.Context, string, string) (*model.User, error)
+	GetByMobilePhone(context.Context, string) (*model.User, error)
+	Search(context.Context, model.Search) (model.Users, int, error)
 	Update(context.Context, model.User) error
+	UpdateHashedPassword(context.Context, model.User) error
+	VerifyEmail(ctx context.Context, id uuid.UUID, email string, at time.Time) error
+	VerifyPhone(ctx context.Context, id uuid.UUID, mobilePhone string, at time.Time) error
+	TxUpdateStatus(context.Context, *sql.Tx, model.User) error
+	TxUpdateTier(context.Context, *sql.Tx, model.User) error
+	TxAnonymize(context.Context, *sql.Tx, model.User) error
+	FetchDeletedBefore(ctx context.Context, before time.Time, limit int) (model.Users, error)
 	TxDelete(context.Context, *sql.Tx, uuid.UUID) error
 	WithTransaction(context.Context, func(tx *sql.Tx) error) error
 }
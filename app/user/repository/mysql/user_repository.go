@@ -8,7 +8,10 @@ import (
 	"github.com/fajardm/ewallet-example/app/user/model"
 	"github.com/fajardm/ewallet-example/database"
 	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/go-sql-driver/mysql"
 	uuid "github.com/satori/go.uuid"
+	"strings"
+	"time"
 )
 
 const (
@@ -19,32 +22,91 @@ const (
 			username,
 			email,
 			mobile_phone,
+			status,
+			tier,
+			email_verified_at,
+			phone_verified_at,
 			hashed_password,
 			created_by,
 			created_at,
 			updated_by,
-			updated_at 
+			updated_at,
+			deleted_at 
 		FROM users
 	`
+	queryCountUser = `
+		SELECT COUNT(*) FROM users
+	`
 	queryInsertUser = `
 		INSERT INTO users (
 			id,
 			username,
 			email,
 			mobile_phone,
+			status,
 			hashed_password,
 			created_by,
 			created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	queryUpdateUser = `
-		UPDATE users SET email=?, hashed_password=?, updated_by=?, updated_at=? WHERE id=?
+		UPDATE users SET
+			username=?,
+			email_verified_at=IF(email=?, email_verified_at, NULL),
+			email=?,
+			phone_verified_at=IF(mobile_phone=?, phone_verified_at, NULL),
+			mobile_phone=?,
+			updated_by=?,
+			updated_at=?
+		WHERE id=? AND deleted_at IS NULL
+	`
+	queryUpdateUserHashedPassword = `
+		UPDATE users SET hashed_password=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryVerifyUserEmail = `
+		UPDATE users SET email_verified_at=? WHERE id=? AND email=?
+	`
+	queryVerifyUserPhone = `
+		UPDATE users SET phone_verified_at=? WHERE id=? AND mobile_phone=?
+	`
+	queryUpdateUserStatus = `
+		UPDATE users SET status=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryUpdateUserTier = `
+		UPDATE users SET tier=?, updated_by=?, updated_at=? WHERE id=?
+	`
+	queryAnonymizeUser = `
+		UPDATE users SET
+			username=?,
+			email=?,
+			mobile_phone=?,
+			hashed_password=?,
+			email_verified_at=NULL,
+			phone_verified_at=NULL,
+			status=?,
+			updated_by=?,
+			updated_at=?,
+			deleted_at=?
+		WHERE id=? AND deleted_at IS NULL
 	`
 	queryDeleteUser = `
 		DELETE FROM users WHERE id=?
 	`
 )
 
+// errDuplicateEntry is MySQL error number of unique index violation
+const errDuplicateEntry = 1062
+
+// sortColumns whitelists columns users can be sorted by, as they can not be passed as query argument
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"username":   "username",
+	"email":      "email",
+}
+
+// likeEscaper escapes wildcards of LIKE pattern, so they are matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type userRepository struct {
 	db *database.MySQL
 }
@@ -58,7 +120,7 @@ func (u userRepository) WithTransaction(ctx context.Context, fn func(tx *sql.Tx)
 }
 
 func (u userRepository) TxStore(ctx context.Context, tx *sql.Tx, user model.User) error {
-	_, err := tx.ExecContext(ctx, queryInsertUser, user.ID, user.Username, user.Email, user.MobilePhone, user.HashedPassword, user.CreatedBy, user.CreatedAt)
+	_, err := tx.ExecContext(ctx, queryInsertUser, user.ID, user.Username, user.Email, user.MobilePhone, user.Status, user.HashedPassword, user.CreatedBy, user.CreatedAt)
 	return err
 }
 
@@ -86,8 +148,136 @@ func (u userRepository) GetByUsernameOrEmail(ctx context.Context, username strin
 	return nil, errorcode.ErrNotFound
 }
 
+func (u userRepository) GetByMobilePhone(ctx context.Context, mobilePhone string) (*model.User, error) {
+	q := querySelectUser + " WHERE mobile_phone=?"
+	list, err := u.fetchContext(ctx, q, mobilePhone)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+	return nil, errorcode.ErrNotFound
+}
+
+// Search returns a page of users matching the criteria and the total of matching users
+func (u userRepository) Search(ctx context.Context, search model.Search) (model.Users, int, error) {
+	where := make([]string, 0)
+	args := make([]interface{}, 0)
+	if search.Query != "" {
+		prefix := likeEscaper.Replace(search.Query) + "%"
+		where = append(where, "(username LIKE ? OR email LIKE ? OR mobile_phone LIKE ? OR id LIKE ?)")
+		args = append(args, prefix, prefix, prefix, strings.ToLower(prefix))
+	}
+	if search.Status != nil {
+		where = append(where, "status=?")
+		args = append(args, *search.Status)
+	}
+	if search.CreatedFrom != nil {
+		where = append(where, "created_at>=?")
+		args = append(args, *search.CreatedFrom)
+	}
+	if search.CreatedBefore != nil {
+		where = append(where, "created_at<?")
+		args = append(args, *search.CreatedBefore)
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := u.db.QueryRowContext(ctx, queryCountUser+cond, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := sortColumns[search.Sort]
+	if !ok {
+		column = sortColumns["created_at"]
+	}
+	order := " ASC"
+	if search.Desc {
+		order = " DESC"
+	}
+	q := querySelectUser + cond + " ORDER BY " + column + order + ", id" + order + " LIMIT ? OFFSET ?"
+	list, err := u.fetchContext(ctx, q, append(args, search.PerPage, search.Offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Update changes the profile fields, password is only replaced by UpdateHashedPassword. Returns errorcode.ErrConflict
+// when the username, email or mobile phone has been taken meanwhile
 func (u userRepository) Update(ctx context.Context, user model.User) (err error) {
-	res, err := u.db.ExecContext(ctx, queryUpdateUser, user.Email, user.HashedPassword, user.UpdatedBy, user.UpdatedAt, user.ID)
+	res, err := u.db.ExecContext(ctx, queryUpdateUser, user.Username, user.Email, user.Email, user.MobilePhone, user.MobilePhone, user.UpdatedBy, user.UpdatedAt, user.ID)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == errDuplicateEntry {
+		return errorcode.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+// UpdateHashedPassword replaces the password hash along with who and when changed it, profile fields are left
+// untouched
+func (u userRepository) UpdateHashedPassword(ctx context.Context, user model.User) (err error) {
+	_, err = u.db.ExecContext(ctx, queryUpdateUserHashedPassword, user.HashedPassword, user.UpdatedBy, user.UpdatedAt, user.ID)
+	return
+}
+
+// VerifyEmail only marks the email when it has not been changed since the verification was sent
+func (u userRepository) VerifyEmail(ctx context.Context, id uuid.UUID, email string, at time.Time) error {
+	return u.verify(ctx, queryVerifyUserEmail, id, email, at)
+}
+
+// VerifyPhone only marks the mobile phone when it has not been changed since the code was sent
+func (u userRepository) VerifyPhone(ctx context.Context, id uuid.UUID, mobilePhone string, at time.Time) error {
+	return u.verify(ctx, queryVerifyUserPhone, id, mobilePhone, at)
+}
+
+func (u userRepository) verify(ctx context.Context, query string, id uuid.UUID, value string, at time.Time) error {
+	res, err := u.db.ExecContext(ctx, query, at, id, value)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrConflict
+	}
+	return nil
+}
+
+func (u userRepository) TxUpdateStatus(ctx context.Context, tx *sql.Tx, user model.User) (err error) {
+	res, err := tx.ExecContext(ctx, queryUpdateUserStatus, user.Status, user.UpdatedBy, user.UpdatedAt, user.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return
+	}
+	if affected > 1 {
+		err = fmt.Errorf("Weird behaviour. Total affected: %d", affected)
+		return
+	}
+	return
+}
+
+func (u userRepository) TxUpdateTier(ctx context.Context, tx *sql.Tx, user model.User) (err error) {
+	res, err := tx.ExecContext(ctx, queryUpdateUserTier, user.Tier, user.UpdatedBy, user.UpdatedAt, user.ID)
 	if err != nil {
 		return err
 	}
@@ -102,6 +292,29 @@ func (u userRepository) Update(ctx context.Context, user model.User) (err error)
 	return
 }
 
+// TxAnonymize overwrites personal data and marks the user deleted, returns errorcode.ErrConflict when the user is
+// already deleted
+func (u userRepository) TxAnonymize(ctx context.Context, tx *sql.Tx, user model.User) error {
+	res, err := tx.ExecContext(ctx, queryAnonymizeUser, user.Username, user.Email, user.MobilePhone, user.HashedPassword, user.Status, user.UpdatedBy, user.UpdatedAt, user.DeletedAt, user.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return errorcode.ErrConflict
+	}
+	return nil
+}
+
+// FetchDeletedBefore returns users deleted before the time, oldest first
+func (u userRepository) FetchDeletedBefore(ctx context.Context, before time.Time, limit int) (model.Users, error) {
+	q := querySelectUser + " WHERE deleted_at<? ORDER BY deleted_at LIMIT ?"
+	return u.fetchContext(ctx, q, before, limit)
+}
+
 func (u userRepository) TxDelete(ctx context.Context, tx *sql.Tx, id uuid.UUID) (err error) {
 	res, err := tx.ExecContext(ctx, queryDeleteUser, id)
 	if err != nil {
@@ -128,7 +341,7 @@ func (u userRepository) fetchContext(ctx context.Context, query string, args ...
 	res := make(model.Users, 0)
 	for rows.Next() {
 		r := model.User{}
-		err = rows.Scan(&r.ID, &r.Username, &r.Email, &r.MobilePhone, &r.HashedPassword, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt)
+		err = rows.Scan(&r.ID, &r.Username, &r.Email, &r.MobilePhone, &r.Status, &r.Tier, &r.EmailVerifiedAt, &r.PhoneVerifiedAt, &r.HashedPassword, &r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt, &r.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
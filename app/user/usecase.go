@@ -4,13 +4,20 @@ import (
 	"context"
 	"github.com/fajardm/ewallet-example/app/user/model"
 	uuid "github.com/satori/go.uuid"
+	"time"
 )
 
 // Usecase represent the user's usecase contract
 type Usecase interface {
-	Login(context.Context, string, string, string) (*model.User, error)
+	Login(ctx context.Context, username, email, password, ip string) (*model.User, error)
+	Unlock(context.Context, uuid.UUID) error
+	CheckPassword(ctx context.Context, id uuid.UUID, password string) error
 	Store(context.Context, model.User) error
 	GetByID(context.Context, uuid.UUID) (*model.User, error)
-	Update(context.Context, model.User) error
-	Delete(context.Context, uuid.UUID) error
+	Search(context.Context, model.Search) (*model.SearchResult, error)
+	GetDetail(context.Context, uuid.UUID) (*model.Detail, error)
+	Update(ctx context.Context, id uuid.UUID, input model.UpdateInput) (*model.User, error)
+	ChangePassword(ctx context.Context, id uuid.UUID, currentPassword, newPassword string) error
+	Close(ctx context.Context, id uuid.UUID, payoutAccount string) error
+	Purge(ctx context.Context, before time.Time) (model.Users, error)
 }
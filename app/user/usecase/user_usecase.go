@@ -6,44 +6,233 @@ import (
 	"github.com/fajardm/ewallet-example/app/balance"
 	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
 	"github.com/fajardm/ewallet-example/app/base"
+	"github.com/fajardm/ewallet-example/app/role"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
 	"github.com/fajardm/ewallet-example/app/user"
 	"github.com/fajardm/ewallet-example/app/user/model"
+	"github.com/fajardm/ewallet-example/audit"
 	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
 	uuid "github.com/satori/go.uuid"
+	"strings"
+	"sync"
 	"time"
 )
 
+// purgeBatchSize is the most users deleted by one Purge call, so the call fits in the context timeout
+const purgeBatchSize = 100
+
 type userUsecase struct {
 	userRepository    user.Repository
 	balanceRepository balance.Repository
+	roleRepository    role.Repository
+	auditor           audit.Auditor
+	loginPolicy       model.LoginPolicy
 	contextTimeout    time.Duration
 }
 
-func NewUserUsecase(userRepository user.Repository, balanceRepository balance.Repository, contextTimeout time.Duration) user.Usecase {
-	return userUsecase{userRepository: userRepository, balanceRepository: balanceRepository, contextTimeout: contextTimeout}
+func NewUserUsecase(userRepository user.Repository, balanceRepository balance.Repository, roleRepository role.Repository, auditor audit.Auditor, loginPolicy model.LoginPolicy, contextTimeout time.Duration) user.Usecase {
+	if loginPolicy.MaxFailures <= 0 {
+		loginPolicy.MaxFailures = 10
+	}
+	if loginPolicy.LockDuration <= 0 {
+		loginPolicy.LockDuration = time.Minute * 15
+	}
+	if loginPolicy.BackoffAfter <= 0 {
+		loginPolicy.BackoffAfter = 3
+	}
+	if loginPolicy.BackoffBase <= 0 {
+		loginPolicy.BackoffBase = time.Second
+	}
+	if loginPolicy.BackoffMax <= 0 {
+		loginPolicy.BackoffMax = time.Minute * 5
+	}
+	if loginPolicy.IPMaxFailures <= 0 {
+		loginPolicy.IPMaxFailures = 50
+	}
+	if loginPolicy.IPBackoffAfter <= 0 {
+		loginPolicy.IPBackoffAfter = 20
+	}
+	return userUsecase{userRepository: userRepository, balanceRepository: balanceRepository, roleRepository: roleRepository, auditor: auditor, loginPolicy: loginPolicy, contextTimeout: contextTimeout}
+}
+
+func accountAttemptsKey(id string) string {
+	return "login_attempts:account:" + id
 }
 
-func (u userUsecase) Login(ctx context.Context, username, email, password string) (*model.User, error) {
+func ipAttemptsKey(ip string) string {
+	return "login_attempts:ip:" + ip
+}
+
+// blockedKey marks the account or IP of the attempts key as refused, it expires when login is allowed again
+func blockedKey(attemptsKey string) string {
+	return "blocked:" + attemptsKey
+}
+
+var dummyOnce sync.Once
+var dummyHash []byte
+
+// compareDummy spends the same time as comparing real password, so unknown account can not be told by response time
+func compareDummy(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = model.GeneratePassword(uuid.NewV4().String())
+	})
+	model.User{HashedPassword: dummyHash}.ValidatePassword(password)
+}
+
+// Login returns errorcode.ErrInvalidCredential for unknown account and wrong password alike. Failures are counted per
+// account and per IP, too many of them are answered with errorcode.ErrTooManyAttempts until the backoff or lockout ends
+func (u userUsecase) Login(ctx context.Context, username, email, password, ip string) (*model.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	user, err := u.userRepository.GetByUsernameOrEmail(ctx, username, email)
-	if err != nil {
+	ipKey := ipAttemptsKey(ip)
+	if err := u.checkBlocked(ctx, ipKey); err != nil {
 		return nil, err
 	}
 
-	valid, err := user.ValidatePassword(password)
-	if err != nil {
+	user, err := u.userRepository.GetByUsernameOrEmail(ctx, username, email)
+	if err != nil && err != errorcode.ErrNotFound {
+		return nil, err
+	}
+	// Unknown account is throttled by the given identifier, so it is locked out the same way as existing one
+	accountKey := accountAttemptsKey(strings.ToLower(username))
+	if user != nil {
+		accountKey = accountAttemptsKey(user.ID.String())
+	}
+	if err := u.checkBlocked(ctx, accountKey); err != nil {
 		return nil, err
 	}
 
+	valid := false
+	if user != nil {
+		if valid, err = user.ValidatePassword(password); err != nil {
+			return nil, err
+		}
+	} else {
+		compareDummy(password)
+	}
 	if !valid {
-		return nil, errorcode.ErrUnauthorized
+		if err := u.fail(ctx, accountKey, u.loginPolicy.MaxFailures, u.loginPolicy.BackoffAfter); err != nil {
+			return nil, err
+		}
+		if err := u.fail(ctx, ipKey, u.loginPolicy.IPMaxFailures, u.loginPolicy.IPBackoffAfter); err != nil {
+			return nil, err
+		}
+		return nil, errorcode.ErrInvalidCredential
+	}
+	if err := session.Session().Delete(ctx, accountKey); err != nil {
+		return nil, err
+	}
+
+	if user.Status == base.Closed {
+		return nil, errorcode.ErrAccountClosed
+	}
+
+	// Upgrade legacy bcrypt or outdated argon2id hash while the plain password is known, failing to
+	// upgrade does not fail the login
+	if user.NeedsRehash() {
+		if hashed, err := model.GeneratePassword(password); err == nil {
+			upgraded := *user
+			now := time.Now()
+			upgraded.HashedPassword = hashed
+			upgraded.UpdatedBy = &user.ID
+			upgraded.UpdatedAt = &now
+			if err := u.userRepository.UpdateHashedPassword(ctx, upgraded); err == nil {
+				*user = upgraded
+			}
+		}
 	}
 
 	return user, nil
 }
 
+// Unlock clears failed login attempts of the account, the lockout by IP is left as is
+func (u userUsecase) Unlock(ctx context.Context, id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	existed, err := u.userRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Attempts made before the account was found by username are kept under the identifier
+	for _, id := range []string{existed.ID.String(), strings.ToLower(existed.Username), strings.ToLower(existed.Email)} {
+		key := accountAttemptsKey(id)
+		if err := session.Session().Delete(ctx, key); err != nil {
+			return err
+		}
+		if err := session.Session().Delete(ctx, blockedKey(key)); err != nil {
+			return err
+		}
+	}
+	audit.Record(ctx, u.auditor, audit.Event{Action: audit.UserUnlock, TargetType: "user", TargetID: existed.ID})
+	return nil
+}
+
+// CheckPassword confirms sensitive action of a logged in user with its password. Failures count toward the same
+// account lockout as Login, so the password can not be guessed through other endpoints
+func (u userUsecase) CheckPassword(ctx context.Context, id uuid.UUID, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	existed, err := u.userRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return u.checkPassword(ctx, *existed, password)
+}
+
+// checkPassword compares the password of the user, counting failures under the account login attempts
+func (u userUsecase) checkPassword(ctx context.Context, user model.User, password string) error {
+	accountKey := accountAttemptsKey(user.ID.String())
+	if err := u.checkBlocked(ctx, accountKey); err != nil {
+		return err
+	}
+	valid, err := user.ValidatePassword(password)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		if err := u.fail(ctx, accountKey, u.loginPolicy.MaxFailures, u.loginPolicy.BackoffAfter); err != nil {
+			return err
+		}
+		return errorcode.ErrInvalidCredential
+	}
+	return session.Session().Delete(ctx, accountKey)
+}
+
+// checkBlocked returns errorcode.ErrTooManyAttempts while the account or IP of the attempts key waits
+func (u userUsecase) checkBlocked(ctx context.Context, key string) error {
+	_, err := session.Session().Get(ctx, blockedKey(key))
+	if err == errorcode.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return errorcode.ErrTooManyAttempts
+}
+
+// fail counts the failure atomically, so concurrent failures are never lost, and blocks the next attempts as the
+// count requires. Locking out starts the count over
+func (u userUsecase) fail(ctx context.Context, key string, maxFailures, backoffAfter int) error {
+	failures, err := session.Session().Increment(ctx, key, u.loginPolicy.Window())
+	if err != nil {
+		return err
+	}
+	wait, locked := u.loginPolicy.Block(failures, maxFailures, backoffAfter)
+	if locked {
+		if err := session.Session().Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+	return session.Session().Set(ctx, blockedKey(key), []byte{1}, wait)
+}
+
 func (u userUsecase) Store(ctx context.Context, user model.User) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
@@ -63,7 +252,9 @@ func (u userUsecase) Store(ctx context.Context, user model.User) error {
 			CreatedAt: now,
 		},
 		UserID:  user.ID,
+		Kind:    _balanceModel.Main,
 		Balance: 0,
+		Status:  base.Active,
 		Histories: _balanceModel.BalanceHistories{
 			_balanceModel.BalanceHistory{
 				Model: base.Model{
@@ -76,6 +267,7 @@ func (u userUsecase) Store(ctx context.Context, user model.User) error {
 				BalanceAfter:  0,
 				Activity:      &activity,
 				Type:          _balanceModel.Credit,
+				Category:      _balanceModel.Opening,
 				IP:            nil,
 				Location:      nil,
 				UserAgent:     nil,
@@ -83,7 +275,7 @@ func (u userUsecase) Store(ctx context.Context, user model.User) error {
 		},
 	}
 
-	return u.userRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+	err := u.userRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
 		if err = u.userRepository.TxStore(ctx, tx, user); err != nil {
 			return err
 		}
@@ -93,8 +285,17 @@ func (u userUsecase) Store(ctx context.Context, user model.User) error {
 		if err = u.balanceRepository.TxStoreBalanceHistory(ctx, tx, userBalance.Histories[0]); err != nil {
 			return err
 		}
+		// Every registered user starts as customer, other roles are assigned by admin
+		if err = u.roleRepository.TxAssign(ctx, tx, _roleModel.UserRole{UserID: user.ID, Role: _roleModel.Customer, CreatedBy: user.ID, CreatedAt: now}); err != nil {
+			return err
+		}
 		return err
 	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, u.auditor, audit.Event{ActorID: &user.ID, Action: audit.UserRegister, TargetType: "user", TargetID: user.ID})
+	return nil
 }
 
 func (u userUsecase) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
@@ -104,19 +305,136 @@ func (u userUsecase) GetByID(ctx context.Context, id uuid.UUID) (*model.User, er
 	return u.userRepository.GetByID(ctx, id)
 }
 
-func (u userUsecase) Update(ctx context.Context, user model.User) error {
+func (u userUsecase) Search(ctx context.Context, search model.Search) (*model.SearchResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	existed, _ := u.GetByID(ctx, user.ID)
+	list, total, err := u.userRepository.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SearchResult{Users: list, Page: search.Page, PerPage: search.PerPage, Total: total}, nil
+}
+
+// GetDetail returns the user with its roles, balances and the latest histories of its main balance
+func (u userUsecase) GetDetail(ctx context.Context, id uuid.UUID) (*model.Detail, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	existed, err := u.userRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	grant, err := u.roleRepository.GetGrantByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := u.balanceRepository.FetchByUserID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.Detail{User: *existed, Roles: grant.Roles, Balances: balances, Histories: make(_balanceModel.BalanceHistories, 0)}
+	for _, b := range balances {
+		if b.Kind == _balanceModel.Main {
+			if res.Histories, err = u.balanceRepository.FetchBalanceHistoriesByBalanceID(ctx, b.ID, _balanceModel.BalanceHistoryFilter{}); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return res, nil
+}
+
+// Update changes only the given profile fields, a username, email or mobile phone owned by another user is conflict
+func (u userUsecase) Update(ctx context.Context, id uuid.UUID, input model.UpdateInput) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	existed, _ := u.GetByID(ctx, id)
+	if existed == nil {
+		return nil, errorcode.ErrNotFound
+	}
+	if err := existed.Status.CheckModify(); err != nil {
+		return nil, err
+	}
+
+	if input.Username != nil && *input.Username != existed.Username {
+		if err := checkTaken(u.userRepository.GetByUsernameOrEmail(ctx, *input.Username, *input.Username)); err != nil {
+			return nil, err
+		}
+	}
+	if input.Email != nil && *input.Email != existed.Email {
+		if err := checkTaken(u.userRepository.GetByUsernameOrEmail(ctx, *input.Email, *input.Email)); err != nil {
+			return nil, err
+		}
+	}
+	if input.MobilePhone != nil && *input.MobilePhone != existed.MobilePhone {
+		if err := checkTaken(u.userRepository.GetByMobilePhone(ctx, *input.MobilePhone)); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *existed
+	input.Apply(existed, time.Now())
+	changed := existed.ChangedFields(before)
+	if err := u.userRepository.Update(ctx, *existed); err != nil {
+		return nil, err
+	}
+	res, err := u.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, u.auditor, audit.Event{Action: audit.UserUpdate, TargetType: "user", TargetID: id, After: audit.Changes(changed)})
+	return res, nil
+}
+
+// checkTaken turns a found user into conflict, not found means the value is free
+func checkTaken(_ *model.User, err error) error {
+	if err == errorcode.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return errorcode.ErrConflict
+}
+
+// ChangePassword replaces the password after the current one is proven, wrong current password counts toward the
+// login lockout. Revoking other sessions is left to caller as only it knows the current session
+func (u userUsecase) ChangePassword(ctx context.Context, id uuid.UUID, currentPassword, newPassword string) error {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	existed, _ := u.GetByID(ctx, id)
 	if existed == nil {
 		return errorcode.ErrNotFound
 	}
+	if err := existed.Status.CheckModify(); err != nil {
+		return err
+	}
+	if err := u.checkPassword(ctx, *existed, currentPassword); err != nil {
+		return err
+	}
 
-	return u.userRepository.Update(ctx, user)
+	hashedPassword, err := model.GeneratePassword(newPassword)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	existed.HashedPassword = hashedPassword
+	existed.UpdatedBy = &id
+	existed.UpdatedAt = &now
+	if err := u.userRepository.UpdateHashedPassword(ctx, *existed); err != nil {
+		return err
+	}
+	audit.Record(ctx, u.auditor, audit.Event{Action: audit.UserPasswordChange, TargetType: "user", TargetID: id})
+	return nil
 }
 
-func (u userUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+// Close settles and closes the account. Money left in the main balance is paid out to the payout account, other
+// balances must be emptied first. Personal data is anonymised right away, balances and their histories are kept
+// until purged after the retention period
+func (u userUsecase) Close(ctx context.Context, id uuid.UUID, payoutAccount string) error {
 	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
@@ -124,18 +442,125 @@ func (u userUsecase) Delete(ctx context.Context, id uuid.UUID) error {
 	if existed == nil {
 		return errorcode.ErrNotFound
 	}
+	if existed.Deleted() {
+		return errorcode.ErrAccountClosed
+	}
+	if err := existed.Status.CheckDebit(); err != nil {
+		return err
+	}
 
-	return u.userRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
-		balance, err := u.balanceRepository.GetByUserID(ctx, id)
+	balances, err := u.balanceRepository.FetchByUserID(ctx, id)
+	if err != nil {
+		return err
+	}
+	ids := make([]uuid.UUID, len(balances))
+	for i, b := range balances {
+		ids[i] = b.ID
+	}
+	now := time.Now()
+	existed.Anonymize(now)
+
+	err = u.userRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		// Balances are read again and locked, so money moved in meanwhile is settled too and never overwritten
+		balances, err := u.balanceRepository.TxLockByIDs(ctx, tx, ids...)
 		if err != nil {
 			return err
 		}
-		if err = u.balanceRepository.TxDeleteBalanceHistoriesByBalanceID(ctx, tx, balance.ID); err != nil {
+		for i := range balances {
+			b := &balances[i]
+			if b.Kind != _balanceModel.Main || b.Balance <= 0 || b.OverdraftInterest > 0 {
+				if !b.Settled() {
+					return errorcode.ErrBalanceNotSettled
+				}
+				continue
+			}
+			if payoutAccount == "" {
+				return errorcode.ErrBalanceNotSettled
+			}
+			if err = b.Debit(b.Balance, _balanceModel.Withdrawal, "payout to "+payoutAccount+" on account closure", id, now); err != nil {
+				return err
+			}
+			b.UpdatedBy = &id
+			b.UpdatedAt = &now
+		}
+
+		if err = u.balanceRepository.TxDeleteMembersByUserID(ctx, tx, id); err != nil {
+			return err
+		}
+		for _, b := range balances {
+			if err = u.balanceRepository.TxDeleteMembersByBalanceID(ctx, tx, b.ID); err != nil {
+				return err
+			}
+			if len(b.Histories) > 0 {
+				if err = u.balanceRepository.TxUpdate(ctx, tx, b); err != nil {
+					return err
+				}
+				for _, history := range b.Histories {
+					if err = u.balanceRepository.TxStoreBalanceHistory(ctx, tx, history); err != nil {
+						return err
+					}
+				}
+			}
+			b.Status = base.Closed
+			b.UpdatedBy = &id
+			b.UpdatedAt = &now
+			if err = u.balanceRepository.TxUpdateStatus(ctx, tx, b); err != nil {
+				return err
+			}
+		}
+		if err = u.userRepository.TxAnonymize(ctx, tx, *existed); err != nil {
 			return err
 		}
-		if err = u.balanceRepository.TxDelete(ctx, tx, balance.ID); err != nil {
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, u.auditor, audit.Event{Action: audit.UserClose, TargetType: "user", TargetID: id, After: audit.Snapshot(map[string]base.AccountStatus{"status": existed.Status})})
+	return nil
+}
+
+// Purge permanently deletes a batch of users closed before the time together with their balances and histories,
+// returns the purged users so their files can be removed as well
+func (u userUsecase) Purge(ctx context.Context, before time.Time) (model.Users, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	users, err := u.userRepository.FetchDeletedBefore(ctx, before, purgeBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	res := make(model.Users, 0, len(users))
+	for _, existed := range users {
+		if err := u.delete(ctx, existed.ID); err != nil {
+			return res, err
+		}
+		res = append(res, existed)
+	}
+	return res, nil
+}
+
+func (u userUsecase) delete(ctx context.Context, id uuid.UUID) error {
+	balances, err := u.balanceRepository.FetchByUserID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return u.userRepository.WithTransaction(ctx, func(tx *sql.Tx) (err error) {
+		if err = u.balanceRepository.TxDeleteMembersByUserID(ctx, tx, id); err != nil {
 			return err
 		}
+		for _, balance := range balances {
+			if err = u.balanceRepository.TxDeleteMembersByBalanceID(ctx, tx, balance.ID); err != nil {
+				return err
+			}
+			if err = u.balanceRepository.TxDeleteBalanceHistoriesByBalanceID(ctx, tx, balance.ID); err != nil {
+				return err
+			}
+			if err = u.balanceRepository.TxDelete(ctx, tx, balance.ID); err != nil {
+				return err
+			}
+		}
 		if err = u.userRepository.TxDelete(ctx, tx, id); err != nil {
 			return err
 		}
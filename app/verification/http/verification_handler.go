@@ -0,0 +1,92 @@
+package http
+
+import (
+	"github.com/fajardm/ewallet-example/app/verification"
+	"github.com/fajardm/ewallet-example/app/verification/model"
+	"github.com/fajardm/ewallet-example/bootstrap"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/gofiber/fiber"
+	"net/http"
+)
+
+type verificationHandler struct {
+	verificationUsecase verification.Usecase
+}
+
+func NewVerificationHandler(app *bootstrap.Bootstrap, verificationUsecase verification.Usecase) {
+	handler := verificationHandler{verificationUsecase: verificationUsecase}
+	api := app.Group("/api")
+	api.Post("/users/verify/email/resend", middleware.Protected(), middleware.CheckSession, handler.SendEmail)
+	api.Get("/users/verify/email", handler.VerifyEmail)
+	api.Post("/users/verify/phone/resend", middleware.Protected(), middleware.CheckSession, handler.SendPhone)
+	api.Post("/users/verify/phone", middleware.Protected(), middleware.CheckSession, handler.VerifyPhone)
+}
+
+func (v verificationHandler) SendEmail(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := v.verificationUsecase.SendEmail(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+// VerifyEmail is opened from the link in the email, so it is not protected by access token
+func (v verificationHandler) VerifyEmail(ctx *fiber.Ctx) {
+	t := ctx.Query("token")
+	if t == "" {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := v.verificationUsecase.VerifyEmail(ctx.Context(), t); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
+
+func (v verificationHandler) SendPhone(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	data, err := v.verificationUsecase.SendPhone(ctx.Context(), *userID)
+	if err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": data})
+}
+
+func (v verificationHandler) VerifyPhone(ctx *fiber.Ctx) {
+	userID, err := middleware.GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	input := new(model.PhoneInput)
+	if err := ctx.BodyParser(input); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+	if err := input.Validate(); err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error(), "data": err.Error()})
+		return
+	}
+
+	if err := v.verificationUsecase.VerifyPhone(ctx.Context(), *userID, input.Code); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.JSON(fiber.Map{"status": "success", "data": true})
+}
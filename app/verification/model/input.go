@@ -0,0 +1,11 @@
+package model
+
+import "github.com/fajardm/ewallet-example/validator"
+
+type PhoneInput struct {
+	Code string `json:"code" validate:"required,max=10,numeric"`
+}
+
+func (p PhoneInput) Validate() error {
+	return validator.Validate().Struct(p)
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	"time"
+)
+
+// Sent tells when the delivered link or code expires and when it may be resent
+type Sent = _otpModel.Sent
+
+// Policy configures email verification link, phone verification follows the otp policy
+type Policy struct {
+	BaseURL       string
+	EmailLinkTTL  time.Duration
+	EmailCooldown time.Duration
+}
@@ -0,0 +1,16 @@
+package verification
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/app/verification/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Usecase represent the verification's usecase contract
+type Usecase interface {
+	SendEmail(context.Context, uuid.UUID) (*model.Sent, error)
+	VerifyEmail(ctx context.Context, token string) error
+	SendPhone(context.Context, uuid.UUID) (*model.Sent, error)
+	VerifyPhone(ctx context.Context, userID uuid.UUID, code string) error
+	CheckPhoneVerified(context.Context, uuid.UUID) error
+}
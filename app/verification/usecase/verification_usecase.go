@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/fajardm/ewallet-example/app/otp"
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	"github.com/fajardm/ewallet-example/app/user"
+	"github.com/fajardm/ewallet-example/app/verification"
+	"github.com/fajardm/ewallet-example/app/verification/model"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	"github.com/fajardm/ewallet-example/session"
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const emailTokenPurpose = "verify_email"
+
+type verificationUsecase struct {
+	userRepository user.Repository
+	otpUsecase     otp.Usecase
+	policy         model.Policy
+	contextTimeout time.Duration
+}
+
+func NewVerificationUsecase(userRepository user.Repository, otpUsecase otp.Usecase, policy model.Policy, contextTimeout time.Duration) verification.Usecase {
+	if policy.EmailLinkTTL <= 0 {
+		policy.EmailLinkTTL = time.Hour * 24
+	}
+	if policy.EmailCooldown <= 0 {
+		policy.EmailCooldown = time.Minute
+	}
+	policy.BaseURL = strings.TrimRight(policy.BaseURL, "/")
+	return verificationUsecase{userRepository: userRepository, otpUsecase: otpUsecase, policy: policy, contextTimeout: contextTimeout}
+}
+
+func emailCooldownKey(userID uuid.UUID) string {
+	return "verify_email:" + userID.String()
+}
+
+// SendEmail delivers signed link bound to the current email, so the link stops working once the email is changed
+func (v verificationUsecase) SendEmail(ctx context.Context, userID uuid.UUID) (*model.Sent, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.contextTimeout)
+	defer cancel()
+
+	u, err := v.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.EmailVerified() {
+		return nil, errorcode.ErrConflict
+	}
+	if _, err := session.Session().Get(ctx, emailCooldownKey(userID)); err == nil {
+		return nil, errorcode.ErrTooManyAttempts
+	} else if err != errorcode.ErrNotFound {
+		return nil, err
+	}
+
+	now := time.Now()
+	t, err := token.SignPurpose(emailTokenPurpose, jwt.MapClaims{"sub": u.ID.String(), "email": u.Email}, v.policy.EmailLinkTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.Session().Set(ctx, emailCooldownKey(userID), []byte{1}, v.policy.EmailCooldown); err != nil {
+		return nil, err
+	}
+	link := v.policy.BaseURL + "/api/users/verify/email?token=" + url.QueryEscape(t)
+	err = notifier.Send(ctx, notifier.Message{
+		Channel: notifier.Email,
+		To:      u.Email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Hi %s,\n\nOpen the link below to verify your email. It expires in %d hours.\n\n%s\n", u.Username, int(v.policy.EmailLinkTTL.Hours()), link),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.Sent{Expires: now.Add(v.policy.EmailLinkTTL).Unix(), RetryAfter: now.Add(v.policy.EmailCooldown).Unix()}, nil
+}
+
+// VerifyEmail marks the email of the link as verified, returns errorcode.ErrInvalidCredential for invalid,
+// expired or outdated link
+func (v verificationUsecase) VerifyEmail(ctx context.Context, t string) error {
+	ctx, cancel := context.WithTimeout(ctx, v.contextTimeout)
+	defer cancel()
+
+	claims, err := token.ParsePurpose(emailTokenPurpose, t)
+	if err != nil {
+		return errorcode.ErrInvalidCredential
+	}
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	userID, err := uuid.FromString(sub)
+	if err != nil || email == "" {
+		return errorcode.ErrInvalidCredential
+	}
+
+	u, err := v.userRepository.GetByID(ctx, userID)
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrInvalidCredential
+	}
+	if err != nil {
+		return err
+	}
+	if u.Email != email {
+		return errorcode.ErrInvalidCredential
+	}
+	// Opening the link again is harmless
+	if u.EmailVerified() {
+		return nil
+	}
+	if err := v.userRepository.VerifyEmail(ctx, userID, email, time.Now()); err != nil {
+		if err == errorcode.ErrConflict {
+			return errorcode.ErrInvalidCredential
+		}
+		return err
+	}
+	return nil
+}
+
+// SendPhone delivers otp by sms to the current mobile phone, the cooldown follows the otp policy
+func (v verificationUsecase) SendPhone(ctx context.Context, userID uuid.UUID) (*model.Sent, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.contextTimeout)
+	defer cancel()
+
+	u, err := v.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.PhoneVerified() {
+		return nil, errorcode.ErrConflict
+	}
+	return v.otpUsecase.Issue(ctx, _otpModel.VerifyPhone, notifier.SMS, u.MobilePhone)
+}
+
+// VerifyPhone checks the otp sent to the current mobile phone and marks it as verified
+func (v verificationUsecase) VerifyPhone(ctx context.Context, userID uuid.UUID, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, v.contextTimeout)
+	defer cancel()
+
+	u, err := v.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.PhoneVerified() {
+		return errorcode.ErrConflict
+	}
+	if err := v.otpUsecase.Verify(ctx, _otpModel.VerifyPhone, notifier.SMS, u.MobilePhone, code); err != nil {
+		return err
+	}
+	return v.userRepository.VerifyPhone(ctx, userID, u.MobilePhone, time.Now())
+}
+
+// CheckPhoneVerified returns errorcode.ErrVerificationRequired when the user has not verified the mobile phone
+func (v verificationUsecase) CheckPhoneVerified(ctx context.Context, userID uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(ctx, v.contextTimeout)
+	defer cancel()
+
+	u, err := v.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.PhoneVerified() {
+		return errorcode.ErrVerificationRequired
+	}
+	return nil
+}
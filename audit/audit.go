@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+// Actions recorded in the audit log, named <target>.<verb>
+const (
+	UserRegister       = "user.register"
+	UserUpdate         = "user.update"
+	UserPasswordChange = "user.password_change"
+	UserPasswordReset  = "user.password_reset"
+	UserClose          = "user.close"
+	UserUnlock         = "user.unlock"
+	UserNotMe          = "user.not_me"
+	BalanceTopUp       = "balance.top_up"
+	BalanceTransfer    = "balance.transfer"
+	BalanceMove        = "balance.move"
+	BalanceOverdraft   = "balance.overdraft"
+	MemberAdd          = "member.add"
+	MemberAccept       = "member.accept"
+	MemberUpdate       = "member.update"
+	MemberRemove       = "member.remove"
+	AccountStatus      = "account.status"
+	RoleAssign         = "role.assign"
+	RoleRevoke         = "role.revoke"
+	AdjustmentPropose  = "adjustment.propose"
+	AdjustmentApprove  = "adjustment.approve"
+	AdjustmentReject   = "adjustment.reject"
+	KYCReview          = "kyc.review"
+)
+
+// Event is one entry of the append-only audit log. Before and After are JSON snapshots of the target, either may be
+// empty when the action creates, removes or does not change state of the target
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uuid.UUID       `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Events represent list of Event
+type Events []Event
+
+// Auditor writes events to the audit log
+type Auditor interface {
+	Record(ctx context.Context, event Event) error
+}
+
+// Meta describes the request events are recorded in, ActorID is set once the access token is verified
+type Meta struct {
+	ActorID   *uuid.UUID
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// MetaKey is the key Meta is stored under in request context, fasthttp only looks up string keys
+const MetaKey = "audit_meta"
+
+// MetaFrom returns metadata of the request the context belongs to, nil outside of requests
+func MetaFrom(ctx context.Context) *Meta {
+	m, _ := ctx.Value(MetaKey).(*Meta)
+	return m
+}
+
+// Record completes the event with request metadata and writes it through the auditor. Failures are logged and never
+// returned, so auditing can not fail the action it records. Falls back to logging the event when auditor is nil
+func Record(ctx context.Context, auditor Auditor, event Event) {
+	if m := MetaFrom(ctx); m != nil {
+		if event.ActorID == nil {
+			event.ActorID = m.ActorID
+		}
+		event.IP = m.IP
+		event.UserAgent = truncate(m.UserAgent, 256)
+		event.RequestID = m.RequestID
+	}
+	event.ID = uuid.NewV4()
+	event.CreatedAt = time.Now()
+
+	entry := log.WithFields(log.Fields{"action": event.Action, "target_type": event.TargetType, "target_id": event.TargetID})
+	if auditor == nil {
+		entry.Info("audit event")
+		return
+	}
+	if err := auditor.Record(ctx, event); err != nil {
+		entry.WithError(err).Error("record audit event")
+	}
+}
+
+// Snapshot marshals the state of the target, nil stays empty. Events are kept after accounts are purged, so
+// personal data must not be snapshotted, see Changes
+func Snapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.WithError(err).Warn("marshal audit snapshot")
+		return nil
+	}
+	return b
+}
+
+// Changes returns snapshot naming the changed fields without their values, for targets holding personal data that
+// must not outlive the anonymisation of the account
+func Changes(fields []string) json.RawMessage {
+	return Snapshot(map[string][]string{"changed": fields})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Store keeps uploaded files by key. Keys are slash separated paths chosen by the app, never by the user
+type Store interface {
+	// Put stores content under the key, replacing the previous content
+	Put(ctx context.Context, key string, content io.Reader) error
+	// Open returns content of the key, errorcode.ErrNotFound when missing
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the key, deleting missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every key under the slash separated prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+var mu sync.RWMutex
+var _store Store
+
+// Use sets the store returned by Blob, should be called once on start up
+func Use(store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	_store = store
+}
+
+// Blob returns the configured store, falls back to file store in storage directory when none configured
+func Blob() Store {
+	mu.RLock()
+	s := _store
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _store == nil {
+		_store = NewFileStore("")
+	}
+	return _store
+}
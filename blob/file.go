@@ -0,0 +1,96 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type fileStore struct {
+	root string
+}
+
+// NewFileStore creates store keeping every key as file under root, "storage" when root is empty
+func NewFileStore(root string) Store {
+	if root == "" {
+		root = "storage"
+	}
+	return &fileStore{root: root}
+}
+
+// path resolves the key inside root, rejecting key that would escape it
+func (f *fileStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(f.root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes to temporary file first, so a failed upload never leaves partial content under the key
+func (f *fileStore) Put(ctx context.Context, key string, content io.Reader) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (f *fileStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f *fileStore) Delete(ctx context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *fileStore) DeletePrefix(ctx context.Context, prefix string) error {
+	name, err := f.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
@@ -1,8 +1,10 @@
 package bootstrap
 
 import (
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/fajardm/ewallet-example/session"
 	"github.com/gofiber/fiber"
-	"github.com/gofiber/session"
 	"time"
 )
 
@@ -13,7 +15,9 @@ type Bootstrap struct {
 	AppName      string
 	AppOwner     string
 	AppSpawnDate time.Time
-	Session      *session.Session
+	Session      session.Store
+	// Admin is /api/admin group, only reachable by logged in users with admin:access permission
+	Admin *fiber.Group
 }
 
 func New(appName, appOwner string, cfgs ...Configuration) *Bootstrap {
@@ -36,4 +40,6 @@ func (b *Bootstrap) Configure(cfgs ...Configuration) {
 }
 
 func (b *Bootstrap) Bootstrap() {
+	b.Use(middleware.RequestID)
+	b.Admin = b.Group("/api/admin", middleware.Protected(), middleware.CheckSession, middleware.Require(_roleModel.AdminAccess))
 }
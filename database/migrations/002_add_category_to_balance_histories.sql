@@ -0,0 +1,31 @@
+SET @ddl = IF(
+  (SELECT COUNT(*) FROM `information_schema`.`columns` WHERE `table_schema` = "ewallet" AND `table_name` = "balance_histories" AND `column_name` = "category") = 0,
+  'ALTER TABLE `ewallet`.`balance_histories` ADD COLUMN `category` ENUM("opening", "topup", "transfer_in", "transfer_out", "payment", "fee", "refund", "cashback", "adjustment", "withdrawal") NULL AFTER `type`',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+UPDATE `ewallet`.`balance_histories` SET
+  `type` = IF(`type` = "credit", "debit", "credit"),
+  `category` = CASE
+    WHEN `activity` = "initial balance" THEN "opening"
+    WHEN `activity` LIKE "topup %" THEN "topup"
+    WHEN `activity` LIKE "transfer %" THEN "transfer_out"
+    WHEN `activity` LIKE "retrieve %" THEN "transfer_in"
+    ELSE "adjustment"
+  END
+  WHERE `category` IS NULL;
+
+ALTER TABLE `ewallet`.`balance_histories`
+  MODIFY COLUMN `category` ENUM("opening", "topup", "transfer_in", "transfer_out", "payment", "fee", "refund", "cashback", "adjustment", "withdrawal") NOT NULL;
+
+SET @ddl = IF(
+  (SELECT COUNT(*) FROM `information_schema`.`statistics` WHERE `table_schema` = "ewallet" AND `table_name` = "balance_histories" AND `index_name` = "balance_histories_balance_id_category_idx") = 0,
+  'ALTER TABLE `ewallet`.`balance_histories` ADD INDEX `balance_histories_balance_id_category_idx` (`balance_id` ASC, `category` ASC)',
+  'DO 0'
+);
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
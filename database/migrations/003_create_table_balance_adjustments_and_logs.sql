@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`balance_adjustments` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `type` ENUM("credit", "debit") NOT NULL,
+  `amount` FLOAT NOT NULL,
+  `reason` VARCHAR(256) NOT NULL,
+  `reference` VARCHAR(128) NOT NULL,
+  `status` ENUM("pending", "approved", "rejected") NOT NULL,
+  `reviewed_by` VARCHAR(36) NULL,
+  `reviewed_at` DATETIME NULL,
+  `review_note` VARCHAR(256) NULL,
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_by` VARCHAR(36) NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  INDEX `balance_adjustments_status_idx` (`status` ASC, `created_at` ASC),
+  INDEX `fk_balance_adjustments_users_idx` (`user_id` ASC),
+  CONSTRAINT `fk_balance_adjustments_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`balance_adjustment_logs` (
+  `id` VARCHAR(36) NOT NULL,
+  `adjustment_id` VARCHAR(36) NOT NULL,
+  `action` ENUM("proposed", "approved", "rejected") NOT NULL,
+  `note` VARCHAR(256) NULL,
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  INDEX `fk_balance_adjustment_logs_balance_adjustments_idx` (`adjustment_id` ASC),
+  CONSTRAINT `fk_balance_adjustment_logs_balance_adjustments`
+    FOREIGN KEY (`adjustment_id`)
+    REFERENCES `ewallet`.`balance_adjustments` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
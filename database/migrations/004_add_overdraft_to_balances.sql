@@ -0,0 +1,5 @@
+ALTER TABLE `ewallet`.`balances`
+  ADD COLUMN `overdraft_limit` FLOAT NOT NULL DEFAULT 0 AFTER `user_id`,
+  ADD COLUMN `overdraft_rate` FLOAT NOT NULL DEFAULT 0 AFTER `overdraft_limit`,
+  ADD COLUMN `overdraft_interest` FLOAT NOT NULL DEFAULT 0 AFTER `overdraft_rate`,
+  ADD COLUMN `overdraft_accrued_at` DATETIME NULL AFTER `overdraft_interest`;
@@ -0,0 +1,9 @@
+ALTER TABLE `ewallet`.`balances`
+  ADD COLUMN `kind` ENUM("main", "pocket") NOT NULL DEFAULT "main" AFTER `user_id`,
+  ADD COLUMN `name` VARCHAR(45) NULL AFTER `kind`,
+  ADD COLUMN `target_amount` FLOAT NULL AFTER `name`,
+  ADD COLUMN `deadline` DATETIME NULL AFTER `target_amount`,
+  ADD INDEX `balances_user_id_kind_idx` (`user_id` ASC, `kind` ASC);
+
+ALTER TABLE `ewallet`.`balance_histories`
+  MODIFY COLUMN `category` ENUM("opening", "topup", "transfer_in", "transfer_out", "payment", "fee", "refund", "cashback", "adjustment", "withdrawal", "internal_transfer") NOT NULL;
@@ -0,0 +1,32 @@
+ALTER TABLE `ewallet`.`balances`
+  MODIFY COLUMN `kind` ENUM("main", "pocket", "shared") NOT NULL DEFAULT "main";
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`balance_members` (
+  `id` VARCHAR(36) NOT NULL,
+  `balance_id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `role` ENUM("owner", "spender", "viewer") NOT NULL,
+  `spending_limit` FLOAT NULL,
+  `allowed_operations` SET("transfer", "withdraw") NOT NULL DEFAULT "",
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_by` VARCHAR(36) NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  UNIQUE INDEX `balance_members_balance_id_user_id_UNIQUE` (`balance_id` ASC, `user_id` ASC),
+  INDEX `fk_balance_members_users_idx` (`user_id` ASC),
+  CONSTRAINT `fk_balance_members_balances`
+    FOREIGN KEY (`balance_id`)
+    REFERENCES `ewallet`.`balances` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_balance_members_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+ALTER TABLE `ewallet`.`balance_histories`
+  ADD INDEX `balance_histories_balance_id_created_by_idx` (`balance_id` ASC, `created_by` ASC, `created_at` ASC);
@@ -0,0 +1,19 @@
+ALTER TABLE `ewallet`.`users`
+  ADD COLUMN `status` ENUM("active", "debit_frozen", "frozen", "closed") NOT NULL DEFAULT "active" AFTER `mobile_phone`;
+
+ALTER TABLE `ewallet`.`balances`
+  ADD COLUMN `status` ENUM("active", "debit_frozen", "frozen", "closed") NOT NULL DEFAULT "active" AFTER `deadline`;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`account_status_changes` (
+  `id` VARCHAR(36) NOT NULL,
+  `target_type` ENUM("user", "balance") NOT NULL,
+  `target_id` VARCHAR(36) NOT NULL,
+  `status_from` ENUM("active", "debit_frozen", "frozen", "closed") NOT NULL,
+  `status_to` ENUM("active", "debit_frozen", "frozen", "closed") NOT NULL,
+  `reason` VARCHAR(256) NOT NULL,
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  INDEX `account_status_changes_target_idx` (`target_type` ASC, `target_id` ASC, `created_at` ASC))
+ENGINE = InnoDB;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`refresh_tokens` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `family_id` VARCHAR(36) NOT NULL,
+  `token_hash` CHAR(64) NOT NULL,
+  `expires_at` DATETIME NOT NULL,
+  `revoked_at` DATETIME NULL,
+  `replaced_by` VARCHAR(36) NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  UNIQUE INDEX `token_hash_UNIQUE` (`token_hash` ASC),
+  INDEX `refresh_tokens_family_idx` (`family_id` ASC),
+  INDEX `fk_refresh_tokens_users_idx` (`user_id` ASC),
+  CONSTRAINT `fk_refresh_tokens_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`sessions` (
+  `id` VARCHAR(128) NOT NULL,
+  `value` BLOB NOT NULL,
+  `expires_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `sessions_expires_at_idx` (`expires_at` ASC))
+ENGINE = InnoDB;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`user_sessions` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `device_name` VARCHAR(64) NOT NULL,
+  `user_agent` VARCHAR(256) NOT NULL,
+  `ip` VARCHAR(45) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `last_seen_at` DATETIME NOT NULL,
+  `expires_at` DATETIME NOT NULL,
+  `revoked_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  INDEX `fk_user_sessions_users_idx` (`user_id` ASC),
+  CONSTRAINT `fk_user_sessions_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`user_pins` (
+  `user_id` VARCHAR(36) NOT NULL,
+  `hashed_pin` VARCHAR(255) NOT NULL,
+  `failed_attempts` INT NOT NULL DEFAULT 0,
+  `locked_until` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `fk_user_pins_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`user_two_factors` (
+  `user_id` VARCHAR(36) NOT NULL,
+  `key_url` VARCHAR(512) NOT NULL,
+  `last_used_step` BIGINT NOT NULL DEFAULT 0,
+  `enabled_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `fk_user_two_factors_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`user_recovery_codes` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `code_hash` CHAR(64) NOT NULL,
+  `used_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `id_UNIQUE` (`id` ASC),
+  INDEX `user_recovery_codes_user_idx` (`user_id` ASC, `code_hash` ASC),
+  CONSTRAINT `fk_user_recovery_codes_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`one_time_passwords` (
+  `id` VARCHAR(36) NOT NULL,
+  `purpose` ENUM('verify_email', 'verify_phone', 'reset_password', 'step_up') NOT NULL,
+  `channel` ENUM('email', 'sms') NOT NULL,
+  `destination` VARCHAR(255) NOT NULL,
+  `hashed_code` VARCHAR(255) NOT NULL,
+  `attempts` INT NOT NULL DEFAULT 0,
+  `expires_at` DATETIME NOT NULL,
+  `consumed_at` DATETIME NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_one_time_passwords_destination` (`purpose` ASC, `destination` ASC, `created_at` DESC))
+ENGINE = InnoDB;
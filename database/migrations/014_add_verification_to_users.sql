@@ -0,0 +1,3 @@
+ALTER TABLE `ewallet`.`users`
+  ADD COLUMN `email_verified_at` DATETIME NULL AFTER `status`,
+  ADD COLUMN `phone_verified_at` DATETIME NULL AFTER `email_verified_at`;
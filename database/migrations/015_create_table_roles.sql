@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`roles` (
+  `name` VARCHAR(32) NOT NULL,
+  `description` VARCHAR(255) NOT NULL,
+  PRIMARY KEY (`name`))
+ENGINE = InnoDB;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`role_permissions` (
+  `role` VARCHAR(32) NOT NULL,
+  `permission` VARCHAR(64) NOT NULL,
+  PRIMARY KEY (`role`, `permission`),
+  CONSTRAINT `fk_role_permissions_roles`
+    FOREIGN KEY (`role`)
+    REFERENCES `ewallet`.`roles` (`name`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`user_roles` (
+  `user_id` VARCHAR(36) NOT NULL,
+  `role` VARCHAR(32) NOT NULL,
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`user_id`, `role`),
+  INDEX `user_roles_role_idx` (`role` ASC),
+  CONSTRAINT `fk_user_roles_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_user_roles_roles`
+    FOREIGN KEY (`role`)
+    REFERENCES `ewallet`.`roles` (`name`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+INSERT INTO `ewallet`.`roles` (`name`, `description`) VALUES
+  ('admin', 'Operator with full access to the admin api'),
+  ('support', 'Operator helping customers, read only except unlocking and proposing adjustments'),
+  ('customer', 'Registered wallet user'),
+  ('merchant', 'Wallet user receiving payments as business');
+
+INSERT INTO `ewallet`.`role_permissions` (`role`, `permission`) VALUES
+  ('admin', 'admin:access'),
+  ('admin', 'users:read'),
+  ('admin', 'users:manage'),
+  ('admin', 'users:unlock'),
+  ('admin', 'balances:manage'),
+  ('admin', 'adjustments:propose'),
+  ('admin', 'adjustments:review'),
+  ('admin', 'roles:manage'),
+  ('support', 'admin:access'),
+  ('support', 'users:read'),
+  ('support', 'users:unlock'),
+  ('support', 'adjustments:propose'),
+  ('customer', 'balances:transfer'),
+  ('merchant', 'balances:transfer');
+
+INSERT INTO `ewallet`.`user_roles` (`user_id`, `role`, `created_by`, `created_at`)
+  SELECT `id`, 'customer', `id`, NOW() FROM `ewallet`.`users`;
@@ -0,0 +1,44 @@
+ALTER TABLE `ewallet`.`users`
+  ADD COLUMN `tier` ENUM('basic', 'verified', 'premium') NOT NULL DEFAULT 'basic' AFTER `status`;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`kyc_submissions` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `tier` ENUM('basic', 'verified', 'premium') NOT NULL,
+  `status` ENUM('pending', 'approved', 'rejected', 'resubmission_required') NOT NULL,
+  `reviewed_by` VARCHAR(36) NULL,
+  `reviewed_at` DATETIME NULL,
+  `review_note` VARCHAR(255) NULL,
+  `created_by` VARCHAR(36) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `updated_by` VARCHAR(36) NULL,
+  `updated_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `kyc_submissions_status_created_at_idx` (`status` ASC, `created_at` ASC),
+  INDEX `kyc_submissions_user_id_created_at_idx` (`user_id` ASC, `created_at` ASC),
+  CONSTRAINT `fk_kyc_submissions_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+CREATE TABLE IF NOT EXISTS `ewallet`.`kyc_documents` (
+  `id` VARCHAR(36) NOT NULL,
+  `submission_id` VARCHAR(36) NOT NULL,
+  `type` ENUM('id_card', 'passport', 'selfie', 'proof_of_address') NOT NULL,
+  `content_type` VARCHAR(64) NOT NULL,
+  `size` BIGINT NOT NULL,
+  `blob_key` VARCHAR(255) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `kyc_documents_submission_id_idx` (`submission_id` ASC),
+  CONSTRAINT `fk_kyc_documents_kyc_submissions`
+    FOREIGN KEY (`submission_id`)
+    REFERENCES `ewallet`.`kyc_submissions` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+INSERT INTO `ewallet`.`role_permissions` (`role`, `permission`) VALUES
+  ('admin', 'kyc:review');
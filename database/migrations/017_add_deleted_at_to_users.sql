@@ -0,0 +1,23 @@
+ALTER TABLE `ewallet`.`users`
+  ADD COLUMN `deleted_at` DATETIME NULL AFTER `updated_at`,
+  ADD INDEX `users_deleted_at_idx` (`deleted_at` ASC);
+
+ALTER TABLE `ewallet`.`balance_adjustments`
+  DROP FOREIGN KEY `fk_balance_adjustments_users`;
+
+ALTER TABLE `ewallet`.`balance_adjustments`
+  ADD CONSTRAINT `fk_balance_adjustments_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION;
+
+ALTER TABLE `ewallet`.`balance_adjustment_logs`
+  DROP FOREIGN KEY `fk_balance_adjustment_logs_balance_adjustments`;
+
+ALTER TABLE `ewallet`.`balance_adjustment_logs`
+  ADD CONSTRAINT `fk_balance_adjustment_logs_balance_adjustments`
+    FOREIGN KEY (`adjustment_id`)
+    REFERENCES `ewallet`.`balance_adjustments` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`data_exports` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NOT NULL,
+  `status` ENUM('pending', 'ready', 'failed') NOT NULL,
+  `size` BIGINT NOT NULL,
+  `blob_key` VARCHAR(255) NOT NULL,
+  `created_at` DATETIME NOT NULL,
+  `completed_at` DATETIME NULL,
+  `expires_at` DATETIME NULL,
+  PRIMARY KEY (`id`),
+  INDEX `data_exports_user_id_created_at_idx` (`user_id` ASC, `created_at` ASC),
+  INDEX `data_exports_expires_at_idx` (`expires_at` ASC),
+  CONSTRAINT `fk_data_exports_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`audit_events` (
+  `id` VARCHAR(36) NOT NULL,
+  `actor_id` VARCHAR(36) NULL,
+  `action` VARCHAR(64) NOT NULL,
+  `target_type` VARCHAR(32) NOT NULL,
+  `target_id` VARCHAR(36) NOT NULL,
+  `before_snapshot` JSON NULL,
+  `after_snapshot` JSON NULL,
+  `ip` VARCHAR(45) NOT NULL,
+  `user_agent` VARCHAR(256) NOT NULL,
+  `request_id` VARCHAR(64) NOT NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `audit_events_actor_id_created_at_idx` (`actor_id` ASC, `created_at` ASC),
+  INDEX `audit_events_target_created_at_idx` (`target_type` ASC, `target_id` ASC, `created_at` ASC),
+  INDEX `audit_events_target_id_idx` (`target_id` ASC),
+  INDEX `audit_events_created_at_idx` (`created_at` ASC))
+ENGINE = InnoDB;
+
+INSERT INTO `ewallet`.`role_permissions` (`role`, `permission`) VALUES
+  ('admin', 'audit:read');
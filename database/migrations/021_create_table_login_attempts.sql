@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS `ewallet`.`login_attempts` (
+  `id` VARCHAR(36) NOT NULL,
+  `user_id` VARCHAR(36) NULL,
+  `identifier` VARCHAR(255) NULL,
+  `success` TINYINT(1) NOT NULL,
+  `reason` VARCHAR(64) NULL,
+  `ip` VARCHAR(45) NOT NULL,
+  `user_agent` VARCHAR(256) NOT NULL,
+  `fingerprint` VARCHAR(32) NOT NULL,
+  `country` CHAR(2) NULL,
+  `not_me_at` DATETIME(6) NULL,
+  `created_at` DATETIME(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `login_attempts_user_id_created_at_idx` (`user_id` ASC, `created_at` ASC),
+  INDEX `login_attempts_user_id_fingerprint_idx` (`user_id` ASC, `fingerprint` ASC),
+  INDEX `login_attempts_identifier_created_at_idx` (`identifier` ASC, `created_at` ASC),
+  CONSTRAINT `fk_login_attempts_users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `ewallet`.`users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,5 @@
+CREATE TRIGGER `ewallet`.`audit_events_before_update` BEFORE UPDATE ON `ewallet`.`audit_events`
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
+
+CREATE TRIGGER `ewallet`.`audit_events_before_delete` BEFORE DELETE ON `ewallet`.`audit_events`
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_events is append-only';
@@ -0,0 +1,4 @@
+ALTER TABLE `ewallet`.`balance_members`
+  ADD COLUMN `accepted_at` DATETIME NULL AFTER `allowed_operations`;
+
+UPDATE `ewallet`.`balance_members` SET `accepted_at` = `created_at` WHERE `accepted_at` IS NULL;
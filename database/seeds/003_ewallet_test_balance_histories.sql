@@ -1,5 +1,5 @@
-INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('0b442f0b-5e3f-476f-9f36-b1df1671379e', 0, 0, 'initial balance', 'debit', null, null, null, '327502bb-9c41-4519-8730-6b03625250c9', '89ae5701-73cb-4115-964c-6d20d899c13b', '2020-07-07 13:52:48', null, null);
-INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('2f9944be-0c06-4470-92ec-cd4bcb370342', 0, 0, 'initial balance', 'debit', null, null, null, '89ab9f0e-a1b1-42cf-b352-27109f361ba4', '7fafd301-61af-4033-bb23-ff131fccd59b', '2020-07-07 13:52:49', null, null);
-INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('49e08c89-09ce-4360-ae7c-c7424e447315', 0, 0, 'initial balance', 'debit', null, null, null, 'dadd2737-145b-4cdd-af3b-a518f034db74', '9fbc62a9-fbf0-4468-90ae-c09a9c727b64', '2020-07-07 13:52:49', null, null);
-INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('9e3355c2-f250-40b8-8c77-15e5b7b3bef6', 0, 0, 'initial balance', 'debit', null, null, null, 'd7e8c0dc-ec44-46f3-a1a8-431ce511a471', '1b26103c-959a-494c-9dcb-58c7b69f33b3', '2020-07-07 13:52:48', null, null);
-INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('e9ec28a4-6964-4217-a551-193476381afa', 0, 0, 'initial balance', 'debit', null, null, null, '5862ed86-0fb5-494a-8d7f-3eaeaaffcd55', '12ad94f1-074b-4e36-8f5a-f50c6f1cebad', '2020-07-07 13:52:48', null, null);
\ No newline at end of file
+INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, category, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('0b442f0b-5e3f-476f-9f36-b1df1671379e', 0, 0, 'initial balance', 'credit', 'opening', null, null, null, '327502bb-9c41-4519-8730-6b03625250c9', '89ae5701-73cb-4115-964c-6d20d899c13b', '2020-07-07 13:52:48', null, null);
+INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, category, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('2f9944be-0c06-4470-92ec-cd4bcb370342', 0, 0, 'initial balance', 'credit', 'opening', null, null, null, '89ab9f0e-a1b1-42cf-b352-27109f361ba4', '7fafd301-61af-4033-bb23-ff131fccd59b', '2020-07-07 13:52:49', null, null);
+INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, category, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('49e08c89-09ce-4360-ae7c-c7424e447315', 0, 0, 'initial balance', 'credit', 'opening', null, null, null, 'dadd2737-145b-4cdd-af3b-a518f034db74', '9fbc62a9-fbf0-4468-90ae-c09a9c727b64', '2020-07-07 13:52:49', null, null);
+INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, category, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('9e3355c2-f250-40b8-8c77-15e5b7b3bef6', 0, 0, 'initial balance', 'credit', 'opening', null, null, null, 'd7e8c0dc-ec44-46f3-a1a8-431ce511a471', '1b26103c-959a-494c-9dcb-58c7b69f33b3', '2020-07-07 13:52:48', null, null);
+INSERT INTO ewallet.balance_histories (id, balance_before, balance_after, activity, type, category, ip, location, user_agent, balance_id, created_by, created_at, updated_by, updated_at) VALUES ('e9ec28a4-6964-4217-a551-193476381afa', 0, 0, 'initial balance', 'credit', 'opening', null, null, null, '5862ed86-0fb5-494a-8d7f-3eaeaaffcd55', '12ad94f1-074b-4e36-8f5a-f50c6f1cebad', '2020-07-07 13:52:48', null, null);
\ No newline at end of file
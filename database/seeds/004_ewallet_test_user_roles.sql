@@ -0,0 +1,5 @@
+INSERT INTO ewallet.user_roles (user_id, role, created_by, created_at) VALUES ('12ad94f1-074b-4e36-8f5a-f50c6f1cebad', 'customer', '12ad94f1-074b-4e36-8f5a-f50c6f1cebad', '2020-07-07 13:52:48');
+INSERT INTO ewallet.user_roles (user_id, role, created_by, created_at) VALUES ('1b26103c-959a-494c-9dcb-58c7b69f33b3', 'customer', '1b26103c-959a-494c-9dcb-58c7b69f33b3', '2020-07-07 13:52:48');
+INSERT INTO ewallet.user_roles (user_id, role, created_by, created_at) VALUES ('7fafd301-61af-4033-bb23-ff131fccd59b', 'customer', '7fafd301-61af-4033-bb23-ff131fccd59b', '2020-07-07 13:52:48');
+INSERT INTO ewallet.user_roles (user_id, role, created_by, created_at) VALUES ('89ae5701-73cb-4115-964c-6d20d899c13b', 'customer', '89ae5701-73cb-4115-964c-6d20d899c13b', '2020-07-07 13:52:48');
+INSERT INTO ewallet.user_roles (user_id, role, created_by, created_at) VALUES ('9fbc62a9-fbf0-4468-90ae-c09a9c727b64', 'customer', '9fbc62a9-fbf0-4468-90ae-c09a9c727b64', '2020-07-07 13:52:48');
\ No newline at end of file
@@ -16,14 +16,47 @@ var (
 	ErrBadParamInput = errors.New("given param is not valid")
 	// ErrUnauthorized will throw if actor not authorized to access usecase
 	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden will throw if actor is authenticated but not allowed to perform the action
+	ErrForbidden = errors.New("forbidden")
+	// ErrInsufficientFunds will throw if the balance and overdraft can not cover the requested amount
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrLimitExceeded will throw if the requested amount exceeds the limit given to the actor
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrAccountFrozen will throw if the account is frozen for the requested action
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountClosed will throw if the account is already closed
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrInvalidCredential will throw if the given password, pin or code does not match
+	ErrInvalidCredential = errors.New("invalid credential")
+	// ErrTooManyAttempts will throw if the actor is locked out after too many failed attempts
+	ErrTooManyAttempts = errors.New("too many attempts")
+	// ErrStepUpRequired will throw if the action needs a fresh pin verification
+	ErrStepUpRequired = errors.New("pin verification required")
+	// ErrVerificationRequired will throw if the action needs verified email or mobile phone
+	ErrVerificationRequired = errors.New("verification required")
+	// ErrTierRequired will throw if the action is not available in the kyc tier of the actor
+	ErrTierRequired = errors.New("higher kyc tier required")
+	// ErrBalanceNotSettled will throw if the account still holds or owes money when it is closed
+	ErrBalanceNotSettled = errors.New("balance must be settled first")
 )
 
 var statusCode = map[error]int{
-	ErrInternalServerError: http.StatusInternalServerError,
-	ErrNotFound:            http.StatusNotFound,
-	ErrConflict:            http.StatusConflict,
-	ErrBadParamInput:       http.StatusBadRequest,
-	ErrUnauthorized:        http.StatusUnauthorized,
+	ErrInternalServerError:  http.StatusInternalServerError,
+	ErrNotFound:             http.StatusNotFound,
+	ErrConflict:             http.StatusConflict,
+	ErrBadParamInput:        http.StatusBadRequest,
+	ErrUnauthorized:         http.StatusUnauthorized,
+	ErrForbidden:            http.StatusForbidden,
+	ErrInsufficientFunds:    http.StatusUnprocessableEntity,
+	ErrLimitExceeded:        http.StatusUnprocessableEntity,
+	ErrAccountFrozen:        http.StatusForbidden,
+	ErrAccountClosed:        http.StatusForbidden,
+	ErrInvalidCredential:    http.StatusForbidden,
+	ErrTooManyAttempts:      http.StatusTooManyRequests,
+	ErrStepUpRequired:       http.StatusForbidden,
+	ErrVerificationRequired: http.StatusForbidden,
+	ErrTierRequired:         http.StatusForbidden,
+	ErrBalanceNotSettled:    http.StatusUnprocessableEntity,
 }
 
 func StatusCode(err error) int {
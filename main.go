@@ -3,20 +3,77 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	_accountHttp "github.com/fajardm/ewallet-example/app/account/http"
+	_accountRepository "github.com/fajardm/ewallet-example/app/account/repository/mysql"
+	_accountUsecase "github.com/fajardm/ewallet-example/app/account/usecase"
+	_adjustmentHttp "github.com/fajardm/ewallet-example/app/adjustment/http"
+	_adjustmentRepository "github.com/fajardm/ewallet-example/app/adjustment/repository/mysql"
+	_adjustmentUsecase "github.com/fajardm/ewallet-example/app/adjustment/usecase"
+	_auditHttp "github.com/fajardm/ewallet-example/app/audit/http"
+	_auditRepository "github.com/fajardm/ewallet-example/app/audit/repository/mysql"
+	_auditUsecase "github.com/fajardm/ewallet-example/app/audit/usecase"
+	_authHttp "github.com/fajardm/ewallet-example/app/auth/http"
+	_authRepository "github.com/fajardm/ewallet-example/app/auth/repository/mysql"
+	_authUsecase "github.com/fajardm/ewallet-example/app/auth/usecase"
 	_usecaseHttp "github.com/fajardm/ewallet-example/app/balance/http"
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
 	_balanceRepository "github.com/fajardm/ewallet-example/app/balance/repository/mysql"
 	_balanceUsecase "github.com/fajardm/ewallet-example/app/balance/usecase"
+	"github.com/fajardm/ewallet-example/app/base"
+	_exportHttp "github.com/fajardm/ewallet-example/app/export/http"
+	_exportModel "github.com/fajardm/ewallet-example/app/export/model"
+	_exportRepository "github.com/fajardm/ewallet-example/app/export/repository/mysql"
+	_exportUsecase "github.com/fajardm/ewallet-example/app/export/usecase"
+	_kycHttp "github.com/fajardm/ewallet-example/app/kyc/http"
+	_kycModel "github.com/fajardm/ewallet-example/app/kyc/model"
+	_kycRepository "github.com/fajardm/ewallet-example/app/kyc/repository/mysql"
+	_kycUsecase "github.com/fajardm/ewallet-example/app/kyc/usecase"
+	_loginHttp "github.com/fajardm/ewallet-example/app/login/http"
+	_loginModel "github.com/fajardm/ewallet-example/app/login/model"
+	_loginRepository "github.com/fajardm/ewallet-example/app/login/repository/mysql"
+	_loginUsecase "github.com/fajardm/ewallet-example/app/login/usecase"
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	_otpRepository "github.com/fajardm/ewallet-example/app/otp/repository/mysql"
+	_otpUsecase "github.com/fajardm/ewallet-example/app/otp/usecase"
+	_pinHttp "github.com/fajardm/ewallet-example/app/pin/http"
+	_pinModel "github.com/fajardm/ewallet-example/app/pin/model"
+	_pinRepository "github.com/fajardm/ewallet-example/app/pin/repository/mysql"
+	_pinUsecase "github.com/fajardm/ewallet-example/app/pin/usecase"
+	_recoveryHttp "github.com/fajardm/ewallet-example/app/recovery/http"
+	_recoveryUsecase "github.com/fajardm/ewallet-example/app/recovery/usecase"
+	_roleHttp "github.com/fajardm/ewallet-example/app/role/http"
+	_roleRepository "github.com/fajardm/ewallet-example/app/role/repository/mysql"
+	_roleUsecase "github.com/fajardm/ewallet-example/app/role/usecase"
+	_twoFactorHttp "github.com/fajardm/ewallet-example/app/twofactor/http"
+	_twoFactorRepository "github.com/fajardm/ewallet-example/app/twofactor/repository/mysql"
+	_twoFactorUsecase "github.com/fajardm/ewallet-example/app/twofactor/usecase"
 	_userHttp "github.com/fajardm/ewallet-example/app/user/http"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
 	_userRepository "github.com/fajardm/ewallet-example/app/user/repository/mysql"
 	_userUsecase "github.com/fajardm/ewallet-example/app/user/usecase"
+	_verificationHttp "github.com/fajardm/ewallet-example/app/verification/http"
+	_verificationModel "github.com/fajardm/ewallet-example/app/verification/model"
+	_verificationUsecase "github.com/fajardm/ewallet-example/app/verification/usecase"
+	"github.com/fajardm/ewallet-example/blob"
 	"github.com/fajardm/ewallet-example/bootstrap"
 	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/fajardm/ewallet-example/notifier"
+	_notifierSMS "github.com/fajardm/ewallet-example/notifier/sms"
+	_notifierSMTP "github.com/fajardm/ewallet-example/notifier/smtp"
+	"github.com/fajardm/ewallet-example/password"
+	"github.com/fajardm/ewallet-example/session"
+	_sessionMySQL "github.com/fajardm/ewallet-example/session/mysql"
+	_sessionRedis "github.com/fajardm/ewallet-example/session/redis"
+	"github.com/fajardm/ewallet-example/token"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"os"
+	"strings"
+	"time"
 )
 
 func prepareConfig() {
@@ -48,8 +105,108 @@ func prepareDatabase() *sql.DB {
 	return conn
 }
 
+func prepareSession(db *database.MySQL) session.Store {
+	cleanupInterval := viper.GetDuration("SESSION.CLEANUP_INTERVAL")
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute * 10
+	}
+	switch driver := viper.GetString("SESSION.DRIVER"); driver {
+	case "", "memory":
+		return session.NewMemoryStore(cleanupInterval)
+	case "mysql":
+		return _sessionMySQL.NewMySQLStore(db, cleanupInterval)
+	case "redis":
+		store, err := _sessionRedis.NewRedisStore(_sessionRedis.Config{
+			Addr:     viper.GetString("SESSION.REDIS.ADDR"),
+			Password: viper.GetString("SESSION.REDIS.PASSWORD"),
+			DB:       viper.GetInt("SESSION.REDIS.DB"),
+			PoolSize: viper.GetInt("SESSION.REDIS.POOL_SIZE"),
+		})
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error connecting session store"))
+		}
+		return store
+	default:
+		log.Fatalf("Fatal error unknown session driver %s", driver)
+		return nil
+	}
+}
+
+// prepareNotifier registers sender of every channel. The file driver only writes messages locally, so it is refused
+// unless NOTIFIER.DEVELOPMENT is set. A channel without driver has no sender and fails to send
+func prepareNotifier() {
+	fileSender := notifier.NewFileSender(viper.GetString("NOTIFIER.FILE.PATH"))
+	development := viper.GetBool("NOTIFIER.DEVELOPMENT")
+
+	switch driver := viper.GetString("NOTIFIER.EMAIL.DRIVER"); driver {
+	case "":
+		log.Warn("No email driver configured, emails can not be sent")
+	case "file":
+		if !development {
+			log.Fatal("Fatal error file email driver requires NOTIFIER.DEVELOPMENT")
+		}
+		notifier.Use(notifier.Email, fileSender)
+	case "smtp":
+		notifier.Use(notifier.Email, _notifierSMTP.NewSMTPSender(_notifierSMTP.Config{
+			Host:     viper.GetString("NOTIFIER.SMTP.HOST"),
+			Port:     viper.GetInt("NOTIFIER.SMTP.PORT"),
+			Username: viper.GetString("NOTIFIER.SMTP.USERNAME"),
+			Password: viper.GetString("NOTIFIER.SMTP.PASSWORD"),
+			From:     viper.GetString("NOTIFIER.SMTP.FROM"),
+		}))
+	default:
+		log.Fatalf("Fatal error unknown email driver %s", driver)
+	}
+
+	switch driver := viper.GetString("NOTIFIER.SMS.DRIVER"); driver {
+	case "":
+		log.Warn("No sms driver configured, sms can not be sent")
+	case "file":
+		if !development {
+			log.Fatal("Fatal error file sms driver requires NOTIFIER.DEVELOPMENT")
+		}
+		notifier.Use(notifier.SMS, fileSender)
+	case "http":
+		notifier.Use(notifier.SMS, _notifierSMS.NewHTTPSender(_notifierSMS.Config{
+			URL:     viper.GetString("NOTIFIER.SMS.URL"),
+			Token:   viper.GetString("NOTIFIER.SMS.TOKEN"),
+			From:    viper.GetString("NOTIFIER.SMS.FROM"),
+			Timeout: viper.GetDuration("NOTIFIER.SMS.TIMEOUT"),
+		}))
+	default:
+		log.Fatalf("Fatal error unknown sms driver %s", driver)
+	}
+}
+
+// prepareLimits reads transaction limits of every kyc tier from KYC.LIMITS.<TIER>
+func prepareLimits() _balanceModel.Limits {
+	limits := _balanceModel.Limits{Tiers: map[base.Tier]_balanceModel.TierLimit{}}
+	for _, tier := range []base.Tier{base.Basic, base.Verified, base.Premium} {
+		key := "KYC.LIMITS." + strings.ToUpper(tier.String())
+		limits.Tiers[tier] = _balanceModel.TierLimit{
+			MaxBalance:    viper.GetFloat64(key + ".MAX_BALANCE"),
+			MaxTransfer:   viper.GetFloat64(key + ".MAX_TRANSFER"),
+			DailyTransfer: viper.GetFloat64(key + ".DAILY_TRANSFER"),
+		}
+	}
+	if s := viper.GetString("KYC.SHARED_WALLET_TIER"); s != "" {
+		tier, err := base.TierFromString(s)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error shared wallet tier"))
+		}
+		limits.SharedWalletTier = tier
+	}
+	return limits
+}
+
 func main() {
 	prepareConfig()
+	if _, err := token.Keys(); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error load signing keys"))
+	}
+	if _, err := password.Current(); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error load password policy"))
+	}
 	contextTimeout := viper.GetDuration("CONTEXT_TIMEOUT")
 
 	conn := prepareDatabase()
@@ -61,21 +218,132 @@ func main() {
 		}
 	}()
 
-	app := bootstrap.New(viper.GetString("APP_NAME"), viper.GetString("APP_OWNER"))
+	prepareNotifier()
+	blob.Use(blob.NewFileStore(viper.GetString("BLOB.PATH")))
+
+	store := prepareSession(db)
+	session.Use(store)
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error close session store"))
+		}
+	}()
+
+	app := bootstrap.New(viper.GetString("APP_NAME"), viper.GetString("APP_OWNER"), func(b *bootstrap.Bootstrap) {
+		b.Session = store
+	})
 	app.Bootstrap()
 	app.Get("/", func(ctx *fiber.Ctx) {
 		ctx.Send("Ok!")
 	})
 
+	// Register audit handler, it is the auditor of every usecase recording events
+	auditRepository := _auditRepository.NewAuditRepository(db)
+	auditUsecase := _auditUsecase.NewAuditUsecase(auditRepository, contextTimeout)
+	_auditHttp.NewAuditHandler(app, auditUsecase)
+
 	// Register balance handler
 	balanceRepository := _balanceRepository.NewBalanceRepository(db)
-	balanceUsecase := _balanceUsecase.NewBalanceUsecase(balanceRepository, contextTimeout)
+	balanceUsecase := _balanceUsecase.NewBalanceUsecase(balanceRepository, auditUsecase, prepareLimits(), contextTimeout)
 	_usecaseHttp.NewBalanceHandler(app, balanceUsecase)
 
-	// Register user handler
+	// Register auth handler
 	userRepository := _userRepository.NewUserRepository(db)
-	userUsecase := _userUsecase.NewUserUsecase(userRepository, balanceRepository, contextTimeout)
-	_userHttp.NewUserHandler(app, userUsecase)
+	authRepository := _authRepository.NewAuthRepository(db)
+	roleRepository := _roleRepository.NewRoleRepository(db)
+	authUsecase := _authUsecase.NewAuthUsecase(authRepository, userRepository, roleRepository, contextTimeout)
+	_authHttp.NewAuthHandler(app, authUsecase)
+	middleware.UseSessionChecker(authUsecase)
+
+	// Register two factor handler
+	twoFactorRepository := _twoFactorRepository.NewTwoFactorRepository(db)
+	twoFactorUsecase := _twoFactorUsecase.NewTwoFactorUsecase(twoFactorRepository, userRepository, viper.GetString("APP_NAME"), contextTimeout)
+	_twoFactorHttp.NewTwoFactorHandler(app, twoFactorUsecase)
+
+	// Register verification handler
+	otpRepository := _otpRepository.NewOTPRepository(db)
+	otpUsecase := _otpUsecase.NewOTPUsecase(otpRepository, _otpModel.Policy{
+		Length:      viper.GetInt("OTP.LENGTH"),
+		TTL:         viper.GetDuration("OTP.TTL"),
+		MaxAttempts: viper.GetInt("OTP.MAX_ATTEMPTS"),
+		Cooldown:    viper.GetDuration("OTP.COOLDOWN"),
+		MaxPerHour:  viper.GetInt("OTP.MAX_PER_HOUR"),
+	}, contextTimeout)
+	verificationUsecase := _verificationUsecase.NewVerificationUsecase(userRepository, otpUsecase, _verificationModel.Policy{
+		BaseURL:       viper.GetString("APP_URL"),
+		EmailLinkTTL:  viper.GetDuration("VERIFICATION.EMAIL_LINK_TTL"),
+		EmailCooldown: viper.GetDuration("VERIFICATION.EMAIL_COOLDOWN"),
+	}, contextTimeout)
+	_verificationHttp.NewVerificationHandler(app, verificationUsecase)
+	if viper.GetBool("VERIFICATION.REQUIRE_PHONE_FOR_TRANSFER") {
+		middleware.UseVerificationChecker(verificationUsecase)
+	}
+
+	// Register recovery handler
+	recoveryUsecase := _recoveryUsecase.NewRecoveryUsecase(userRepository, otpUsecase, authUsecase, auditUsecase, contextTimeout)
+	_recoveryHttp.NewRecoveryHandler(app, recoveryUsecase)
+
+	// Register login handler
+	loginRepository := _loginRepository.NewLoginRepository(db)
+	loginUsecase := _loginUsecase.NewLoginUsecase(loginRepository, userRepository, authUsecase, auditUsecase, _loginModel.Policy{
+		BaseURL:       viper.GetString("APP_URL"),
+		AlertTTL:      viper.GetDuration("LOGIN.ALERT_TTL"),
+		CountryHeader: viper.GetString("LOGIN.COUNTRY_HEADER"),
+		IPHeader:      viper.GetString("LOGIN.IP_HEADER"),
+	}, contextTimeout)
+	_loginHttp.NewLoginHandler(app, loginUsecase)
+
+	// Register user handler
+	userUsecase := _userUsecase.NewUserUsecase(userRepository, balanceRepository, roleRepository, auditUsecase, _userModel.LoginPolicy{
+		MaxFailures:    viper.GetInt("LOGIN.MAX_FAILURES"),
+		LockDuration:   viper.GetDuration("LOGIN.LOCK_DURATION"),
+		BackoffAfter:   viper.GetInt("LOGIN.BACKOFF_AFTER"),
+		BackoffBase:    viper.GetDuration("LOGIN.BACKOFF_BASE"),
+		BackoffMax:     viper.GetDuration("LOGIN.BACKOFF_MAX"),
+		IPMaxFailures:  viper.GetInt("LOGIN.IP_MAX_FAILURES"),
+		IPBackoffAfter: viper.GetInt("LOGIN.IP_BACKOFF_AFTER"),
+	}, contextTimeout)
+	_userHttp.NewUserHandler(app, userUsecase, authUsecase, twoFactorUsecase, verificationUsecase, loginUsecase)
+
+	// Register role handler
+	roleUsecase := _roleUsecase.NewRoleUsecase(roleRepository, userRepository, authUsecase, auditUsecase, contextTimeout)
+	_roleHttp.NewRoleHandler(app, roleUsecase)
+
+	// Register pin handler
+	pinRepository := _pinRepository.NewPINRepository(db)
+	pinUsecase := _pinUsecase.NewPINUsecase(pinRepository, userUsecase, _pinModel.Policy{
+		MaxAttempts:  viper.GetInt("PIN.MAX_ATTEMPTS"),
+		LockDuration: viper.GetDuration("PIN.LOCK_DURATION"),
+		StepUpTTL:    viper.GetDuration("PIN.STEP_UP_TTL"),
+	}, contextTimeout)
+	_pinHttp.NewPINHandler(app, pinUsecase, twoFactorUsecase)
+
+	// Register kyc handler
+	kycRepository := _kycRepository.NewKYCRepository(db)
+	kycUsecase := _kycUsecase.NewKYCUsecase(kycRepository, userRepository, auditUsecase, _kycModel.Policy{
+		MaxDocumentSize:   viper.GetInt64("KYC.MAX_DOCUMENT_SIZE"),
+		RejectionCooldown: viper.GetDuration("KYC.REJECTION_COOLDOWN"),
+	}, contextTimeout)
+	_kycHttp.NewKYCHandler(app, kycUsecase)
+
+	// Register adjustment handler
+	adjustmentRepository := _adjustmentRepository.NewAdjustmentRepository(db)
+	adjustmentUsecase := _adjustmentUsecase.NewAdjustmentUsecase(adjustmentRepository, balanceRepository, auditUsecase, contextTimeout)
+	_adjustmentHttp.NewAdjustmentHandler(app, adjustmentUsecase)
+
+	// Register account handler
+	accountRepository := _accountRepository.NewAccountRepository(db)
+	accountUsecase := _accountUsecase.NewAccountUsecase(accountRepository, userRepository, balanceRepository, auditUsecase, contextTimeout)
+	_accountHttp.NewAccountHandler(app, accountUsecase)
+
+	// Register export handler
+	exportUsecase := _exportUsecase.NewExportUsecase(_exportRepository.NewExportRepository(db), userRepository, balanceRepository, authRepository, kycRepository, accountRepository, auditRepository, loginRepository, _exportModel.Policy{
+		BaseURL:         viper.GetString("APP_URL"),
+		TTL:             viper.GetDuration("EXPORT.TTL"),
+		BuildTimeout:    viper.GetDuration("EXPORT.BUILD_TIMEOUT"),
+		CleanupInterval: viper.GetDuration("EXPORT.CLEANUP_INTERVAL"),
+	}, contextTimeout)
+	_exportHttp.NewExportHandler(app, exportUsecase)
 
 	if err := app.Listen(viper.GetInt("APP_PORT")); err != nil {
 		log.Fatal(errors.Wrap(err, "Fatal error listen port"))
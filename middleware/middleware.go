@@ -1,22 +1,64 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/fajardm/ewallet-example/audit"
 	"github.com/fajardm/ewallet-example/errorcode"
 	"github.com/fajardm/ewallet-example/session"
+	"github.com/fajardm/ewallet-example/token"
 	"github.com/gofiber/fiber"
-	jwtware "github.com/gofiber/jwt"
+	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
-	"github.com/spf13/viper"
 	"net/http"
+	"strings"
 )
 
+// Protected verifies bearer token against the signing key of its kid and stores it in ctx.Locals("user")
 func Protected() func(*fiber.Ctx) {
-	return jwtware.New(jwtware.Config{
-		SigningKey:   []byte(viper.GetString("APP_SECRET")),
-		ErrorHandler: jwtError,
-	})
+	return func(ctx *fiber.Ctx) {
+		auth := ctx.Get(fiber.HeaderAuthorization)
+		if len(auth) <= len(bearer)+1 || !strings.EqualFold(auth[:len(bearer)], bearer) {
+			jwtError(ctx, errMissingJWT)
+			return
+		}
+		t, err := jwt.Parse(auth[len(bearer)+1:], token.Keyfunc)
+		if err != nil || !t.Valid {
+			jwtError(ctx, err)
+			return
+		}
+		ctx.Locals("user", t)
+		if m := audit.MetaFrom(ctx.Context()); m != nil {
+			m.ActorID, _ = GetUserID(ctx)
+		}
+		ctx.Next()
+	}
+}
+
+// HeaderRequestID carries id of the request, kept from the client when given so logs can be correlated
+const HeaderRequestID = "X-Request-ID"
+
+// RequestID tags the request and its response with an id and keeps the metadata audit events are recorded with
+func RequestID(ctx *fiber.Ctx) {
+	id := ctx.Get(HeaderRequestID)
+	if id == "" || len(id) > 64 {
+		id = uuid.NewV4().String()
+	}
+	ctx.Set(HeaderRequestID, id)
+	ctx.Fasthttp.SetUserValue(audit.MetaKey, &audit.Meta{IP: ctx.IP(), UserAgent: ctx.Get(fiber.HeaderUserAgent), RequestID: id})
+	ctx.Next()
+}
+
+// SessionChecker validates the session an access token was issued for
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID, sessionID uuid.UUID) error
+}
+
+var sessionChecker SessionChecker
+
+// UseSessionChecker sets checker used by CheckSession, should be called once on start up
+func UseSessionChecker(checker SessionChecker) {
+	sessionChecker = checker
 }
 
 func CheckSession(ctx *fiber.Ctx) {
@@ -25,18 +67,84 @@ func CheckSession(ctx *fiber.Ctx) {
 		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
 		return
 	}
+	sessionID, err := GetSessionID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT"})
+		return
+	}
 
-	session := session.Session().Get(ctx)
-	t := session.Get(fmt.Sprintf("%s:token", userID.String()))
-	if t == nil {
+	if err := sessionChecker.CheckSession(ctx.Context(), *userID, *sessionID); err != nil {
 		ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT"})
 		return
 	}
 	ctx.Next()
 }
 
+// VerificationChecker tells whether the user has verified the contact required by an action
+type VerificationChecker interface {
+	CheckPhoneVerified(ctx context.Context, userID uuid.UUID) error
+}
+
+var verificationChecker VerificationChecker
+
+// UseVerificationChecker sets checker used by PhoneVerified, unverified users are not restricted when none is set
+func UseVerificationChecker(checker VerificationChecker) {
+	verificationChecker = checker
+}
+
+// PhoneVerified restricts the route to users with verified mobile phone
+func PhoneVerified(ctx *fiber.Ctx) {
+	if verificationChecker == nil {
+		ctx.Next()
+		return
+	}
+	userID, err := GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := verificationChecker.CheckPhoneVerified(ctx.Context(), *userID); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Next()
+}
+
+// StepUp requires single use step up token from pin verification in X-Step-Up-Token header
+func StepUp(ctx *fiber.Ctx) {
+	userID, err := GetUserID(ctx)
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": errorcode.ErrBadParamInput.Error()})
+		return
+	}
+
+	if err := session.ConsumeStepUp(ctx.Context(), *userID, ctx.Get("X-Step-Up-Token")); err != nil {
+		ctx.Status(errorcode.StatusCode(err)).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		return
+	}
+	ctx.Next()
+}
+
+// Require only lets through users whose access token grants the permission
+func Require(permission string) func(*fiber.Ctx) {
+	return func(ctx *fiber.Ctx) {
+		for _, p := range GetPermissions(ctx) {
+			if p == permission {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.Status(http.StatusForbidden).JSON(fiber.Map{"status": "error", "message": errorcode.ErrForbidden.Error()})
+	}
+}
+
+const bearer = "Bearer"
+
+var errMissingJWT = errors.New("Missing or malformed JWT")
+
 func jwtError(c *fiber.Ctx, err error) {
-	if err.Error() == "Missing or malformed JWT" {
+	if err == errMissingJWT {
 		c.Status(http.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Missing or malformed JWT"})
 	} else {
 		c.Status(http.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid or expired JWT"})
@@ -44,10 +152,48 @@ func jwtError(c *fiber.Ctx, err error) {
 }
 
 func GetUserID(ctx *fiber.Ctx) (*uuid.UUID, error) {
+	return getClaimID(ctx, "user_id")
+}
+
+// GetSessionID returns session the access token was issued for
+func GetSessionID(ctx *fiber.Ctx) (*uuid.UUID, error) {
+	return getClaimID(ctx, "jti")
+}
+
+// GetPermissions returns permissions granted by the roles of the user when the access token was issued
+func GetPermissions(ctx *fiber.Ctx) []string {
+	return getClaimStrings(ctx, "permissions")
+}
+
+// GetRoles returns roles of the user when the access token was issued
+func GetRoles(ctx *fiber.Ctx) []string {
+	return getClaimStrings(ctx, "roles")
+}
+
+func getClaimStrings(ctx *fiber.Ctx, key string) []string {
+	user, ok := ctx.Locals("user").(*jwt.Token)
+	if !ok {
+		return nil
+	}
+	claims := user.Claims.(jwt.MapClaims)
+	values, _ := claims[key].([]interface{})
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func getClaimID(ctx *fiber.Ctx, key string) (*uuid.UUID, error) {
 	user := ctx.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
-	userID := claims["user_id"].(string)
-	id, err := uuid.FromString(userID)
+	value, ok := claims[key].(string)
+	if !ok {
+		return nil, errorcode.ErrUnauthorized
+	}
+	id, err := uuid.FromString(value)
 	if err != nil {
 		return nil, errorcode.ErrUnauthorized
 	}
@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+// fileRecord is one line of the file sink
+type fileRecord struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+// NewFileSender creates sender appending every message as JSON line to the file, or logging it
+// when path is empty. Only suitable for development and tests
+func NewFileSender(path string) Sender {
+	return &fileSender{path: path}
+}
+
+func (f *fileSender) Send(_ context.Context, msg Message) error {
+	if f.path == "" {
+		log.WithFields(log.Fields{"channel": msg.Channel, "to": msg.To, "subject": msg.Subject}).Info(msg.Body)
+		return nil
+	}
+
+	line, err := json.Marshal(fileRecord{Message: msg, SentAt: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
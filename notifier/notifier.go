@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Channel is the medium a message is delivered through
+type Channel string
+
+const (
+	Email Channel = "email"
+	SMS   Channel = "sms"
+)
+
+// Message is a single notification, Subject is ignored by channels without one
+type Message struct {
+	Channel Channel `json:"channel"`
+	To      string  `json:"to"`
+	Subject string  `json:"subject,omitempty"`
+	Body    string  `json:"body"`
+}
+
+// ErrNoSender is returned when the channel of the message has no sender configured
+var ErrNoSender = errors.New("notifier: no sender configured for the channel")
+
+// Sender delivers message through one channel
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var mu sync.RWMutex
+var senders = make(map[Channel]Sender)
+
+// Use sets the sender of the channel, should be called once on start up
+func Use(channel Channel, sender Sender) {
+	mu.Lock()
+	defer mu.Unlock()
+	senders[channel] = sender
+}
+
+// Send delivers the message through sender of its channel, returns ErrNoSender when none configured so a
+// message is never silently dropped
+func Send(ctx context.Context, msg Message) error {
+	mu.RLock()
+	s, ok := senders[msg.Channel]
+	mu.RUnlock()
+	if !ok {
+		return ErrNoSender
+	}
+	return s.Send(ctx, msg)
+}
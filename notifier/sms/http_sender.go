@@ -0,0 +1,67 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fajardm/ewallet-example/notifier"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Config holds settings of the sms gateway. The message is posted as JSON object of to, from and
+// message, Token is sent as bearer authorization when not empty
+type Config struct {
+	URL     string
+	Token   string
+	From    string
+	Timeout time.Duration
+}
+
+type httpSender struct {
+	config Config
+	client *http.Client
+}
+
+type payload struct {
+	To      string `json:"to"`
+	From    string `json:"from,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewHTTPSender creates sender delivering sms through generic http gateway
+func NewHTTPSender(config Config) notifier.Sender {
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second * 10
+	}
+	return &httpSender{config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+func (h *httpSender) Send(ctx context.Context, msg notifier.Message) error {
+	body, err := json.Marshal(payload{To: msg.To, From: h.config.From, Message: msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.config.Token)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		detail, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sms gateway responded %d: %s", res.StatusCode, detail)
+	}
+	return nil
+}
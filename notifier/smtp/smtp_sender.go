@@ -0,0 +1,69 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/fajardm/ewallet-example/notifier"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds settings of the smtp relay, authentication is skipped when Username is empty
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type smtpSender struct {
+	config Config
+	addr   string
+	auth   smtp.Auth
+}
+
+// NewSMTPSender creates sender delivering email through smtp relay, STARTTLS is used when the server offers it
+func NewSMTPSender(config Config) notifier.Sender {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	s := &smtpSender{config: config, addr: net.JoinHostPort(config.Host, strconv.Itoa(config.Port))}
+	if config.Username != "" {
+		s.auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	return s
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg notifier.Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", msg.To)
+	}
+
+	var body bytes.Buffer
+	body.WriteString("From: " + s.config.From + "\r\n")
+	body.WriteString("To: " + msg.To + "\r\n")
+	body.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	body.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	// smtp.SendMail does not take context, so run it aside and give up when the context is done
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, s.config.From, []string{msg.To}, body.Bytes())
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,107 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+// ErrUnknownHash is returned when the stored hash is neither argon2id nor bcrypt
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Params is the argon2id cost, changing it makes existing hashes upgraded on next login
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follows the recommendation of golang.org/x/crypto/argon2 for interactive login
+var DefaultParams = Params{Memory: 64 * 1024, Iterations: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+
+// Hash returns argon2id hash of the password in PHC string format, $argon2id$v=19$m=..,t=..,p=..$salt$key
+func Hash(password string) ([]byte, error) {
+	p := DefaultParams
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	return []byte(encoded), nil
+}
+
+// Compare reports whether the password matches the hash, the hash format is told by its prefix so
+// bcrypt hashes created before argon2id keep working
+func Compare(hashed []byte, password string) (bool, error) {
+	if !strings.HasPrefix(string(hashed), argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword(hashed, []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		if err != nil {
+			return false, ErrUnknownHash
+		}
+		return true, nil
+	}
+
+	p, salt, key, err := decode(hashed)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether the hash is not argon2id of the current params
+func NeedsRehash(hashed []byte) bool {
+	p, salt, key, err := decode(hashed)
+	if err != nil {
+		return true
+	}
+	return p.Memory != DefaultParams.Memory || p.Iterations != DefaultParams.Iterations || p.Parallelism != DefaultParams.Parallelism ||
+		uint32(len(salt)) != DefaultParams.SaltLength || uint32(len(key)) != DefaultParams.KeyLength
+}
+
+func decode(hashed []byte) (p Params, salt, key []byte, err error) {
+	parts := strings.Split(string(hashed), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		err = ErrUnknownHash
+		return
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		err = ErrUnknownHash
+		return
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		err = ErrUnknownHash
+		return
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		err = ErrUnknownHash
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		err = ErrUnknownHash
+		return
+	}
+	// Malformed params would make argon2 panic or compare empty keys
+	if len(salt) == 0 || len(key) == 0 || p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) {
+		err = ErrUnknownHash
+		return
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return
+}
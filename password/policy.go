@@ -0,0 +1,96 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 128
+)
+
+// Policy is the rule a new password must follow
+type Policy struct {
+	MinLength int
+	MaxLength int
+	breached  map[string]struct{}
+}
+
+// LoadPolicy reads PASSWORD.MIN_LENGTH, PASSWORD.MAX_LENGTH and PASSWORD.BREACHED_LIST, the breached list is a
+// file of one password per line and compared case insensitively
+func LoadPolicy() (*Policy, error) {
+	p := &Policy{
+		MinLength: viper.GetInt("PASSWORD.MIN_LENGTH"),
+		MaxLength: viper.GetInt("PASSWORD.MAX_LENGTH"),
+		breached:  make(map[string]struct{}),
+	}
+	if p.MinLength <= 0 {
+		p.MinLength = defaultMinLength
+	}
+	if p.MaxLength <= 0 {
+		p.MaxLength = defaultMaxLength
+	}
+
+	path := viper.GetString("PASSWORD.BREACHED_LIST")
+	if path == "" {
+		return p, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			p.breached[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Check returns error describing why the password is not acceptable
+func (p Policy) Check(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return errors.New("password is too common, it appears in known data breaches")
+	}
+	return nil
+}
+
+var policyOnce sync.Once
+var _policy *Policy
+var _policyErr error
+
+// Current returns the policy loaded once from the configuration
+func Current() (*Policy, error) {
+	policyOnce.Do(func() {
+		_policy, _policyErr = LoadPolicy()
+	})
+	return _policy, _policyErr
+}
+
+// Check validates the password against the current policy
+func Check(password string) error {
+	p, err := Current()
+	if err != nil {
+		return err
+	}
+	return p.Check(password)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	_auditRepository "github.com/fajardm/ewallet-example/app/audit/repository/mysql"
+	_auditUsecase "github.com/fajardm/ewallet-example/app/audit/usecase"
+	_balanceRepository "github.com/fajardm/ewallet-example/app/balance/repository/mysql"
+	_exportModel "github.com/fajardm/ewallet-example/app/export/model"
+	_kycModel "github.com/fajardm/ewallet-example/app/kyc/model"
+	_kycRepository "github.com/fajardm/ewallet-example/app/kyc/repository/mysql"
+	_kycUsecase "github.com/fajardm/ewallet-example/app/kyc/usecase"
+	_roleRepository "github.com/fajardm/ewallet-example/app/role/repository/mysql"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	_userRepository "github.com/fajardm/ewallet-example/app/user/repository/mysql"
+	_userUsecase "github.com/fajardm/ewallet-example/app/user/usecase"
+	"github.com/fajardm/ewallet-example/blob"
+	"github.com/fajardm/ewallet-example/database"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"log"
+	"time"
+)
+
+// Permanently deletes accounts closed longer than RETENTION.PERIOD ago, together with their balances, histories,
+// kyc documents and data exports. Meant to run daily from cron, -dry-run only lists the accounts
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list the accounts without deleting them")
+	flag.Parse()
+
+	viper.SetConfigFile("./config.yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error config file"))
+	}
+	retention := viper.GetDuration("RETENTION.PERIOD")
+	if retention <= 0 {
+		log.Fatal("Fatal error RETENTION.PERIOD must be positive")
+	}
+
+	dbUser := viper.GetString("DATABASE.USER")
+	dbPassword := viper.GetString("DATABASE.PASSWORD")
+	dbHost := viper.GetString("DATABASE.HOST")
+	dbPort := viper.GetString("DATABASE.PORT")
+	dbName := viper.GetString("DATABASE.NAME")
+	conn, err := sql.Open(`mysql`, fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUser, dbPassword, dbHost, dbPort, dbName))
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error connecting database"))
+	}
+	err = conn.Ping()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error ping database"))
+	}
+	db := &database.MySQL{DB: conn}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error close database"))
+		}
+	}()
+	blob.Use(blob.NewFileStore(viper.GetString("BLOB.PATH")))
+
+	ctx := context.Background()
+	contextTimeout := viper.GetDuration("CONTEXT_TIMEOUT")
+	auditUsecase := _auditUsecase.NewAuditUsecase(_auditRepository.NewAuditRepository(db), contextTimeout)
+	userRepository := _userRepository.NewUserRepository(db)
+	userUsecase := _userUsecase.NewUserUsecase(userRepository, _balanceRepository.NewBalanceRepository(db), _roleRepository.NewRoleRepository(db), auditUsecase, _userModel.LoginPolicy{}, contextTimeout)
+	kycUsecase := _kycUsecase.NewKYCUsecase(_kycRepository.NewKYCRepository(db), userRepository, auditUsecase, _kycModel.Policy{}, contextTimeout)
+	before := time.Now().Add(-retention)
+
+	if *dryRun {
+		users, err := userRepository.FetchDeletedBefore(ctx, before, 1000)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error fetch closed accounts"))
+		}
+		for _, user := range users {
+			fmt.Println(user.ID, "closed at", user.DeletedAt.Format(time.RFC3339))
+		}
+		return
+	}
+
+	total := 0
+	for {
+		users, err := userUsecase.Purge(ctx, before)
+		for _, user := range users {
+			// Rows are gone already, so failing to remove files is reported but does not stop the purge
+			if err := kycUsecase.PurgeDocuments(ctx, user.ID); err != nil {
+				log.Println(errors.Wrapf(err, "Error purge kyc documents of %s", user.ID))
+			}
+			if err := blob.Blob().DeletePrefix(ctx, _exportModel.BlobPrefix(user.ID)); err != nil {
+				log.Println(errors.Wrapf(err, "Error purge data exports of %s", user.ID))
+			}
+		}
+		total += len(users)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error purge closed accounts"))
+		}
+		if len(users) == 0 {
+			break
+		}
+	}
+	fmt.Println("purged", total, "accounts")
+}
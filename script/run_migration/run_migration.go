@@ -12,6 +12,14 @@ import (
 	"strings"
 )
 
+const (
+	queryCreateSchema = "CREATE SCHEMA IF NOT EXISTS `ewallet` DEFAULT CHARACTER SET utf8"
+	// Table schema_migrations keeps track of applied migration files, so each file only runs once
+	queryCreateMigrations = "CREATE TABLE IF NOT EXISTS `ewallet`.`schema_migrations` (`version` VARCHAR(255) NOT NULL, `applied_at` DATETIME NOT NULL, PRIMARY KEY (`version`)) ENGINE = InnoDB"
+	querySelectMigration  = "SELECT COUNT(*) FROM `ewallet`.`schema_migrations` WHERE version=?"
+	queryInsertMigration  = "INSERT INTO `ewallet`.`schema_migrations` (version, applied_at) VALUES (?, NOW())"
+)
+
 func main() {
 	viper.SetConfigFile("./config.yaml")
 	if err := viper.ReadInConfig(); err != nil {
@@ -31,11 +39,24 @@ func main() {
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Fatal error ping database"))
 	}
+	if _, err := conn.Exec(queryCreateSchema); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error create schema"))
+	}
+	if _, err := conn.Exec(queryCreateMigrations); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error create migrations table"))
+	}
 	files, err := ioutil.ReadDir("./database/migrations")
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Fatal error read migrations directory"))
 	}
 	for _, file := range files {
+		var applied int
+		if err := conn.QueryRow(querySelectMigration, file.Name()).Scan(&applied); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error check migration file"))
+		}
+		if applied > 0 {
+			continue
+		}
 		f, err := ioutil.ReadFile("./database/migrations/" + file.Name())
 		if err != nil {
 			log.Fatal(errors.Wrap(err, "Fatal error read migration file"))
@@ -49,6 +70,9 @@ func main() {
 				}
 			}
 		}
+		if _, err := conn.Exec(queryInsertMigration, file.Name()); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error record migration file"))
+		}
 	}
 	db := &database.MySQL{DB: conn}
 	if err := db.Close(); err != nil {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	_auditRepository "github.com/fajardm/ewallet-example/app/audit/repository/mysql"
+	_auditUsecase "github.com/fajardm/ewallet-example/app/audit/usecase"
+	_balanceRepository "github.com/fajardm/ewallet-example/app/balance/repository/mysql"
+	_roleModel "github.com/fajardm/ewallet-example/app/role/model"
+	_roleRepository "github.com/fajardm/ewallet-example/app/role/repository/mysql"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
+	_userRepository "github.com/fajardm/ewallet-example/app/user/repository/mysql"
+	_userUsecase "github.com/fajardm/ewallet-example/app/user/usecase"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"log"
+	"os"
+	"time"
+)
+
+// Creates the admin account, or grants admin role to the existing account of the username. The password is read
+// from ADMIN_PASSWORD environment variable so it does not end up in shell history
+func main() {
+	username := flag.String("username", "admin", "username of the admin account")
+	email := flag.String("email", "", "email of the admin account")
+	mobilePhone := flag.String("phone", "", "mobile phone of the admin account")
+	flag.Parse()
+
+	viper.SetConfigFile("./config.yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error config file"))
+	}
+
+	dbUser := viper.GetString("DATABASE.USER")
+	dbPassword := viper.GetString("DATABASE.PASSWORD")
+	dbHost := viper.GetString("DATABASE.HOST")
+	dbPort := viper.GetString("DATABASE.PORT")
+	dbName := viper.GetString("DATABASE.NAME")
+	conn, err := sql.Open(`mysql`, fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUser, dbPassword, dbHost, dbPort, dbName))
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error connecting database"))
+	}
+	err = conn.Ping()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error ping database"))
+	}
+	db := &database.MySQL{DB: conn}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error close database"))
+		}
+	}()
+
+	ctx := context.Background()
+	contextTimeout := viper.GetDuration("CONTEXT_TIMEOUT")
+	userRepository := _userRepository.NewUserRepository(db)
+	roleRepository := _roleRepository.NewRoleRepository(db)
+	auditUsecase := _auditUsecase.NewAuditUsecase(_auditRepository.NewAuditRepository(db), contextTimeout)
+	userUsecase := _userUsecase.NewUserUsecase(userRepository, _balanceRepository.NewBalanceRepository(db), roleRepository, auditUsecase, _userModel.LoginPolicy{}, contextTimeout)
+
+	user, err := userRepository.GetByUsernameOrEmail(ctx, *username, *username)
+	if err == errorcode.ErrNotFound {
+		input := _userModel.Input{Username: *username, Email: *email, MobilePhone: *mobilePhone, Password: os.Getenv("ADMIN_PASSWORD")}
+		if err := input.Validate(); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error invalid admin account"))
+		}
+		if user, err = input.NewUser(); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error create admin account"))
+		}
+		if err := userUsecase.Store(ctx, *user); err != nil {
+			log.Fatal(errors.Wrap(err, "Fatal error store admin account"))
+		}
+		fmt.Println("created", user.Username)
+	} else if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error get admin account"))
+	}
+
+	grant, err := roleRepository.GetGrantByUserID(ctx, user.ID)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error get roles"))
+	}
+	if grant.HasRole(_roleModel.Admin) {
+		fmt.Println(user.Username, "is already admin")
+		return
+	}
+	err = roleRepository.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return roleRepository.TxAssign(ctx, tx, _roleModel.UserRole{UserID: user.ID, Role: _roleModel.Admin, CreatedBy: user.ID, CreatedAt: time.Now()})
+	})
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error assign admin role"))
+	}
+	fmt.Println("granted admin to", user.Username)
+}
@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu    sync.RWMutex
+	items map[string]memoryItem
+	done  chan struct{}
+}
+
+// NewMemoryStore creates store living in the process memory, only suitable for single instance and
+// tests. Expired keys are removed every cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) Store {
+	m := &memoryStore{items: make(map[string]memoryItem), done: make(chan struct{})}
+	go m.cleanup(cleanupInterval)
+	return m
+}
+
+func (m *memoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[key] = memoryItem{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (m *memoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	item, ok := m.items[key]
+	if !ok || !time.Now().Before(item.expiresAt) {
+		return nil, errorcode.ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (m *memoryStore) Take(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	delete(m.items, key)
+	if !ok || !time.Now().Before(item.expiresAt) {
+		return nil, errorcode.ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (m *memoryStore) Increment(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok || !time.Now().Before(item.expiresAt) {
+		item = memoryItem{value: []byte("0"), expiresAt: time.Now().Add(ttl)}
+	}
+	count, err := strconv.ParseInt(string(item.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+	item.value = []byte(strconv.FormatInt(count, 10))
+	m.items[key] = item
+	return count, nil
+}
+
+func (m *memoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *memoryStore) Close() error {
+	close(m.done)
+	return nil
+}
+
+func (m *memoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for key, item := range m.items {
+				if !now.Before(item.expiresAt) {
+					delete(m.items, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
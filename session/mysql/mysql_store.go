@@ -0,0 +1,137 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	log "github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	// Table sessions
+	querySelectSession = `
+		SELECT value FROM sessions WHERE id=? AND expires_at>?
+	`
+	querySelectSessionForUpdate = `
+		SELECT value FROM sessions WHERE id=? AND expires_at>? FOR UPDATE
+	`
+	queryUpsertSession = `
+		INSERT INTO sessions (id, value, expires_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE value=VALUES(value), expires_at=VALUES(expires_at)
+	`
+	queryIncrementSession = `
+		INSERT INTO sessions (id, value, expires_at) VALUES (?, '1', ?)
+		ON DUPLICATE KEY UPDATE
+			value=IF(expires_at>?, CAST(value AS UNSIGNED)+1, 1),
+			expires_at=IF(expires_at>?, expires_at, VALUES(expires_at))
+	`
+	querySelectSessionCounter = `
+		SELECT CAST(value AS UNSIGNED) FROM sessions WHERE id=?
+	`
+	queryDeleteSession = `
+		DELETE FROM sessions WHERE id=?
+	`
+	queryDeleteExpiredSessions = `
+		DELETE FROM sessions WHERE expires_at<=?
+	`
+)
+
+type mysqlStore struct {
+	db   *database.MySQL
+	done chan struct{}
+}
+
+// NewMySQLStore creates store persisted in sessions table. Expired rows are removed every cleanupInterval
+func NewMySQLStore(conn *database.MySQL, cleanupInterval time.Duration) session.Store {
+	m := &mysqlStore{db: conn, done: make(chan struct{})}
+	go m.cleanup(cleanupInterval)
+	return m
+}
+
+func (m mysqlStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+	_, err = m.db.ExecContext(ctx, queryUpsertSession, key, value, time.Now().Add(ttl))
+	return
+}
+
+func (m mysqlStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := m.db.QueryRowContext(ctx, querySelectSession, key, time.Now()).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, errorcode.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Take locks the row while reading it, the value is only returned when this call is the one deleting it
+func (m mysqlStore) Take(ctx context.Context, key string) (value []byte, err error) {
+	err = m.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, querySelectSessionForUpdate, key, time.Now()).Scan(&value)
+		if err == sql.ErrNoRows {
+			return errorcode.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, queryDeleteSession, key)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return errorcode.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Increment upserts the counter and reads it back in one transaction, the row stays locked in between so the
+// count read is the one written by this call. Value assignments rely on MySQL evaluating them left to right
+func (m mysqlStore) Increment(ctx context.Context, key string, ttl time.Duration) (count int64, err error) {
+	err = m.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, queryIncrementSession, key, now.Add(ttl), now, now); err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx, querySelectSessionCounter, key).Scan(&count)
+	})
+	return
+}
+
+func (m mysqlStore) Delete(ctx context.Context, key string) (err error) {
+	_, err = m.db.ExecContext(ctx, queryDeleteSession, key)
+	return
+}
+
+// Close stops the cleanup, the connection is owned by the caller
+func (m mysqlStore) Close() error {
+	close(m.done)
+	return nil
+}
+
+func (m mysqlStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			if _, err := m.db.Exec(queryDeleteExpiredSessions, now); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
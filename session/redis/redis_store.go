@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	"github.com/pkg/errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Config holds connection settings of redis server, or any server speaking redis protocol
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+	PoolSize int
+	Timeout  time.Duration
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+type redisStore struct {
+	config Config
+	pool   chan *conn
+}
+
+// NewRedisStore creates store backed by redis. Expiry is handled by the server itself, so no cleanup is needed
+func NewRedisStore(config Config) (session.Store, error) {
+	if config.PoolSize <= 0 {
+		config.PoolSize = 10
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Second * 3
+	}
+	r := &redisStore{config: config, pool: make(chan *conn, config.PoolSize)}
+
+	// Fail early on wrong address or credential
+	c, err := r.dial()
+	if err != nil {
+		return nil, err
+	}
+	r.put(c)
+	return r, nil
+}
+
+func (r *redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(milliseconds(ttl), 10))
+	return err
+}
+
+// milliseconds of the ttl, redis refuses expiry below one
+func milliseconds(ttl time.Duration) int64 {
+	if ms := ttl.Milliseconds(); ms > 0 {
+		return ms
+	}
+	return 1
+}
+
+func (r *redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return r.bulk(ctx, "GET", key)
+}
+
+// Take relies on GETDEL, which needs redis 6.2 or newer
+func (r *redisStore) Take(ctx context.Context, key string) ([]byte, error) {
+	return r.bulk(ctx, "GETDEL", key)
+}
+
+// bulk runs command replying bulk string, nil reply is errorcode.ErrNotFound
+func (r *redisStore) bulk(ctx context.Context, command, key string) ([]byte, error) {
+	reply, err := r.do(ctx, command, key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, errorcode.ErrNotFound
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %T for %s", reply, command)
+	}
+	return b, nil
+}
+
+// Increment creates the counter with its expiry before incrementing, INCR keeps the expiry of existing key
+func (r *redisStore) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if _, err := r.do(ctx, "SET", key, "0", "NX", "PX", strconv.FormatInt(milliseconds(ttl), 10)); err != nil {
+		return 0, err
+	}
+	reply, err := r.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply %T for INCR", reply)
+	}
+	return count, nil
+}
+
+func (r *redisStore) Delete(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DEL", key)
+	return err
+}
+
+func (r *redisStore) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (r *redisStore) dial() (*conn, error) {
+	nc, err := net.DialTimeout("tcp", r.config.Addr, r.config.Timeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial redis")
+	}
+	c := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	if r.config.Password != "" {
+		if _, err := c.do(time.Now().Add(r.config.Timeout), "AUTH", r.config.Password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if r.config.DB != 0 {
+		if _, err := c.do(time.Now().Add(r.config.Timeout), "SELECT", strconv.Itoa(r.config.DB)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (r *redisStore) get() (*conn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+		return r.dial()
+	}
+}
+
+func (r *redisStore) put(c *conn) {
+	select {
+	case r.pool <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (r *redisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := r.get()
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.config.Timeout)
+	}
+	reply, err := c.do(deadline, args...)
+	if _, ok := err.(replyError); err != nil && !ok {
+		// Connection state is unknown after network error
+		c.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// replyError is error returned by the server, the connection is still usable
+type replyError string
+
+func (e replyError) Error() string {
+	return "redis: " + string(e)
+}
+
+func (c *conn) do(deadline time.Time, args ...string) (interface{}, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read parses one RESP reply, bulk string is returned as []byte and nil bulk as nil
+func (c *conn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	head, body := line[0], line[1:len(line)-2]
+	switch head {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, replyError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			if list[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", head)
+}
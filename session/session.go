@@ -1,17 +1,53 @@
 package session
 
 import (
-	"github.com/gofiber/session"
+	"context"
 	"sync"
 	"time"
 )
 
-var once sync.Once
-var _session *session.Session
+// Store is the key value storage with expiry behind sessions. Every instance of the app must
+// point to the same store so a session is known regardless which instance served the login
+type Store interface {
+	// Set stores value under the key, the key expires after ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Get returns value of the key, errorcode.ErrNotFound when missing or expired
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Take returns value of the key and removes it at once, so only one of concurrent callers gets the value.
+	// Returns errorcode.ErrNotFound when missing or expired
+	Take(ctx context.Context, key string) ([]byte, error)
+	// Increment adds one to the counter under the key and returns the new count. A missing or expired counter
+	// starts from one and expires after ttl, incrementing does not extend the expiry
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Delete removes the key, deleting missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// Close releases resources held by the store
+	Close() error
+}
+
+var mu sync.RWMutex
+var _store Store
+
+// Use sets the store returned by Session, should be called once on start up
+func Use(store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	_store = store
+}
+
+// Session returns the configured store, falls back to in-memory store when none configured
+func Session() Store {
+	mu.RLock()
+	s := _store
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
 
-func Session() *session.Session {
-	once.Do(func() {
-		_session = session.New(session.Config{Expiration: time.Hour * 24})
-	})
-	return _session
+	mu.Lock()
+	defer mu.Unlock()
+	if _store == nil {
+		_store = NewMemoryStore(time.Minute)
+	}
+	return _store
 }
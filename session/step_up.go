@@ -0,0 +1,45 @@
+package session
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/token"
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
+
+func stepUpKey(t string) string {
+	return "step_up:" + token.Hash(t)
+}
+
+// IssueStepUp creates single use token proving the user has just verified the pin
+func IssueStepUp(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, error) {
+	t, err := token.NewOpaque()
+	if err != nil {
+		return "", err
+	}
+	if err := Session().Set(ctx, stepUpKey(t), userID.Bytes(), ttl); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+// ConsumeStepUp returns errorcode.ErrStepUpRequired when the token is unknown, expired or belongs
+// to other user. The token is taken out of the store before it is checked, so concurrent requests
+// presenting the same token authorize one action only
+func ConsumeStepUp(ctx context.Context, userID uuid.UUID, t string) error {
+	if t == "" {
+		return errorcode.ErrStepUpRequired
+	}
+	value, err := Session().Take(ctx, stepUpKey(t))
+	if err == errorcode.ErrNotFound {
+		return errorcode.ErrStepUpRequired
+	}
+	if err != nil {
+		return err
+	}
+	if !uuid.Equal(uuid.FromBytesOrNil(value), userID) {
+		return errorcode.ErrStepUpRequired
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAdjustmentApproval(t *testing.T) {
+	maker := createUser(`{ "username": "adjustmaker", "email": "adjustmaker@gmail.com", "mobile_phone": "081273649610", "password": "secret-pass" }`)
+	checker := createUser(`{ "username": "adjustchecker", "email": "adjustchecker@gmail.com", "mobile_phone": "081273649611", "password": "secret-pass" }`)
+	customer := createUser(`{ "username": "adjustcustomer", "email": "adjustcustomer@gmail.com", "mobile_phone": "081273649612", "password": "secret-pass" }`)
+	grantRole(t, maker.ID, "admin")
+	grantRole(t, checker.ID, "admin")
+	makerToken := loginUser(`{ "username_or_email": "adjustmaker", "password": "secret-pass" }`)
+	checkerToken := loginUser(`{ "username_or_email": "adjustchecker", "password": "secret-pass" }`)
+	customerToken := loginUser(`{ "username_or_email": "adjustcustomer", "password": "secret-pass" }`)
+
+	code, body := sendJSON("POST", "/api/admin/adjustments", makerToken, fmt.Sprintf(`{ "user_id": "%s", "type": "credit", "amount": 10, "reason": "refund of failed top up", "reference": "TICKET-1" }`, customer.ID))
+	assert.Equal(t, 201, code, "test propose adjustment")
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	url := "/api/admin/adjustments/" + resp.Data.ID
+
+	code, _ = sendJSON("POST", url+"/approve", makerToken, `{}`)
+	assert.Equal(t, 403, code, "test proposer can not approve own adjustment")
+	_, amount := fetchBalance(t, customerToken)
+	assert.Equal(t, float64(0), amount, "test self approval posts nothing")
+
+	code, _ = sendJSON("POST", url+"/approve", checkerToken, `{}`)
+	assert.Equal(t, 200, code, "test other operator approves")
+	_, amount = fetchBalance(t, customerToken)
+	assert.Equal(t, float64(10), amount, "test approved adjustment posted")
+
+	code, _ = sendJSON("POST", url+"/approve", checkerToken, `{}`)
+	assert.Equal(t, 409, code, "test adjustment approved once")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type auditEvent struct {
+	ActorID    string          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+}
+
+func fetchAudit(token, query string) (int, []auditEvent) {
+	code, body := sendJSON("GET", "/api/admin/audit?"+query, token, "")
+	var resp struct {
+		Data struct {
+			Events []auditEvent `json:"events"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return code, resp.Data.Events
+}
+
+func TestAuditLog(t *testing.T) {
+	admin := createUser(`{ "username": "auditadmin", "email": "auditadmin@gmail.com", "mobile_phone": "081273649590", "password": "secret-pass" }`)
+	user := createUser(`{ "username": "audituser", "email": "audituser@gmail.com", "mobile_phone": "081273649591", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "admin")
+	adminToken := loginUser(`{ "username_or_email": "auditadmin", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "audituser", "password": "secret-pass" }`)
+
+	req, _ := http.NewRequest("PATCH", "/api/users", bytes.NewBufferString(`{ "email": "audituser2@gmail.com" }`))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("X-Request-ID", "audit-test-request")
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error update user"))
+	}
+	assert.Equal(t, 200, res.StatusCode, "test update user")
+	assert.Equal(t, "audit-test-request", res.Header.Get("X-Request-ID"), "test request id is echoed")
+	assert.Equal(t, 200, topUpBalance(token, 10), "test top up")
+
+	code, _ := fetchAudit(token, "actor_id="+user.ID.String())
+	assert.Equal(t, 403, code, "test customer can not query audit log")
+	code, _ = fetchAudit(adminToken, "actor_id=xxx")
+	assert.Equal(t, 400, code, "test query with invalid actor id")
+	code, _ = fetchAudit(adminToken, "from=yesterday")
+	assert.Equal(t, 400, code, "test query with invalid time")
+
+	code, events := fetchAudit(adminToken, fmt.Sprintf("actor_id=%s&action=user.update", user.ID))
+	assert.Equal(t, 200, code, "test query by actor and action")
+	if assert.Len(t, events, 1, "test profile update is recorded") {
+		assert.Equal(t, user.ID.String(), events[0].TargetID, "test target of profile update")
+		assert.Equal(t, "audit-test-request", events[0].RequestID, "test request id is recorded")
+		assert.Contains(t, string(events[0].After), `"email"`, "test changed fields are recorded")
+		assert.NotContains(t, string(events[0].After), "audituser2@gmail.com", "test personal data is left out")
+	}
+
+	code, events = fetchAudit(adminToken, fmt.Sprintf("actor_id=%s&target_type=balance", user.ID))
+	assert.Equal(t, 200, code, "test query by actor and target type")
+	if assert.Len(t, events, 1, "test top up is recorded") {
+		assert.Equal(t, "balance.top_up", events[0].Action, "test top up action")
+	}
+
+	code, events = fetchAudit(adminToken, fmt.Sprintf("target_id=%s&to=2000-01-01T00:00:00Z", user.ID))
+	assert.Equal(t, 200, code, "test query by time range")
+	assert.Len(t, events, 0, "test events after the range are left out")
+
+	_, err = db.Exec("UPDATE audit_events SET action='user.register' WHERE actor_id=?", user.ID)
+	assert.Error(t, err, "test audit events can not be updated")
+	_, err = db.Exec("DELETE FROM audit_events WHERE actor_id=?", user.ID)
+	assert.Error(t, err, "test audit events can not be deleted")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func loginRefreshToken(request string) string {
+	req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(ipHeader, clientIP(request))
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error login user"))
+	}
+	body, err := ioutil.ReadAll(res.Body)
+
+	var resp struct {
+		Data struct {
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal token"))
+	}
+
+	return resp.Data.RefreshToken
+}
+
+func refreshToken(refreshToken string) (int, string) {
+	req, _ := http.NewRequest("POST", "/api/users/token/refresh", bytes.NewBufferString(fmt.Sprintf(`{ "refresh_token": "%s" }`, refreshToken)))
+	req.Header.Add("Content-Type", "application/json")
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error refresh token"))
+	}
+	body, err := ioutil.ReadAll(res.Body)
+
+	var resp struct {
+		Data struct {
+			RefreshToken string `json:"refresh_token"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal token"))
+	}
+
+	return res.StatusCode, resp.Data.RefreshToken
+}
+
+func TestRefreshToken(t *testing.T) {
+	createUser(`{ "username": "orton", "email": "orton@gmail.com", "mobile_phone": "081273649510", "password": "secret-pass" }`)
+	first := loginRefreshToken(`{ "username_or_email": "orton", "password": "secret-pass" }`)
+
+	code, _ := refreshToken("unknown")
+	assert.Equal(t, 401, code, "test with unknown refresh token")
+
+	code, second := refreshToken(first)
+	assert.Equal(t, 200, code, "test with valid refresh token")
+	assert.NotEqual(t, first, second, "test refresh token is rotated")
+
+	code, _ = refreshToken(first)
+	assert.Equal(t, 401, code, "test with reused refresh token")
+
+	code, _ = refreshToken(second)
+	assert.Equal(t, 401, code, "test family is revoked after reuse")
+}
+
+func fetchSessions(token string) (int, []map[string]interface{}) {
+	req, _ := http.NewRequest("GET", "/api/users/sessions", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error fetch sessions"))
+	}
+	body, err := ioutil.ReadAll(res.Body)
+
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal sessions"))
+	}
+
+	return res.StatusCode, resp.Data
+}
+
+func TestSessions(t *testing.T) {
+	createUser(`{ "username": "batista", "email": "batista@gmail.com", "mobile_phone": "081273649511", "password": "secret-pass" }`)
+	phone := loginUser(`{ "username_or_email": "batista", "password": "secret-pass", "device_name": "phone" }`)
+	laptop := loginUser(`{ "username_or_email": "batista", "password": "secret-pass", "device_name": "laptop" }`)
+
+	code, sessions := fetchSessions(laptop)
+	assert.Equal(t, 200, code, "test fetch sessions")
+	assert.Len(t, sessions, 2, "test fetch sessions")
+
+	req, _ := http.NewRequest("DELETE", "/api/users/sessions", nil)
+	req.Header.Add("Authorization", "Bearer "+laptop)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test revoke other sessions")
+	assert.Equal(t, 200, res.StatusCode, "test revoke other sessions")
+
+	code, sessions = fetchSessions(laptop)
+	assert.Equal(t, 200, code, "test fetch sessions after revoke")
+	assert.Len(t, sessions, 1, "test fetch sessions after revoke")
+	assert.Equal(t, "laptop", sessions[0]["device_name"], "test current session is kept")
+	assert.Equal(t, true, sessions[0]["current"], "test current session is kept")
+
+	code, _ = fetchSessions(phone)
+	assert.Equal(t, 401, code, "test revoked session is rejected")
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/users/sessions/%s", uuid.NewV4().String()), nil)
+	req.Header.Add("Authorization", "Bearer "+laptop)
+	res, err = app.Test(req, -1)
+	assert.NoError(t, err, "test revoke unknown session")
+	assert.Equal(t, 404, res.StatusCode, "test revoke unknown session")
+}
+
+func TestJWKS(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test fetch jwks")
+	assert.Equal(t, 200, res.StatusCode, "test fetch jwks")
+
+	body, _ := ioutil.ReadAll(res.Body)
+	var resp struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &resp), "test fetch jwks")
+	assert.NotNil(t, resp.Keys, "test fetch jwks")
+}
@@ -0,0 +1,527 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func topUpBalance(token string, amount float64) int {
+	req, _ := http.NewRequest("POST", "/api/balances/topup", bytes.NewBufferString(fmt.Sprintf(`{ "amount": %f }`, amount)))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		return 0
+	}
+	return res.StatusCode
+}
+
+func TestTransferBalance(t *testing.T) {
+	sender := createUser(`{ "username": "sender", "email": "sender@gmail.com", "mobile_phone": "081200000001", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "receiver", "email": "receiver@gmail.com", "mobile_phone": "081200000002", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "sender", "password": "secret-pass" }`)
+	assert.Equal(t, 200, topUpBalance(token, 10))
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+
+	_, stepUp := verifyPIN(token, "123456")
+	req, _ := http.NewRequest("POST", "/api/balances/transfer", bytes.NewBufferString(fmt.Sprintf(`{ "to_user_id": "%s", "amount": 1 }`, receiver.ID)))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("X-Step-Up-Token", stepUp)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test without verified phone")
+	assert.Equal(t, 403, res.StatusCode, "test without verified phone")
+	assert.Contains(t, string(GetBody(res.Body)), "verification required", "test without verified phone")
+	assert.Equal(t, 200, verifyPhone(token, "081200000001", t))
+
+	cases := []struct {
+		description  string
+		request      string
+		withoutPIN   bool
+		expectedCode int
+	}{
+		{
+			description:  "test without pin verification",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": 1 }`, receiver.ID),
+			withoutPIN:   true,
+			expectedCode: 403,
+		},
+		{
+			description:  "test with negative amount",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": -1 }`, receiver.ID),
+			expectedCode: 400,
+		},
+		{
+			description:  "test with amount more than balance",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": 1000 }`, receiver.ID),
+			expectedCode: 422,
+		},
+		{
+			description:  "test transfer to self",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": 1 }`, sender.ID),
+			expectedCode: 400,
+		},
+		{
+			description:  "test with amount covered by balance",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": 10 }`, receiver.ID),
+			expectedCode: 200,
+		},
+		{
+			description:  "test with empty balance",
+			request:      fmt.Sprintf(`{ "to_user_id": "%s", "amount": 1 }`, receiver.ID),
+			expectedCode: 422,
+		},
+	}
+
+	for _, test := range cases {
+		req, _ := http.NewRequest("POST", "/api/balances/transfer", bytes.NewBufferString(test.request))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("Authorization", "Bearer "+token)
+		if !test.withoutPIN {
+			_, stepUp := verifyPIN(token, "123456")
+			req.Header.Add("X-Step-Up-Token", stepUp)
+		}
+		res, err := app.Test(req, -1)
+
+		assert.NoError(t, err, test.description)
+		assert.Equal(t, test.expectedCode, res.StatusCode, test.description)
+	}
+}
+
+func TestConcurrentTransfers(t *testing.T) {
+	createUser(`{ "username": "racer", "email": "racer@gmail.com", "mobile_phone": "081273649660", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "racereceiver", "email": "racereceiver@gmail.com", "mobile_phone": "081273649661", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "racer", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649660", t))
+	assert.Equal(t, 200, topUpBalance(token, 10))
+
+	stepUps := make([]string, 5)
+	for i := range stepUps {
+		_, stepUps[i] = verifyPIN(token, "123456")
+	}
+	codes := make([]int, len(stepUps))
+	var wg sync.WaitGroup
+	for i, stepUp := range stepUps {
+		wg.Add(1)
+		go func(i int, stepUp string) {
+			defer wg.Done()
+			req, _ := http.NewRequest("POST", "/api/balances/transfer", bytes.NewBufferString(fmt.Sprintf(`{ "to_user_id": "%s", "amount": 5 }`, receiver.ID)))
+			req.Header.Add("Content-Type", "application/json")
+			req.Header.Add("Authorization", "Bearer "+token)
+			req.Header.Add("X-Step-Up-Token", stepUp)
+			if res, err := app.Test(req, -1); err == nil {
+				codes[i] = res.StatusCode
+			}
+		}(i, stepUp)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == 200 {
+			succeeded++
+		} else {
+			assert.Equal(t, 422, code, "test concurrent transfer over balance")
+		}
+	}
+	assert.Equal(t, 2, succeeded, "test only transfers covered by balance succeed")
+	_, amount := fetchBalance(t, token)
+	assert.Equal(t, float64(0), amount, "test sender balance after concurrent transfers")
+	_, amount = fetchBalance(t, loginUser(`{ "username_or_email": "racereceiver", "password": "secret-pass" }`))
+	assert.Equal(t, float64(10), amount, "test receiver balance after concurrent transfers")
+}
+
+// fetchBalance returns id and amount of the main balance of the token owner
+func fetchBalance(t *testing.T, token string) (string, float64) {
+	code, body := sendJSON("GET", "/api/balances", token, "")
+	if code != 200 {
+		t.Fatalf("fetch balance: %d %s", code, body)
+	}
+	var resp struct {
+		Data struct {
+			ID      string  `json:"id"`
+			Balance float64 `json:"balance"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return resp.Data.ID, resp.Data.Balance
+}
+
+// setTier sets the kyc tier directly in database, it is read along with the balance on every request
+func setTier(t *testing.T, userID uuid.UUID, tier string) {
+	if _, err := db.Exec("UPDATE users SET tier=? WHERE id=?", tier, userID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sendStepUp sends the request with a fresh step up token of the pin
+func sendStepUp(method, url, token, pin, request string) int {
+	_, stepUp := verifyPIN(token, pin)
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("X-Step-Up-Token", stepUp)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		return 0
+	}
+	return res.StatusCode
+}
+
+// createSharedWallet creates shared wallet of the token owner and returns its id
+func createSharedWallet(t *testing.T, token, name string) string {
+	code, body := sendJSON("POST", "/api/balances/shared", token, fmt.Sprintf(`{ "name": "%s" }`, name))
+	if code != 201 {
+		t.Fatalf("create shared wallet: %d %s", code, body)
+	}
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return resp.Data.ID
+}
+
+func TestSharedWalletTransferLimits(t *testing.T) {
+	owner := createUser(`{ "username": "limitowner", "email": "limitowner@gmail.com", "mobile_phone": "081273649600", "password": "secret-pass" }`)
+	member := createUser(`{ "username": "limitmember", "email": "limitmember@gmail.com", "mobile_phone": "081273649601", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "limitreceiver", "email": "limitreceiver@gmail.com", "mobile_phone": "081273649602", "password": "secret-pass" }`)
+	setTier(t, owner.ID, "verified")
+	ownerToken := loginUser(`{ "username_or_email": "limitowner", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(ownerToken, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(ownerToken, "081273649600", t))
+	token := loginUser(`{ "username_or_email": "limitmember", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649601", t))
+
+	walletID := createSharedWallet(t, ownerToken, "limits")
+	url := "/api/balances/shared/" + walletID
+	assert.Equal(t, 200, topUpBalance(ownerToken, 300))
+	assert.Equal(t, 200, sendStepUp("POST", url+"/contribute", ownerToken, "123456", `{ "amount": 300 }`), "test contribute to shared wallet")
+	code, _ := sendJSON("POST", url+"/members", ownerToken, fmt.Sprintf(`{ "user_id": "%s", "role": "spender", "allowed_operations": ["transfer"] }`, member.ID))
+	assert.Equal(t, 201, code, "test add basic member")
+	code, _ = sendJSON("POST", url+"/members/accept", token, "")
+	assert.Equal(t, 200, code, "test accept invitation")
+
+	transfer := func(amount float64) string {
+		return fmt.Sprintf(`{ "to_user_id": "%s", "amount": %f }`, receiver.ID, amount)
+	}
+	assert.Equal(t, 422, sendStepUp("POST", url+"/transfer", token, "123456", transfer(55)), "test shared transfer over basic max transfer")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/transfer", token, "123456", transfer(40)), "test shared transfer within basic limits")
+	assert.Equal(t, 200, topUpBalance(token, 30))
+	assert.Equal(t, 422, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer(30)), "test shared transfer counts toward daily limit")
+	assert.Equal(t, 422, sendStepUp("POST", url+"/transfer", token, "123456", transfer(30)), "test shared transfer over basic daily limit")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/transfer", token, "123456", transfer(20)), "test shared transfer up to basic daily limit")
+}
+
+func TestSharedWalletContributeLimits(t *testing.T) {
+	owner := createUser(`{ "username": "contribowner", "email": "contribowner@gmail.com", "mobile_phone": "081273649680", "password": "secret-pass" }`)
+	member := createUser(`{ "username": "contribmember", "email": "contribmember@gmail.com", "mobile_phone": "081273649681", "password": "secret-pass" }`)
+	setTier(t, owner.ID, "verified")
+	ownerToken := loginUser(`{ "username_or_email": "contribowner", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "contribmember", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649681", t))
+
+	walletID := createSharedWallet(t, ownerToken, "contributions")
+	url := "/api/balances/shared/" + walletID
+	code, _ := sendJSON("POST", url+"/members", ownerToken, fmt.Sprintf(`{ "user_id": "%s", "role": "spender", "allowed_operations": ["withdraw"] }`, member.ID))
+	assert.Equal(t, 201, code, "test add basic member")
+	code, _ = sendJSON("POST", url+"/members/accept", token, "")
+	assert.Equal(t, 200, code, "test accept invitation")
+
+	assert.Equal(t, 200, topUpBalance(token, 60))
+	assert.Equal(t, 422, sendStepUp("POST", url+"/contribute", token, "123456", `{ "amount": 55 }`), "test contribute over basic max transfer")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/contribute", token, "123456", `{ "amount": 40 }`), "test contribute within basic limits")
+	assert.Equal(t, 422, sendStepUp("POST", url+"/contribute", token, "123456", `{ "amount": 20 }`), "test contribute over basic daily limit")
+
+	assert.Equal(t, 200, topUpBalance(token, 60))
+	assert.Equal(t, 422, sendStepUp("POST", url+"/withdraw", token, "123456", `{ "amount": 30 }`), "test withdraw over basic max balance")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/withdraw", token, "123456", `{ "amount": 20 }`), "test withdraw up to basic max balance")
+	_, amount := fetchBalance(t, token)
+	assert.Equal(t, float64(100), amount)
+}
+
+func TestPockets(t *testing.T) {
+	createUser(`{ "username": "pocketowner", "email": "pocketowner@gmail.com", "mobile_phone": "081273649620", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "pocketowner", "password": "secret-pass" }`)
+	assert.Equal(t, 200, topUpBalance(token, 50))
+
+	code, body := sendJSON("POST", "/api/balances/pockets", token, `{ "name": "holiday", "target_amount": 40 }`)
+	assert.Equal(t, 201, code, "test create pocket")
+	var resp struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	url := "/api/balances/pockets/" + resp.Data.ID
+	code, _ = sendJSON("POST", "/api/balances/pockets", token, `{ "name": "holiday" }`)
+	assert.Equal(t, 409, code, "test create pocket with duplicate name")
+
+	code, _ = sendJSON("POST", url+"/deposit", token, `{ "amount": 60 }`)
+	assert.Equal(t, 422, code, "test move more than main balance")
+	code, _ = sendJSON("POST", url+"/deposit", token, `{ "amount": 30 }`)
+	assert.Equal(t, 200, code, "test move to pocket")
+	_, amount := fetchBalance(t, token)
+	assert.Equal(t, float64(20), amount, "test main balance after move to pocket")
+
+	code, _ = sendJSON("POST", url+"/withdraw", token, `{ "amount": 10 }`)
+	assert.Equal(t, 200, code, "test move from pocket")
+	_, amount = fetchBalance(t, token)
+	assert.Equal(t, float64(30), amount, "test main balance after move from pocket")
+	code, body = sendJSON("GET", url, token, "")
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), `"balance":20`, "test pocket balance after moves")
+
+	code, _ = sendJSON("DELETE", url, token, "")
+	assert.Equal(t, 409, code, "test delete pocket with balance")
+	code, _ = sendJSON("POST", url+"/withdraw", token, `{ "amount": 20 }`)
+	assert.Equal(t, 200, code, "test empty pocket")
+	code, _ = sendJSON("DELETE", url, token, "")
+	assert.Equal(t, 200, code, "test delete empty pocket")
+
+	code, body = sendJSON("GET", "/api/balances/pockets", token, "")
+	assert.Equal(t, 200, code)
+	assert.NotContains(t, string(body), resp.Data.ID, "test deleted pocket is not listed")
+	code, _ = sendJSON("POST", url+"/deposit", token, `{ "amount": 1 }`)
+	assert.Equal(t, 403, code, "test move to deleted pocket")
+	_, amount = fetchBalance(t, token)
+	assert.Equal(t, float64(50), amount, "test main balance after pocket is deleted")
+}
+
+func TestSharedWalletPermissions(t *testing.T) {
+	owner := createUser(`{ "username": "permowner", "email": "permowner@gmail.com", "mobile_phone": "081273649630", "password": "secret-pass" }`)
+	viewer := createUser(`{ "username": "permviewer", "email": "permviewer@gmail.com", "mobile_phone": "081273649631", "password": "secret-pass" }`)
+	withdrawer := createUser(`{ "username": "permwithdrawer", "email": "permwithdrawer@gmail.com", "mobile_phone": "081273649632", "password": "secret-pass" }`)
+	spender := createUser(`{ "username": "permspender", "email": "permspender@gmail.com", "mobile_phone": "081273649633", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "permreceiver", "email": "permreceiver@gmail.com", "mobile_phone": "081273649634", "password": "secret-pass" }`)
+	setTier(t, owner.ID, "verified")
+	tokens := map[string]string{}
+	for _, member := range []struct{ username, phone string }{
+		{"permowner", "081273649630"},
+		{"permviewer", "081273649631"},
+		{"permwithdrawer", "081273649632"},
+		{"permspender", "081273649633"},
+		{"permreceiver", "081273649634"},
+	} {
+		token := loginUser(fmt.Sprintf(`{ "username_or_email": "%s", "password": "secret-pass" }`, member.username))
+		assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+		assert.Equal(t, 200, verifyPhone(token, member.phone, t))
+		tokens[member.username] = token
+	}
+
+	ownerToken := tokens["permowner"]
+	walletID := createSharedWallet(t, ownerToken, "permissions")
+	url := "/api/balances/shared/" + walletID
+	assert.Equal(t, 200, topUpBalance(ownerToken, 100))
+	code, _ := sendJSON("POST", url+"/contribute", ownerToken, `{ "amount": 100 }`)
+	assert.Equal(t, 403, code, "test contribute without step up")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/contribute", ownerToken, "123456", `{ "amount": 100 }`), "test contribute to shared wallet")
+	code, _ = sendJSON("POST", url+"/members", ownerToken, fmt.Sprintf(`{ "user_id": "%s", "role": "viewer" }`, viewer.ID))
+	assert.Equal(t, 201, code, "test add viewer")
+	code, _ = sendJSON("POST", url+"/members", ownerToken, fmt.Sprintf(`{ "user_id": "%s", "role": "spender", "allowed_operations": ["withdraw"] }`, withdrawer.ID))
+	assert.Equal(t, 201, code, "test add spender allowed to withdraw only")
+	code, _ = sendJSON("POST", url+"/members", ownerToken, fmt.Sprintf(`{ "user_id": "%s", "role": "spender", "spending_limit": 15, "allowed_operations": ["transfer"] }`, spender.ID))
+	assert.Equal(t, 201, code, "test add spender with spending limit")
+
+	code, body := sendJSON("GET", "/api/balances/shared/invitations", tokens["permspender"], "")
+	assert.Equal(t, 200, code)
+	assert.Contains(t, string(body), walletID, "test invitation is listed before accepting")
+	code, _ = sendJSON("GET", url, tokens["permspender"], "")
+	assert.Equal(t, 404, code, "test shared wallet is hidden before accepting")
+	assert.Equal(t, 404, sendStepUp("POST", url+"/transfer", tokens["permspender"], "123456", fmt.Sprintf(`{ "to_user_id": "%s", "amount": 5 }`, receiver.ID)), "test transfer before accepting")
+	for _, username := range []string{"permviewer", "permwithdrawer", "permspender"} {
+		code, _ = sendJSON("POST", url+"/members/accept", tokens[username], "")
+		assert.Equal(t, 200, code, "test accept invitation")
+	}
+	code, _ = sendJSON("POST", url+"/members/accept", tokens["permspender"], "")
+	assert.Equal(t, 409, code, "test accept invitation twice")
+	code, _ = sendJSON("POST", url+"/members", tokens["permspender"], fmt.Sprintf(`{ "user_id": "%s", "role": "viewer" }`, receiver.ID))
+	assert.Equal(t, 403, code, "test add member as non owner")
+
+	transfer := func(amount float64) string {
+		return fmt.Sprintf(`{ "to_user_id": "%s", "amount": %f }`, receiver.ID, amount)
+	}
+	assert.Equal(t, 404, sendStepUp("POST", url+"/transfer", tokens["permreceiver"], "123456", transfer(5)), "test transfer as non member")
+	assert.Equal(t, 403, sendStepUp("POST", url+"/transfer", tokens["permviewer"], "123456", transfer(5)), "test transfer as viewer")
+	assert.Equal(t, 403, sendStepUp("POST", url+"/withdraw", tokens["permviewer"], "123456", `{ "amount": 5 }`), "test withdraw as viewer")
+	assert.Equal(t, 403, sendStepUp("POST", url+"/transfer", tokens["permwithdrawer"], "123456", transfer(5)), "test transfer without transfer operation")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/withdraw", tokens["permwithdrawer"], "123456", `{ "amount": 5 }`), "test withdraw with withdraw operation")
+	assert.Equal(t, 403, sendStepUp("POST", url+"/withdraw", tokens["permspender"], "123456", `{ "amount": 5 }`), "test withdraw without withdraw operation")
+
+	assert.Equal(t, 200, sendStepUp("POST", url+"/transfer", tokens["permspender"], "123456", transfer(10)), "test transfer within spending limit")
+	assert.Equal(t, 422, sendStepUp("POST", url+"/transfer", tokens["permspender"], "123456", transfer(10)), "test transfer over spending limit")
+	assert.Equal(t, 200, sendStepUp("POST", url+"/transfer", tokens["permspender"], "123456", transfer(5)), "test transfer up to spending limit")
+	_, amount := fetchBalance(t, tokens["permreceiver"])
+	assert.Equal(t, float64(15), amount, "test receiver balance after shared transfers")
+}
+
+func TestFreezeAccount(t *testing.T) {
+	admin := createUser(`{ "username": "freezeadmin", "email": "freezeadmin@gmail.com", "mobile_phone": "081273649640", "password": "secret-pass" }`)
+	user := createUser(`{ "username": "freezeuser", "email": "freezeuser@gmail.com", "mobile_phone": "081273649641", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "freezereceiver", "email": "freezereceiver@gmail.com", "mobile_phone": "081273649642", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "admin")
+	adminToken := loginUser(`{ "username_or_email": "freezeadmin", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "freezeuser", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649641", t))
+	assert.Equal(t, 200, topUpBalance(token, 20))
+	balanceID, _ := fetchBalance(t, token)
+
+	balanceStatus := "/api/admin/balances/" + balanceID + "/status"
+	userStatus := "/api/admin/users/" + user.ID.String() + "/status"
+	transfer := fmt.Sprintf(`{ "to_user_id": "%s", "amount": 5 }`, receiver.ID)
+	code, _ := sendJSON("PUT", balanceStatus, token, `{ "status": "frozen", "reason": "suspicious activity" }`)
+	assert.Equal(t, 403, code, "test change status without admin role")
+
+	code, _ = sendJSON("PUT", balanceStatus, adminToken, `{ "status": "debit_frozen", "reason": "suspicious activity" }`)
+	assert.Equal(t, 200, code, "test debit freeze balance")
+	assert.Equal(t, 403, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer), "test transfer from debit frozen balance")
+	assert.Equal(t, 200, topUpBalance(token, 5), "test top up debit frozen balance")
+
+	code, _ = sendJSON("PUT", balanceStatus, adminToken, `{ "status": "frozen", "reason": "confirmed fraud" }`)
+	assert.Equal(t, 200, code, "test freeze balance")
+	code, _ = sendJSON("PUT", balanceStatus, adminToken, `{ "status": "frozen", "reason": "confirmed fraud" }`)
+	assert.Equal(t, 409, code, "test freeze already frozen balance")
+	assert.Equal(t, 403, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer), "test transfer from frozen balance")
+	assert.Equal(t, 403, topUpBalance(token, 5), "test top up frozen balance")
+
+	code, _ = sendJSON("PUT", balanceStatus, adminToken, `{ "status": "active", "reason": "fraud cleared" }`)
+	assert.Equal(t, 200, code, "test unfreeze balance")
+	assert.Equal(t, 200, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer), "test transfer from unfrozen balance")
+
+	code, _ = sendJSON("PUT", userStatus, adminToken, `{ "status": "frozen", "reason": "identity under review" }`)
+	assert.Equal(t, 200, code, "test freeze user")
+	assert.Equal(t, 403, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer), "test transfer of frozen user")
+	assert.Equal(t, 403, topUpBalance(token, 5), "test top up of frozen user")
+	code, _ = sendJSON("PUT", userStatus, adminToken, `{ "status": "active", "reason": "identity verified" }`)
+	assert.Equal(t, 200, code, "test unfreeze user")
+	assert.Equal(t, 200, topUpBalance(token, 5), "test top up of unfrozen user")
+
+	_, amount := fetchBalance(t, token)
+	assert.Equal(t, float64(25), amount, "test balance after freeze and unfreeze")
+	_, amount = fetchBalance(t, loginUser(`{ "username_or_email": "freezereceiver", "password": "secret-pass" }`))
+	assert.Equal(t, float64(5), amount, "test receiver balance after freeze and unfreeze")
+}
+
+// fetchHistories returns the latest main balance histories of the token owner matching the query
+func fetchHistories(t *testing.T, token, query string) (int, []map[string]interface{}) {
+	code, body := sendJSON("GET", "/api/balances/histories"+query, token, "")
+	var resp struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return code, resp.Data
+}
+
+func TestOverdraft(t *testing.T) {
+	admin := createUser(`{ "username": "overdraftadmin", "email": "overdraftadmin@gmail.com", "mobile_phone": "081273649690", "password": "secret-pass" }`)
+	user := createUser(`{ "username": "overdraftuser", "email": "overdraftuser@gmail.com", "mobile_phone": "081273649691", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "overdraftreceiver", "email": "overdraftreceiver@gmail.com", "mobile_phone": "081273649692", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "admin")
+	setTier(t, user.ID, "verified")
+	adminToken := loginUser(`{ "username_or_email": "overdraftadmin", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "overdraftuser", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649691", t))
+	assert.Equal(t, 200, topUpBalance(token, 20))
+
+	transfer := func(amount float64) string {
+		return fmt.Sprintf(`{ "to_user_id": "%s", "amount": %f }`, receiver.ID, amount)
+	}
+	assert.Equal(t, 422, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer(30)), "test spend beyond balance without overdraft")
+
+	overdraft := "/api/admin/balances/" + user.ID.String() + "/overdraft"
+	code, _ := sendJSON("PUT", overdraft, token, `{ "limit": 50, "rate": 0.01 }`)
+	assert.Equal(t, 403, code, "test set overdraft without admin role")
+	code, _ = sendJSON("PUT", overdraft, adminToken, `{ "limit": 50, "rate": 2 }`)
+	assert.Equal(t, 400, code, "test set overdraft with invalid rate")
+	code, _ = sendJSON("PUT", overdraft, adminToken, `{ "limit": 50, "rate": 0.01 }`)
+	assert.Equal(t, 200, code, "test set overdraft")
+
+	assert.Equal(t, 200, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer(60)), "test spend into overdraft")
+	_, amount := fetchBalance(t, token)
+	assert.Equal(t, float64(-40), amount, "test balance is negative within overdraft")
+	assert.Equal(t, 422, sendStepUp("POST", "/api/balances/transfer", token, "123456", transfer(15)), "test spend beyond overdraft limit")
+	_, amount = fetchBalance(t, token)
+	assert.Equal(t, float64(-40), amount, "test balance unchanged after refused spend")
+
+	// move the last accrual two days back so the next read accrues two days of interest
+	if _, err := db.Exec("UPDATE balances SET overdraft_accrued_at = overdraft_accrued_at - INTERVAL 2 DAY WHERE user_id=? AND kind='main'", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	code, body := sendJSON("GET", "/api/balances", token, "")
+	assert.Equal(t, 200, code)
+	var resp struct {
+		Data struct {
+			Balance           float64 `json:"balance"`
+			OverdraftInterest float64 `json:"overdraft_interest"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	assert.InDelta(t, 0.8, resp.Data.OverdraftInterest, 0.0001, "test interest accrues daily on negative balance")
+
+	assert.Equal(t, 200, topUpBalance(token, 10), "test credit into overdraft")
+	code, body = sendJSON("GET", "/api/balances", token, "")
+	assert.Equal(t, 200, code)
+	json.Unmarshal(body, &resp)
+	assert.InDelta(t, -30.8, resp.Data.Balance, 0.0001, "test credit pays off interest before principal")
+	assert.Equal(t, float64(0), resp.Data.OverdraftInterest, "test interest repaid")
+
+	code, fees := fetchHistories(t, token, "?category=fee")
+	assert.Equal(t, 200, code)
+	if assert.Len(t, fees, 1, "test interest repayment recorded as fee") {
+		assert.InDelta(t, -30, fees[0]["balance_before"], 0.0001)
+		assert.InDelta(t, -30.8, fees[0]["balance_after"], 0.0001)
+	}
+}
+
+func TestBalanceHistoriesCategory(t *testing.T) {
+	createUser(`{ "username": "historyuser", "email": "historyuser@gmail.com", "mobile_phone": "081273649700", "password": "secret-pass" }`)
+	receiver := createUser(`{ "username": "historyreceiver", "email": "historyreceiver@gmail.com", "mobile_phone": "081273649701", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "historyuser", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, verifyPhone(token, "081273649700", t))
+	assert.Equal(t, 200, topUpBalance(token, 20))
+	assert.Equal(t, 200, sendStepUp("POST", "/api/balances/transfer", token, "123456", fmt.Sprintf(`{ "to_user_id": "%s", "amount": 5 }`, receiver.ID)))
+
+	code, histories := fetchHistories(t, token, "?category=topup")
+	assert.Equal(t, 200, code, "test filter histories by category")
+	if assert.Len(t, histories, 1) {
+		assert.Equal(t, "topup", histories[0]["category"])
+		assert.Equal(t, "credit", histories[0]["type"])
+	}
+
+	code, histories = fetchHistories(t, token, "?category=topup,transfer_out")
+	assert.Equal(t, 200, code, "test filter histories by many categories")
+	types := map[interface{}]interface{}{}
+	for _, history := range histories {
+		types[history["category"]] = history["type"]
+	}
+	assert.Equal(t, map[interface{}]interface{}{"topup": "credit", "transfer_out": "debit"}, types)
+	assert.Len(t, histories, 2)
+
+	code, histories = fetchHistories(t, token, "?category=refund")
+	assert.Equal(t, 200, code)
+	assert.Len(t, histories, 0, "test filter histories without match")
+
+	code, histories = fetchHistories(t, token, "")
+	assert.Equal(t, 200, code, "test histories without filter")
+	categories := make([]interface{}, 0)
+	for _, history := range histories {
+		categories = append(categories, history["category"])
+	}
+	assert.Subset(t, categories, []interface{}{"topup", "transfer_out"})
+
+	code, _ = fetchHistories(t, token, "?category=bonus")
+	assert.Equal(t, 400, code, "test filter histories by unknown category")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type dataExport struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	DownloadURL string `json:"download_url"`
+}
+
+func fetchExport(token, id string) (int, dataExport) {
+	code, body := sendJSON("GET", "/api/users/export/"+id, token, "")
+	var resp struct {
+		Data dataExport `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return code, resp.Data
+}
+
+// download fetches the path and query of the download link, the app under test has no host
+func download(link string) (int, []byte) {
+	u, err := url.Parse(link)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error parse download url"))
+	}
+	req, _ := http.NewRequest("GET", u.RequestURI(), nil)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error download export"))
+	}
+	return res.StatusCode, GetBody(res.Body)
+}
+
+func TestDataExport(t *testing.T) {
+	createUser(`{ "username": "exportuser", "email": "exportuser@gmail.com", "mobile_phone": "081273649580", "password": "secret-pass" }`)
+	createUser(`{ "username": "exportother", "email": "exportother@gmail.com", "mobile_phone": "081273649581", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "exportuser", "password": "secret-pass" }`)
+	otherToken := loginUser(`{ "username_or_email": "exportother", "password": "secret-pass" }`)
+	assert.Equal(t, 200, topUpBalance(token, 10), "test top up before export")
+
+	code, body := sendJSON("POST", "/api/users/export", token, "")
+	assert.Equal(t, 202, code, "test request export")
+	var resp struct {
+		Data dataExport `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	assert.Equal(t, "pending", resp.Data.Status, "test requested export is pending")
+
+	code, _ = fetchExport(otherToken, resp.Data.ID)
+	assert.Equal(t, 404, code, "test get export of other user")
+
+	var export dataExport
+	for i := 0; i < 50; i++ {
+		_, export = fetchExport(token, resp.Data.ID)
+		if export.Status != "pending" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, "ready", export.Status, "test export is built")
+	assert.NotEmpty(t, export.DownloadURL, "test ready export has download url")
+
+	code, content := download(export.DownloadURL)
+	assert.Equal(t, 200, code, "test download export")
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err, "test download is zip archive")
+	names := map[string]bool{}
+	if archive != nil {
+		for _, f := range archive.File {
+			names[f.Name] = true
+		}
+	}
+	for _, name := range []string{"profile.json", "profile.csv", "balances.json", "balance_histories.csv", "logins.json", "logins.csv"} {
+		assert.True(t, names[name], "test archive contains "+name)
+	}
+
+	code, _ = download("/api/users/export/" + export.ID + "/download?token=invalid")
+	assert.Equal(t, 403, code, "test download with invalid token")
+}
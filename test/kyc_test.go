@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"testing"
+)
+
+// pngContent starts with png signature so the content type is detected as image/png
+var pngContent = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+type kycSubmission struct {
+	ID        string `json:"id"`
+	Tier      string `json:"tier"`
+	Status    string `json:"status"`
+	Documents []struct {
+		ID          string `json:"id"`
+		Type        string `json:"type"`
+		ContentType string `json:"content_type"`
+	} `json:"documents"`
+}
+
+// submitKYC uploads the documents, keyed by document type, as multipart form
+func submitKYC(token, tier string, documents map[string][]byte) (int, kycSubmission) {
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	w.WriteField("tier", tier)
+	for t, content := range documents {
+		part, _ := w.CreateFormFile(t, t+".png")
+		part.Write(content)
+	}
+	w.Close()
+
+	req, _ := http.NewRequest("POST", "/api/users/kyc", body)
+	req.Header.Add("Content-Type", w.FormDataContentType())
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error submit kyc"))
+	}
+	var resp struct {
+		Data kycSubmission `json:"data"`
+	}
+	json.Unmarshal(GetBody(res.Body), &resp)
+	return res.StatusCode, resp.Data
+}
+
+func kycTier(token string) string {
+	_, body := sendJSON("GET", "/api/users/kyc", token, "")
+	var resp struct {
+		Data struct {
+			Tier string `json:"tier"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return resp.Data.Tier
+}
+
+func TestKYC(t *testing.T) {
+	admin := createUser(`{ "username": "kycadmin", "email": "kycadmin@gmail.com", "mobile_phone": "081273649570", "password": "secret-pass" }`)
+	createUser(`{ "username": "kycuser", "email": "kycuser@gmail.com", "mobile_phone": "081273649571", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "admin")
+	adminToken := loginUser(`{ "username_or_email": "kycadmin", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "kycuser", "password": "secret-pass" }`)
+
+	assert.Equal(t, "basic", kycTier(token), "test registered user is basic")
+	assert.Equal(t, 200, topUpBalance(token, 100), "test top up up to basic limit")
+	assert.Equal(t, 422, topUpBalance(token, 1), "test top up over basic limit")
+
+	code, _ := submitKYC(token, "verified", map[string][]byte{"id_card": pngContent})
+	assert.Equal(t, 400, code, "test submit without selfie")
+	code, _ = submitKYC(token, "verified", map[string][]byte{"id_card": []byte("plain text"), "selfie": pngContent})
+	assert.Equal(t, 400, code, "test submit document of unsupported type")
+
+	code, submission := submitKYC(token, "verified", map[string][]byte{"id_card": pngContent, "selfie": pngContent})
+	assert.Equal(t, 201, code, "test submit kyc")
+	assert.Equal(t, "pending", submission.Status, "test submission is pending")
+	code, _ = submitKYC(token, "verified", map[string][]byte{"passport": pngContent, "selfie": pngContent})
+	assert.Equal(t, 409, code, "test submit while pending")
+
+	code, _ = sendJSON("GET", "/api/admin/kyc", token, "")
+	assert.Equal(t, 403, code, "test customer can not review kyc")
+	code, body := sendJSON("GET", "/api/admin/kyc", adminToken, "")
+	assert.Equal(t, 200, code, "test fetch review queue")
+	assert.Contains(t, string(body), submission.ID, "test review queue contains submission")
+
+	url := fmt.Sprintf("/api/admin/kyc/%s", submission.ID)
+	code, body = sendJSON("GET", url, adminToken, "")
+	assert.Equal(t, 200, code, "test get submission")
+	var detail struct {
+		Data kycSubmission `json:"data"`
+	}
+	json.Unmarshal(body, &detail)
+	assert.Len(t, detail.Data.Documents, 2, "test submission has documents")
+	if len(detail.Data.Documents) > 0 {
+		assert.Equal(t, "image/png", detail.Data.Documents[0].ContentType, "test content type detected")
+		req, _ := http.NewRequest("GET", url+"/documents/"+detail.Data.Documents[0].ID, nil)
+		req.Header.Add("Authorization", "Bearer "+adminToken)
+		res, err := app.Test(req, -1)
+		assert.NoError(t, err, "test get document")
+		assert.Equal(t, 200, res.StatusCode, "test get document")
+		content, _ := ioutil.ReadAll(res.Body)
+		assert.Equal(t, pngContent, content, "test document content")
+	}
+
+	code, _ = sendJSON("POST", url+"/review", adminToken, `{ "status": "rejected" }`)
+	assert.Equal(t, 400, code, "test reject without note")
+	code, _ = sendJSON("POST", url+"/review", adminToken, `{ "status": "resubmission_required", "note": "selfie is blurry" }`)
+	assert.Equal(t, 200, code, "test ask for resubmission")
+	assert.Contains(t, lastMessage(t, "kycuser@gmail.com").Body, "selfie is blurry", "test user notified")
+	code, _ = sendJSON("POST", url+"/review", adminToken, `{ "status": "approved" }`)
+	assert.Equal(t, 409, code, "test review twice")
+	assert.Equal(t, "basic", kycTier(token), "test tier kept until approved")
+
+	code, submission = submitKYC(token, "verified", map[string][]byte{"passport": pngContent, "selfie": pngContent})
+	assert.Equal(t, 201, code, "test resubmit kyc")
+	code, _ = sendJSON("POST", fmt.Sprintf("/api/admin/kyc/%s/review", submission.ID), adminToken, `{ "status": "approved" }`)
+	assert.Equal(t, 200, code, "test approve kyc")
+	assert.Equal(t, "verified", kycTier(token), "test approval raises tier")
+	assert.Equal(t, 200, topUpBalance(token, 1), "test top up within verified limit")
+
+	code, _ = submitKYC(token, "verified", map[string][]byte{"passport": pngContent, "selfie": pngContent})
+	assert.Equal(t, 409, code, "test submit for current tier")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type loginAttempt struct {
+	Success   bool    `json:"success"`
+	Reason    *string `json:"reason"`
+	UserAgent string  `json:"user_agent"`
+}
+
+// loginFrom logs in with the user agent, returns the access token or empty when refused
+func loginFrom(userAgent, request string) string {
+	req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("User-Agent", userAgent)
+	req.Header.Add(ipHeader, clientIP(request))
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error login user"))
+	}
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	json.Unmarshal(GetBody(res.Body), &resp)
+	return resp.Data.Token
+}
+
+// confirmNotMe posts the token of "this wasn't me" link as the confirmation form does
+func confirmNotMe(link string) int {
+	u, err := url.Parse(link)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error parse not me url"))
+	}
+	form := url.Values{"token": {u.Query().Get("token")}}
+	req, _ := http.NewRequest("POST", u.Path, strings.NewReader(form.Encode()))
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error confirm not me"))
+	}
+	return res.StatusCode
+}
+
+func fetchLogins(token string) (int, []loginAttempt) {
+	code, body := sendJSON("GET", "/api/users/logins", token, "")
+	var resp struct {
+		Data []loginAttempt `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return code, resp.Data
+}
+
+func TestLoginHistory(t *testing.T) {
+	createUser(`{ "username": "loginhistory", "email": "loginhistory@gmail.com", "mobile_phone": "081273649595", "password": "secret-pass" }`)
+	credential := `{ "username_or_email": "loginhistory", "password": "secret-pass" }`
+
+	token := loginFrom("Laptop/1.0", credential)
+	assert.NotEmpty(t, token, "test first login")
+	assert.Empty(t, loginFrom("Laptop/1.0", `{ "username_or_email": "loginhistory", "password": "wrong-pass" }`), "test failed login")
+	assert.Empty(t, loginFrom("Laptop/1.0", `{ "username_or_email": "loginnobody", "password": "wrong-pass" }`), "test failed login of unknown account")
+	var unknown int
+	if err := db.QueryRow("SELECT COUNT(*) FROM login_attempts WHERE identifier=? AND user_id IS NULL", "loginnobody").Scan(&unknown); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, unknown, "test failed login of unknown account recorded")
+	assert.NotEmpty(t, loginFrom("Laptop/1.0", credential), "test login from known device")
+	msg := lastMessage(t, "loginhistory@gmail.com")
+	assert.NotEqual(t, "New sign-in to your account", msg.Subject, "test no alert for first or known device")
+
+	assert.NotEmpty(t, loginFrom("Phone/2.0", credential), "test login from new device")
+	msg = lastMessage(t, "loginhistory@gmail.com")
+	assert.Equal(t, "New sign-in to your account", msg.Subject, "test alert for new device")
+	assert.Contains(t, msg.Body, "Phone/2.0", "test alert describes the device")
+
+	code, logins := fetchLogins(token)
+	assert.Equal(t, 200, code, "test fetch login history")
+	if assert.Len(t, logins, 4, "test every attempt recorded") {
+		assert.Equal(t, "Phone/2.0", logins[0].UserAgent, "test latest attempt first")
+		assert.True(t, logins[0].Success, "test successful attempt")
+		assert.False(t, logins[2].Success, "test failed attempt")
+		assert.NotNil(t, logins[2].Reason, "test failed attempt has reason")
+	}
+	code, _ = fetchLogins("")
+	assert.Equal(t, 400, code, "test fetch login history without token")
+
+	link := regexp.MustCompile(`http\S+/not-me\?token=\S+`).FindString(msg.Body)
+	assert.NotEmpty(t, link, "test alert has not me link")
+	code, page := download(link)
+	assert.Equal(t, 200, code, "test not me confirmation")
+	assert.Contains(t, string(page), `method="post"`, "test not me confirmation posts the token")
+	code, _ = fetchSessions(token)
+	assert.Equal(t, 200, code, "test opening not me link revokes nothing")
+	code = confirmNotMe(regexp.MustCompile(`token=\S+`).ReplaceAllString(link, "token=invalid"))
+	assert.Equal(t, 403, code, "test not me with invalid token")
+	code = confirmNotMe(link)
+	assert.Equal(t, 200, code, "test not me")
+	code, _ = fetchSessions(token)
+	assert.Equal(t, 401, code, "test sessions revoked after not me")
+	code = confirmNotMe(link)
+	assert.Equal(t, 403, code, "test not me link works once")
+}
@@ -3,23 +3,74 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	_accountRepository "github.com/fajardm/ewallet-example/app/account/repository/mysql"
+	_auditHttp "github.com/fajardm/ewallet-example/app/audit/http"
+	_auditRepository "github.com/fajardm/ewallet-example/app/audit/repository/mysql"
+	_auditUsecase "github.com/fajardm/ewallet-example/app/audit/usecase"
+	_authHttp "github.com/fajardm/ewallet-example/app/auth/http"
+	_authRepository "github.com/fajardm/ewallet-example/app/auth/repository/mysql"
+	_authUsecase "github.com/fajardm/ewallet-example/app/auth/usecase"
+	_balanceHttp "github.com/fajardm/ewallet-example/app/balance/http"
+	_balanceModel "github.com/fajardm/ewallet-example/app/balance/model"
 	_balanceRepository "github.com/fajardm/ewallet-example/app/balance/repository/mysql"
+	_balanceUsecase "github.com/fajardm/ewallet-example/app/balance/usecase"
+	"github.com/fajardm/ewallet-example/app/base"
+	_exportHttp "github.com/fajardm/ewallet-example/app/export/http"
+	_exportModel "github.com/fajardm/ewallet-example/app/export/model"
+	_exportRepository "github.com/fajardm/ewallet-example/app/export/repository/mysql"
+	_exportUsecase "github.com/fajardm/ewallet-example/app/export/usecase"
+	_kycHttp "github.com/fajardm/ewallet-example/app/kyc/http"
+	_kycModel "github.com/fajardm/ewallet-example/app/kyc/model"
+	_kycRepository "github.com/fajardm/ewallet-example/app/kyc/repository/mysql"
+	_kycUsecase "github.com/fajardm/ewallet-example/app/kyc/usecase"
+	_loginHttp "github.com/fajardm/ewallet-example/app/login/http"
+	_loginModel "github.com/fajardm/ewallet-example/app/login/model"
+	_loginRepository "github.com/fajardm/ewallet-example/app/login/repository/mysql"
+	_loginUsecase "github.com/fajardm/ewallet-example/app/login/usecase"
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	_otpRepository "github.com/fajardm/ewallet-example/app/otp/repository/mysql"
+	_otpUsecase "github.com/fajardm/ewallet-example/app/otp/usecase"
+	_pinHttp "github.com/fajardm/ewallet-example/app/pin/http"
+	_pinModel "github.com/fajardm/ewallet-example/app/pin/model"
+	_pinRepository "github.com/fajardm/ewallet-example/app/pin/repository/mysql"
+	_pinUsecase "github.com/fajardm/ewallet-example/app/pin/usecase"
+	_recoveryHttp "github.com/fajardm/ewallet-example/app/recovery/http"
+	_recoveryUsecase "github.com/fajardm/ewallet-example/app/recovery/usecase"
+	_roleHttp "github.com/fajardm/ewallet-example/app/role/http"
+	_roleRepository "github.com/fajardm/ewallet-example/app/role/repository/mysql"
+	_roleUsecase "github.com/fajardm/ewallet-example/app/role/usecase"
+	_twoFactorHttp "github.com/fajardm/ewallet-example/app/twofactor/http"
+	_twoFactorRepository "github.com/fajardm/ewallet-example/app/twofactor/repository/mysql"
+	_twoFactorUsecase "github.com/fajardm/ewallet-example/app/twofactor/usecase"
 	_userHttp "github.com/fajardm/ewallet-example/app/user/http"
+	_userModel "github.com/fajardm/ewallet-example/app/user/model"
 	_userRepository "github.com/fajardm/ewallet-example/app/user/repository/mysql"
 	_userUsecase "github.com/fajardm/ewallet-example/app/user/usecase"
+	_verificationHttp "github.com/fajardm/ewallet-example/app/verification/http"
+	_verificationModel "github.com/fajardm/ewallet-example/app/verification/model"
+	_verificationUsecase "github.com/fajardm/ewallet-example/app/verification/usecase"
+	"github.com/fajardm/ewallet-example/blob"
 	"github.com/fajardm/ewallet-example/bootstrap"
 	"github.com/fajardm/ewallet-example/database"
+	"github.com/fajardm/ewallet-example/middleware"
+	"github.com/fajardm/ewallet-example/notifier"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 var app *bootstrap.Bootstrap
+var db *database.MySQL
+
+// outbox is the file every email and sms sent during test is written to
+var outbox string
 
 func GetBody(r io.Reader) []byte {
 	body, err := ioutil.ReadAll(r)
@@ -35,6 +86,8 @@ func TestMain(m *testing.M) {
 		log.Fatal(errors.Wrap(err, "Fatal error config file"))
 	}
 
+	// Paths in the configuration are relative to the project root
+	viper.Set("PASSWORD.BREACHED_LIST", "../database/breached_passwords.txt")
 	contextTimeout := viper.GetDuration("CONTEXT_TIMEOUT")
 
 	dbUser := viper.GetString("DATABASE.USER")
@@ -72,7 +125,7 @@ func TestMain(m *testing.M) {
 			}
 		}
 	}
-	db := &database.MySQL{DB: conn}
+	db = &database.MySQL{DB: conn}
 	defer func() {
 		err := db.Close()
 		if err != nil {
@@ -80,16 +133,121 @@ func TestMain(m *testing.M) {
 		}
 	}()
 
+	f, err := ioutil.TempFile("", "outbox")
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error create outbox"))
+	}
+	f.Close()
+	outbox = f.Name()
+	defer os.Remove(outbox)
+	notifier.Use(notifier.Email, notifier.NewFileSender(outbox))
+	notifier.Use(notifier.SMS, notifier.NewFileSender(outbox))
+
+	storage, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error create blob storage"))
+	}
+	defer os.RemoveAll(storage)
+	blob.Use(blob.NewFileStore(storage))
+
 	app = bootstrap.New(viper.GetString("APP_NAME"), viper.GetString("APP_OWNER"))
 	app.Bootstrap()
 
+	// Register audit handler, it is the auditor of every usecase recording events
+	auditRepository := _auditRepository.NewAuditRepository(db)
+	auditUsecase := _auditUsecase.NewAuditUsecase(auditRepository, contextTimeout)
+	_auditHttp.NewAuditHandler(app, auditUsecase)
+
 	// Register balance handler
 	balanceRepository := _balanceRepository.NewBalanceRepository(db)
+	balanceUsecase := _balanceUsecase.NewBalanceUsecase(balanceRepository, auditUsecase, _balanceModel.Limits{
+		Tiers: map[base.Tier]_balanceModel.TierLimit{
+			base.Basic:    {MaxBalance: 100, MaxTransfer: 50, DailyTransfer: 60},
+			base.Verified: {MaxBalance: 1000, MaxTransfer: 500, DailyTransfer: 600},
+		},
+		SharedWalletTier: base.Verified,
+	}, contextTimeout)
+	_balanceHttp.NewBalanceHandler(app, balanceUsecase)
 
-	// Register user handler
+	// Register auth handler
 	userRepository := _userRepository.NewUserRepository(db)
-	userUsecase := _userUsecase.NewUserUsecase(userRepository, balanceRepository, contextTimeout)
-	_userHttp.NewUserHandler(app, userUsecase)
+	authRepository := _authRepository.NewAuthRepository(db)
+	roleRepository := _roleRepository.NewRoleRepository(db)
+	authUsecase := _authUsecase.NewAuthUsecase(authRepository, userRepository, roleRepository, contextTimeout)
+	_authHttp.NewAuthHandler(app, authUsecase)
+	middleware.UseSessionChecker(authUsecase)
+
+	// Register two factor handler
+	twoFactorRepository := _twoFactorRepository.NewTwoFactorRepository(db)
+	twoFactorUsecase := _twoFactorUsecase.NewTwoFactorUsecase(twoFactorRepository, userRepository, viper.GetString("APP_NAME"), contextTimeout)
+	_twoFactorHttp.NewTwoFactorHandler(app, twoFactorUsecase)
+
+	// Register verification handler
+	otpRepository := _otpRepository.NewOTPRepository(db)
+	otpUsecase := _otpUsecase.NewOTPUsecase(otpRepository, _otpModel.Policy{
+		Length:      viper.GetInt("OTP.LENGTH"),
+		TTL:         viper.GetDuration("OTP.TTL"),
+		MaxAttempts: viper.GetInt("OTP.MAX_ATTEMPTS"),
+		Cooldown:    viper.GetDuration("OTP.COOLDOWN"),
+		MaxPerHour:  viper.GetInt("OTP.MAX_PER_HOUR"),
+	}, contextTimeout)
+	verificationUsecase := _verificationUsecase.NewVerificationUsecase(userRepository, otpUsecase, _verificationModel.Policy{
+		BaseURL:       viper.GetString("APP_URL"),
+		EmailLinkTTL:  viper.GetDuration("VERIFICATION.EMAIL_LINK_TTL"),
+		EmailCooldown: viper.GetDuration("VERIFICATION.EMAIL_COOLDOWN"),
+	}, contextTimeout)
+	_verificationHttp.NewVerificationHandler(app, verificationUsecase)
+	middleware.UseVerificationChecker(verificationUsecase)
+
+	// Register recovery handler
+	recoveryUsecase := _recoveryUsecase.NewRecoveryUsecase(userRepository, otpUsecase, authUsecase, auditUsecase, contextTimeout)
+	_recoveryHttp.NewRecoveryHandler(app, recoveryUsecase)
+
+	// Register login handler
+	loginRepository := _loginRepository.NewLoginRepository(db)
+	loginUsecase := _loginUsecase.NewLoginUsecase(loginRepository, userRepository, authUsecase, auditUsecase, _loginModel.Policy{
+		BaseURL:  viper.GetString("APP_URL"),
+		IPHeader: ipHeader,
+	}, contextTimeout)
+	_loginHttp.NewLoginHandler(app, loginUsecase)
+
+	// Register user handler
+	userUsecase := _userUsecase.NewUserUsecase(userRepository, balanceRepository, roleRepository, auditUsecase, _userModel.LoginPolicy{
+		MaxFailures:    viper.GetInt("LOGIN.MAX_FAILURES"),
+		LockDuration:   viper.GetDuration("LOGIN.LOCK_DURATION"),
+		BackoffAfter:   viper.GetInt("LOGIN.BACKOFF_AFTER"),
+		BackoffBase:    viper.GetDuration("LOGIN.BACKOFF_BASE"),
+		BackoffMax:     viper.GetDuration("LOGIN.BACKOFF_MAX"),
+		IPMaxFailures:  viper.GetInt("LOGIN.IP_MAX_FAILURES"),
+		IPBackoffAfter: viper.GetInt("LOGIN.IP_BACKOFF_AFTER"),
+	}, contextTimeout)
+	_userHttp.NewUserHandler(app, userUsecase, authUsecase, twoFactorUsecase, verificationUsecase, loginUsecase)
+
+	// Register role handler
+	roleUsecase := _roleUsecase.NewRoleUsecase(roleRepository, userRepository, authUsecase, auditUsecase, contextTimeout)
+	_roleHttp.NewRoleHandler(app, roleUsecase)
+
+	// Register pin handler
+	pinRepository := _pinRepository.NewPINRepository(db)
+	pinUsecase := _pinUsecase.NewPINUsecase(pinRepository, userUsecase, _pinModel.Policy{
+		MaxAttempts:  viper.GetInt("PIN.MAX_ATTEMPTS"),
+		LockDuration: viper.GetDuration("PIN.LOCK_DURATION"),
+		StepUpTTL:    viper.GetDuration("PIN.STEP_UP_TTL"),
+	}, contextTimeout)
+	_pinHttp.NewPINHandler(app, pinUsecase, twoFactorUsecase)
+
+	// Register kyc handler
+	kycRepository := _kycRepository.NewKYCRepository(db)
+	kycUsecase := _kycUsecase.NewKYCUsecase(kycRepository, userRepository, auditUsecase, _kycModel.Policy{
+		RejectionCooldown: time.Hour,
+	}, contextTimeout)
+	_kycHttp.NewKYCHandler(app, kycUsecase)
+
+	// Register export handler
+	exportUsecase := _exportUsecase.NewExportUsecase(_exportRepository.NewExportRepository(db), userRepository, balanceRepository, authRepository, kycRepository, _accountRepository.NewAccountRepository(db), auditRepository, loginRepository, _exportModel.Policy{
+		BaseURL: viper.GetString("APP_URL"),
+	}, contextTimeout)
+	_exportHttp.NewExportHandler(app, exportUsecase)
 
 	m.Run()
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	_otpModel "github.com/fajardm/ewallet-example/app/otp/model"
+	_otpRepository "github.com/fajardm/ewallet-example/app/otp/repository/mysql"
+	_otpUsecase "github.com/fajardm/ewallet-example/app/otp/usecase"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/notifier"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// lastMessage returns the last message written to the outbox for the recipient
+func lastMessage(t *testing.T, to string) notifier.Message {
+	f, err := os.Open(outbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var res notifier.Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		msg := notifier.Message{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.To == to {
+			res = msg
+		}
+	}
+	if res.To == "" {
+		t.Fatalf("no message sent to %s", to)
+	}
+	return res
+}
+
+// waitMessage waits for message with the subject delivered in the background to the recipient
+func waitMessage(t *testing.T, to, subject string) notifier.Message {
+	for i := 0; i < 50; i++ {
+		if msg := lastMessage(t, to); msg.Subject == subject {
+			return msg
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("no %s sent to %s", subject, to)
+	return notifier.Message{}
+}
+
+// lastCode returns the code of the last message written to the outbox for the recipient
+func lastCode(t *testing.T, to string) string {
+	return codePattern.FindString(lastMessage(t, to).Body)
+}
+
+func TestOTP(t *testing.T) {
+	ctx := context.Background()
+	usecase := _otpUsecase.NewOTPUsecase(_otpRepository.NewOTPRepository(db), _otpModel.Policy{
+		MaxAttempts: 3,
+		Cooldown:    time.Second,
+		MaxPerHour:  3,
+	}, time.Second*3)
+
+	sent, err := usecase.Issue(ctx, _otpModel.VerifyEmail, notifier.Email, " OTP@Example.com ")
+	assert.NoError(t, err, "test issue otp")
+	assert.NotNil(t, sent, "test issue otp")
+	code := lastCode(t, "otp@example.com")
+	assert.Len(t, code, 6, "test otp delivered to normalized destination")
+
+	_, err = usecase.Issue(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com")
+	assert.Equal(t, errorcode.ErrTooManyAttempts, err, "test issue otp within cooldown")
+
+	err = usecase.Verify(ctx, _otpModel.VerifyPhone, notifier.Email, "otp@example.com", code)
+	assert.Equal(t, errorcode.ErrInvalidCredential, err, "test verify otp of other purpose")
+	assert.NoError(t, usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", code), "test verify otp")
+	err = usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", code)
+	assert.Equal(t, errorcode.ErrInvalidCredential, err, "test verify consumed otp")
+
+	time.Sleep(time.Second * 2)
+	_, err = usecase.Issue(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com")
+	assert.NoError(t, err, "test issue otp after cooldown")
+	code = lastCode(t, "otp@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	err = usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", wrong)
+	assert.Equal(t, errorcode.ErrInvalidCredential, err, "test verify wrong otp")
+	usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", wrong)
+	err = usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", wrong)
+	assert.Equal(t, errorcode.ErrTooManyAttempts, err, "test verify wrong otp on last attempt")
+	err = usecase.Verify(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com", code)
+	assert.Equal(t, errorcode.ErrTooManyAttempts, err, "test verify otp after attempts exhausted")
+
+	time.Sleep(time.Second * 2)
+	_, err = usecase.Issue(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com")
+	assert.NoError(t, err, "test issue third otp")
+	time.Sleep(time.Second * 2)
+	_, err = usecase.Issue(ctx, _otpModel.VerifyEmail, notifier.Email, "otp@example.com")
+	assert.Equal(t, errorcode.ErrTooManyAttempts, err, "test issue otp over hourly limit")
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func setPIN(token, request string) int {
+	req, _ := http.NewRequest("POST", "/api/users/pin", bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error set pin"))
+	}
+	return res.StatusCode
+}
+
+func verifyPIN(token, pin string) (int, string) {
+	req, _ := http.NewRequest("POST", "/api/users/pin/verify", bytes.NewBufferString(fmt.Sprintf(`{ "pin": "%s" }`, pin)))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error verify pin"))
+	}
+	body, err := ioutil.ReadAll(res.Body)
+
+	var resp struct {
+		Data struct {
+			StepUpToken string `json:"step_up_token"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal step up"))
+	}
+
+	return res.StatusCode, resp.Data.StepUpToken
+}
+
+func TestSetPIN(t *testing.T) {
+	createUser(`{ "username": "mysterio", "email": "mysterio@gmail.com", "mobile_phone": "081273649512", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "mysterio", "password": "secret-pass" }`)
+
+	cases := []struct {
+		description  string
+		request      string
+		expectedCode int
+	}{
+		{
+			description:  "test with non numeric pin",
+			request:      `{ "pin": "abcdef", "password": "secret-pass" }`,
+			expectedCode: 400,
+		},
+		{
+			description:  "test with short pin",
+			request:      `{ "pin": "1234", "password": "secret-pass" }`,
+			expectedCode: 400,
+		},
+		{
+			description:  "test with wrong password",
+			request:      `{ "pin": "123456", "password": "wrong" }`,
+			expectedCode: 403,
+		},
+		{
+			description:  "test with valid json",
+			request:      `{ "pin": "123456", "password": "secret-pass" }`,
+			expectedCode: 201,
+		},
+		{
+			description:  "test with pin already set",
+			request:      `{ "pin": "654321", "password": "secret-pass" }`,
+			expectedCode: 409,
+		},
+	}
+
+	for _, test := range cases {
+		assert.Equal(t, test.expectedCode, setPIN(token, test.request), test.description)
+	}
+}
+
+func TestVerifyPIN(t *testing.T) {
+	createUser(`{ "username": "kane", "email": "kane@gmail.com", "mobile_phone": "081273649513", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "kane", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+
+	code, stepUp := verifyPIN(token, "123456")
+	assert.Equal(t, 200, code, "test with valid pin")
+	assert.NotEmpty(t, stepUp, "test with valid pin")
+
+	for i := 1; i < 5; i++ {
+		code, _ = verifyPIN(token, "000000")
+		assert.Equal(t, 403, code, "test with wrong pin")
+	}
+	code, _ = verifyPIN(token, "000000")
+	assert.Equal(t, 429, code, "test lockout after too many wrong pin")
+
+	code, _ = verifyPIN(token, "123456")
+	assert.Equal(t, 429, code, "test valid pin while locked out")
+}
+
+func TestResetPINThrottle(t *testing.T) {
+	createUser(`{ "username": "resetpin", "email": "resetpin@gmail.com", "mobile_phone": "081273649650", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "resetpin", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+
+	for i := 0; i < 4; i++ {
+		code, _ := sendJSON("POST", "/api/users/pin/reset", token, `{ "password": "wrong-pass", "new_pin": "654321" }`)
+		assert.Equal(t, 403, code, "test reset pin with wrong password")
+	}
+	code, _ := sendJSON("POST", "/api/users/pin/reset", token, `{ "password": "secret-pass", "new_pin": "654321" }`)
+	assert.Equal(t, 429, code, "test reset pin during backoff")
+	code, _ = loginStatus(`{ "username_or_email": "resetpin", "password": "secret-pass" }`)
+	assert.Equal(t, 429, code, "test wrong password on reset pin counts toward login lockout")
+	code, _ = verifyPIN(token, "123456")
+	assert.Equal(t, 200, code, "test pin is kept")
+}
+
+func TestResetPINTwoFactorThrottle(t *testing.T) {
+	createUser(`{ "username": "resetpin2fa", "email": "resetpin2fa@gmail.com", "mobile_phone": "081273649651", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "resetpin2fa", "password": "secret-pass" }`)
+	assert.Equal(t, 201, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	recoveryCodes := enableTwoFactor(t, token)
+
+	for i := 0; i < 5; i++ {
+		code, _ := sendJSON("POST", "/api/users/pin/reset", token, `{ "password": "secret-pass", "new_pin": "654321", "code": "000000" }`)
+		assert.Equal(t, 403, code, "test reset pin with wrong code")
+	}
+	code, _ := sendJSON("POST", "/api/users/pin/reset", token, fmt.Sprintf(`{ "password": "secret-pass", "new_pin": "654321", "code": "%s" }`, recoveryCodes[0]))
+	assert.Equal(t, 429, code, "test reset pin after too many wrong codes")
+	code, _ = verifyPIN(token, "123456")
+	assert.Equal(t, 200, code, "test pin is kept")
+}
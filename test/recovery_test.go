@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestResetPassword(t *testing.T) {
+	createUser(`{ "username": "forgot", "email": "forgot@gmail.com", "mobile_phone": "081273649530", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "forgot", "password": "secret-pass" }`)
+
+	code, unknownBody := postJSON("/api/users/password/forgot", "", `{ "username_or_email": "nobody@gmail.com" }`)
+	assert.Equal(t, 202, code, "test forgot password of unknown account")
+	code, body := postJSON("/api/users/password/forgot", "", `{ "username_or_email": "forgot@gmail.com" }`)
+	assert.Equal(t, 202, code, "test forgot password")
+	assert.Equal(t, unknownBody, body, "test response does not reveal account")
+	code, _ = postJSON("/api/users/password/forgot", "", `{ "username_or_email": "forgot@gmail.com" }`)
+	assert.Equal(t, 202, code, "test forgot password within cooldown")
+
+	code, _ = postJSON("/api/users/password/reset", "", `{ "username_or_email": "nobody@gmail.com", "code": "000000", "password": "newsecret" }`)
+	assert.Equal(t, 403, code, "test reset password of unknown account")
+	code, _ = postJSON("/api/users/password/reset", "", `{ "username_or_email": "forgot@gmail.com", "code": "0000000", "password": "newsecret" }`)
+	assert.Equal(t, 403, code, "test reset password with wrong code")
+
+	resetCode := codePattern.FindString(waitMessage(t, "forgot@gmail.com", "Password reset code").Body)
+	request := fmt.Sprintf(`{ "username_or_email": "forgot", "code": "%s", "password": "newsecret" }`, resetCode)
+	code, _ = postJSON("/api/users/password/reset", "", request)
+	assert.Equal(t, 200, code, "test reset password")
+	code, _ = postJSON("/api/users/password/reset", "", request)
+	assert.Equal(t, 403, code, "test reset password with used code")
+
+	req, _ := http.NewRequest("GET", "/api/users", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test session revoked after reset")
+	assert.Equal(t, 401, res.StatusCode, "test session revoked after reset")
+
+	assert.Empty(t, loginUser(`{ "username_or_email": "forgot", "password": "secret-pass" }`), "test login with old password")
+	assert.NotEmpty(t, loginUser(`{ "username_or_email": "forgot", "password": "newsecret" }`), "test login with new password")
+}
+
+func TestResetPasswordThrottle(t *testing.T) {
+	createUser(`{ "username": "forgotlocked", "email": "forgotlocked@gmail.com", "mobile_phone": "081273649710", "password": "secret-pass" }`)
+	code, _ := postJSON("/api/users/password/forgot", "", `{ "username_or_email": "forgotlocked@gmail.com" }`)
+	assert.Equal(t, 202, code)
+	resetCode := codePattern.FindString(waitMessage(t, "forgotlocked@gmail.com", "Password reset code").Body)
+
+	for i := 0; i < 6; i++ {
+		code, _ = postJSON("/api/users/password/reset", "", `{ "username_or_email": "forgotlocked", "code": "0000000", "password": "newsecret" }`)
+		assert.Equal(t, 403, code, "test reset password with wrong code does not reveal lockout")
+		code, _ = postJSON("/api/users/password/reset", "", `{ "username_or_email": "nobodylocked", "code": "0000000", "password": "newsecret" }`)
+		assert.Equal(t, 403, code, "test reset password of unknown account")
+	}
+	code, _ = postJSON("/api/users/password/reset", "", fmt.Sprintf(`{ "username_or_email": "forgotlocked", "code": "%s", "password": "newsecret" }`, resetCode))
+	assert.Equal(t, 403, code, "test reset password with locked code")
+	assert.NotEmpty(t, loginUser(`{ "username_or_email": "forgotlocked", "password": "secret-pass" }`), "test password unchanged")
+}
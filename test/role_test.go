@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func sendJSON(method, url, token, request string) (int, []byte) {
+	req, _ := http.NewRequest(method, url, bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error "+method+" "+url))
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	return res.StatusCode, body
+}
+
+// grantRole assigns the role directly in database, it is embedded in the token of the next login
+func grantRole(t *testing.T, userID uuid.UUID, role string) {
+	if _, err := db.Exec("INSERT INTO user_roles (user_id, role, created_by, created_at) VALUES (?, ?, ?, NOW())", userID, role, userID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fetchRoles(token string, userID uuid.UUID) (int, []string) {
+	code, body := sendJSON("GET", fmt.Sprintf("/api/admin/users/%s/roles", userID), token, "")
+	var resp struct {
+		Data struct {
+			Roles []string `json:"roles"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	return code, resp.Data.Roles
+}
+
+func TestRoles(t *testing.T) {
+	admin := createUser(`{ "username": "rbacadmin", "email": "rbacadmin@gmail.com", "mobile_phone": "081273649560", "password": "secret-pass" }`)
+	user := createUser(`{ "username": "rbacuser", "email": "rbacuser@gmail.com", "mobile_phone": "081273649561", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "admin")
+	adminToken := loginUser(`{ "username_or_email": "rbacadmin", "password": "secret-pass" }`)
+	userToken := loginUser(`{ "username_or_email": "rbacuser", "password": "secret-pass" }`)
+
+	code, _ := fetchRoles(userToken, user.ID)
+	assert.Equal(t, 403, code, "test customer can not access admin api")
+	code, _ = fetchRoles("", user.ID)
+	assert.Equal(t, 400, code, "test admin api without token")
+
+	code, roles := fetchRoles(adminToken, user.ID)
+	assert.Equal(t, 200, code, "test admin fetch roles")
+	assert.Equal(t, []string{"customer"}, roles, "test registered user is customer")
+
+	url := fmt.Sprintf("/api/admin/users/%s/roles", user.ID)
+	code, _ = sendJSON("POST", url, adminToken, `{ "role": "owner" }`)
+	assert.Equal(t, 400, code, "test assign unknown role")
+	code, _ = sendJSON("POST", url, adminToken, `{ "role": "support" }`)
+	assert.Equal(t, 201, code, "test assign support role")
+	code, _ = sendJSON("POST", url, adminToken, `{ "role": "support" }`)
+	assert.Equal(t, 409, code, "test assign role twice")
+
+	supportToken := loginUser(`{ "username_or_email": "rbacuser", "password": "secret-pass" }`)
+	code, _ = fetchRoles(supportToken, admin.ID)
+	assert.Equal(t, 200, code, "test support can read users")
+	code, _ = sendJSON("POST", fmt.Sprintf("/api/admin/users/%s/roles", admin.ID), supportToken, `{ "role": "merchant" }`)
+	assert.Equal(t, 403, code, "test support can not manage roles")
+
+	code, _ = sendJSON("DELETE", url+"/support", adminToken, "")
+	assert.Equal(t, 200, code, "test revoke support role")
+	code, _ = fetchRoles(supportToken, admin.ID)
+	assert.Equal(t, 401, code, "test revoking role ends the sessions")
+	code, _ = sendJSON("DELETE", url+"/support", adminToken, "")
+	assert.Equal(t, 404, code, "test revoke role the user does not have")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"github.com/fajardm/ewallet-example/errorcode"
+	"github.com/fajardm/ewallet-example/session"
+	_sessionMySQL "github.com/fajardm/ewallet-example/session/mysql"
+	_sessionRedis "github.com/fajardm/ewallet-example/session/redis"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func testSessionStore(t *testing.T, store session.Store) {
+	ctx := context.Background()
+	defer store.Close()
+
+	_, err := store.Get(ctx, "missing")
+	assert.Equal(t, errorcode.ErrNotFound, err, "test get missing key")
+
+	assert.NoError(t, store.Set(ctx, "key", []byte("value"), time.Minute), "test set key")
+	value, err := store.Get(ctx, "key")
+	assert.NoError(t, err, "test get key")
+	assert.Equal(t, []byte("value"), value, "test get key")
+
+	assert.NoError(t, store.Set(ctx, "key", []byte("other"), time.Minute), "test overwrite key")
+	value, _ = store.Get(ctx, "key")
+	assert.Equal(t, []byte("other"), value, "test overwrite key")
+
+	assert.NoError(t, store.Delete(ctx, "key"), "test delete key")
+	_, err = store.Get(ctx, "key")
+	assert.Equal(t, errorcode.ErrNotFound, err, "test get deleted key")
+
+	assert.NoError(t, store.Set(ctx, "taken", []byte("value"), time.Minute), "test set taken key")
+	value, err = store.Take(ctx, "taken")
+	assert.NoError(t, err, "test take key")
+	assert.Equal(t, []byte("value"), value, "test take key")
+	_, err = store.Take(ctx, "taken")
+	assert.Equal(t, errorcode.ErrNotFound, err, "test take key twice")
+
+	for i := int64(1); i <= 3; i++ {
+		count, err := store.Increment(ctx, "counter", time.Minute)
+		assert.NoError(t, err, "test increment counter")
+		assert.Equal(t, i, count, "test increment counter")
+	}
+
+	assert.NoError(t, store.Set(ctx, "expiring", []byte("value"), time.Second), "test set expiring key")
+	time.Sleep(time.Second * 2)
+	_, err = store.Get(ctx, "expiring")
+	assert.Equal(t, errorcode.ErrNotFound, err, "test get expired key")
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	testSessionStore(t, session.NewMemoryStore(time.Minute))
+}
+
+func TestMySQLSessionStore(t *testing.T) {
+	testSessionStore(t, _sessionMySQL.NewMySQLStore(db, time.Minute))
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	addr := viper.GetString("SESSION.REDIS.ADDR")
+	if addr == "" {
+		t.Skip("SESSION.REDIS.ADDR is not configured")
+	}
+	store, err := _sessionRedis.NewRedisStore(_sessionRedis.Config{
+		Addr:     addr,
+		Password: viper.GetString("SESSION.REDIS.PASSWORD"),
+		DB:       viper.GetInt("SESSION.REDIS.DB"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSessionStore(t, store)
+}
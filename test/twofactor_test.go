@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func postJSON(url, token, request string) (int, []byte) {
+	req, _ := http.NewRequest("POST", url, bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(ipHeader, clientIP(request))
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error post "+url))
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	return res.StatusCode, body
+}
+
+// enableTwoFactor enrolls and enables two factor of the token owner, returns its recovery codes
+func enableTwoFactor(t *testing.T, token string) []string {
+	code, body := postJSON("/api/users/2fa/enroll", token, `{}`)
+	if code != 200 {
+		t.Fatalf("enroll two factor: %d %s", code, body)
+	}
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &enrollment)
+	totpCode, _ := totp.GenerateCode(enrollment.Data.Secret, time.Now())
+	code, body = postJSON("/api/users/2fa/enable", token, fmt.Sprintf(`{ "code": "%s" }`, totpCode))
+	if code != 200 {
+		t.Fatalf("enable two factor: %d %s", code, body)
+	}
+	var enabled struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &enabled)
+	return enabled.Data.RecoveryCodes
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	user := createUser(`{ "username": "edge", "email": "edge@gmail.com", "mobile_phone": "081273649514", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "edge", "password": "secret-pass" }`)
+
+	code, body := postJSON("/api/users/2fa/enroll", token, `{}`)
+	assert.Equal(t, 200, code, "test enroll")
+	var enrollment struct {
+		Data struct {
+			Secret string `json:"secret"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &enrollment); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal enrollment"))
+	}
+
+	req, _ := http.NewRequest("GET", "/api/users/2fa/qr", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test qr code")
+	assert.Equal(t, "image/png", res.Header.Get("Content-Type"), "test qr code")
+
+	code, _ = postJSON("/api/users/2fa/enable", token, `{ "code": "000000" }`)
+	assert.Equal(t, 403, code, "test enable with wrong code")
+
+	totpCode, _ := totp.GenerateCode(enrollment.Data.Secret, time.Now())
+	code, body = postJSON("/api/users/2fa/enable", token, fmt.Sprintf(`{ "code": "%s" }`, totpCode))
+	assert.Equal(t, 200, code, "test enable with valid code")
+	var enabled struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &enabled); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal recovery codes"))
+	}
+	assert.Len(t, enabled.Data.RecoveryCodes, 10, "test enable returns recovery codes")
+
+	code, body = postJSON("/api/users/login", "", `{ "username_or_email": "edge", "password": "secret-pass" }`)
+	assert.Equal(t, 200, code, "test login returns challenge")
+	var challenge struct {
+		Data struct {
+			Required bool   `json:"two_factor_required"`
+			Token    string `json:"challenge_token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &challenge); err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error unmarshal challenge"))
+	}
+	assert.True(t, challenge.Data.Required, "test login returns challenge")
+
+	code, _ = postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "000000" }`, challenge.Data.Token))
+	assert.Equal(t, 403, code, "test complete login with wrong code")
+	var failed int
+	if err := db.QueryRow("SELECT COUNT(*) FROM login_attempts WHERE user_id=? AND success=FALSE AND identifier IS NULL", user.ID).Scan(&failed); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, failed, "test wrong code recorded as failed login")
+
+	code, _ = postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "%s" }`, challenge.Data.Token, enabled.Data.RecoveryCodes[0]))
+	assert.Equal(t, 200, code, "test complete login with recovery code")
+
+	code, _ = postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "%s" }`, challenge.Data.Token, enabled.Data.RecoveryCodes[1]))
+	assert.Equal(t, 401, code, "test challenge can only be completed once")
+
+	code, _ = postJSON("/api/users/2fa/disable", token, fmt.Sprintf(`{ "code": "%s", "password": "wrong" }`, enabled.Data.RecoveryCodes[1]))
+	assert.Equal(t, 403, code, "test disable with wrong password")
+
+	code, _ = postJSON("/api/users/2fa/disable", token, fmt.Sprintf(`{ "code": "%s", "password": "secret-pass" }`, enabled.Data.RecoveryCodes[0]))
+	assert.Equal(t, 403, code, "test disable with used recovery code")
+
+	code, _ = postJSON("/api/users/2fa/disable", token, fmt.Sprintf(`{ "code": "%s", "password": "secret-pass" }`, enabled.Data.RecoveryCodes[1]))
+	assert.Equal(t, 200, code, "test disable with password and code")
+}
+
+// startChallenge logs in with the password and returns the challenge token of the two factor step
+func startChallenge(t *testing.T, request string) string {
+	code, body := postJSON("/api/users/login", "", request)
+	var challenge struct {
+		Data struct {
+			Token string `json:"challenge_token"`
+		} `json:"data"`
+	}
+	json.Unmarshal(body, &challenge)
+	if code != 200 || challenge.Data.Token == "" {
+		t.Fatalf("start challenge: %d %s", code, body)
+	}
+	return challenge.Data.Token
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	createUser(`{ "username": "guesser", "email": "guesser@gmail.com", "mobile_phone": "081273649670", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "guesser", "password": "secret-pass" }`)
+	recoveryCodes := enableTwoFactor(t, token)
+	login := `{ "username_or_email": "guesser", "password": "secret-pass" }`
+
+	challenge := startChallenge(t, login)
+	for i := 0; i < 3; i++ {
+		code, _ := postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "000000" }`, challenge))
+		assert.Equal(t, 403, code, "test complete login with wrong code")
+	}
+
+	// A new challenge continues the count of the user, parallel guesses can not pass the limit either
+	challenge = startChallenge(t, login)
+	codes := make([]int, 6)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i], _ = postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "000000" }`, challenge))
+		}(i)
+	}
+	wg.Wait()
+	wrong := 0
+	for _, code := range codes {
+		if code == 403 {
+			wrong++
+		}
+	}
+	assert.Equal(t, 2, wrong, "test wrong codes accepted for comparison across challenges")
+
+	challenge = startChallenge(t, login)
+	code, _ := postJSON("/api/users/login/2fa", "", fmt.Sprintf(`{ "challenge_token": "%s", "code": "%s" }`, challenge, recoveryCodes[0]))
+	assert.Equal(t, 429, code, "test valid code while locked out")
+}
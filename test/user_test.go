@@ -2,16 +2,25 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	_balanceRepository "github.com/fajardm/ewallet-example/app/balance/repository/mysql"
+	_roleRepository "github.com/fajardm/ewallet-example/app/role/repository/mysql"
 	"github.com/fajardm/ewallet-example/app/user/model"
+	_userRepository "github.com/fajardm/ewallet-example/app/user/repository/mysql"
+	_userUsecase "github.com/fajardm/ewallet-example/app/user/usecase"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"hash/fnv"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func createUser(request string) model.User {
@@ -33,9 +42,26 @@ func createUser(request string) model.User {
 	return resp.Data
 }
 
+// ipHeader carries the client IP in tests, as every request of app.Test comes from the same address
+const ipHeader = "X-Real-IP"
+
+// clientIP derives the address from the login identifier, so every test logs in from its own IP and failures of
+// one test never throttle the others
+func clientIP(request string) string {
+	var input struct {
+		UsernameOrEmail string `json:"username_or_email"`
+	}
+	json.Unmarshal([]byte(request), &input)
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(input.UsernameOrEmail)))
+	sum := h.Sum32()
+	return fmt.Sprintf("10.%d.%d.%d", byte(sum>>16), byte(sum>>8), byte(sum))
+}
+
 func loginUser(request string) string {
 	req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBufferString(request))
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(ipHeader, clientIP(request))
 	res, err := app.Test(req, -1)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "Fatal error login user"))
@@ -55,7 +81,7 @@ func loginUser(request string) string {
 }
 
 func TestLoginUser(t *testing.T) {
-	createUser(`{ "username": "cenna", "email": "cenna@gmail.com", "mobile_phone": "081273649506", "password": "secret" }`)
+	createUser(`{ "username": "cenna", "email": "cenna@gmail.com", "mobile_phone": "081273649506", "password": "secret-pass" }`)
 
 	cases := []struct {
 		description    string
@@ -70,7 +96,7 @@ func TestLoginUser(t *testing.T) {
 		},
 		{
 			description:  "test with empty username or email",
-			request:      `{ "username_or_email": "", "password": "secret" }`,
+			request:      `{ "username_or_email": "", "password": "secret-pass" }`,
 			expectedCode: 400,
 		},
 		{
@@ -80,7 +106,7 @@ func TestLoginUser(t *testing.T) {
 		},
 		{
 			description:  "test with valid json",
-			request:      `{ "username_or_email": "cenna", "password": "secret" }`,
+			request:      `{ "username_or_email": "cenna", "password": "secret-pass" }`,
 			expectedCode: 200,
 		},
 	}
@@ -88,6 +114,7 @@ func TestLoginUser(t *testing.T) {
 	for _, test := range cases {
 		req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBufferString(test.request))
 		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add(ipHeader, clientIP(test.request))
 		res, err := app.Test(req, -1)
 
 		assert.NoError(t, err, test.description)
@@ -109,22 +136,22 @@ func TestCreateUser(t *testing.T) {
 		},
 		{
 			description:  "test with empty username",
-			request:      `{ "username": "", "email": "john@gmail.com", "mobile_phone": "0817384956973", "password": "secret" }`,
+			request:      `{ "username": "", "email": "john@gmail.com", "mobile_phone": "0817384956973", "password": "secret-pass" }`,
 			expectedCode: 400,
 		},
 		{
 			description:  "test with empty email",
-			request:      `{ "username": "john", "email": "", "mobile_phone": "0817384956973", "password": "secret" }`,
+			request:      `{ "username": "john", "email": "", "mobile_phone": "0817384956973", "password": "secret-pass" }`,
 			expectedCode: 400,
 		},
 		{
 			description:  "test with invalid email",
-			request:      `{ "username": "john", "email": "john", "mobile_phone": "0817384956973", "password": "secret" }`,
+			request:      `{ "username": "john", "email": "john", "mobile_phone": "0817384956973", "password": "secret-pass" }`,
 			expectedCode: 400,
 		},
 		{
 			description:  "test with empty mobile phone",
-			request:      `{ "username": "john", "email": "john@gmail.com", "mobile_phone": "", "password": "secret" }`,
+			request:      `{ "username": "john", "email": "john@gmail.com", "mobile_phone": "", "password": "secret-pass" }`,
 			expectedCode: 400,
 		},
 		{
@@ -133,8 +160,18 @@ func TestCreateUser(t *testing.T) {
 			expectedCode: 400,
 		},
 		{
-			description:  "test with valid json",
+			description:  "test with too short password",
 			request:      `{ "username": "john", "email": "john@gmail.com", "mobile_phone": "0817384956973", "password": "secret" }`,
+			expectedCode: 400,
+		},
+		{
+			description:  "test with breached password",
+			request:      `{ "username": "john", "email": "john@gmail.com", "mobile_phone": "0817384956973", "password": "Password123" }`,
+			expectedCode: 400,
+		},
+		{
+			description:  "test with valid json",
+			request:      `{ "username": "john", "email": "john@gmail.com", "mobile_phone": "0817384956973", "password": "correct horse battery staple" }`,
 			expectedCode: 201,
 		},
 	}
@@ -149,9 +186,60 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
+func loginStatus(request string) (int, string) {
+	req, _ := http.NewRequest("POST", "/api/users/login", bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(ipHeader, clientIP(request))
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error login user"))
+	}
+	return res.StatusCode, string(GetBody(res.Body))
+}
+
+func TestLoginThrottling(t *testing.T) {
+	user := createUser(`{ "username": "throttle", "email": "throttle@gmail.com", "mobile_phone": "081273649550", "password": "secret-pass" }`)
+	admin := createUser(`{ "username": "unlocker", "email": "unlocker@gmail.com", "mobile_phone": "081273649551", "password": "secret-pass" }`)
+	grantRole(t, admin.ID, "support")
+	adminToken := loginUser(`{ "username_or_email": "unlocker", "password": "secret-pass" }`)
+
+	for i := 0; i < 4; i++ {
+		code, body := loginStatus(`{ "username_or_email": "throttle", "password": "wrong-pass" }`)
+		assert.Equal(t, 403, code, "test login with wrong password")
+		unknownCode, unknownBody := loginStatus(`{ "username_or_email": "ghost", "password": "wrong-pass" }`)
+		assert.Equal(t, code, unknownCode, "test unknown account fails the same way")
+		assert.Equal(t, body, unknownBody, "test unknown account fails the same way")
+	}
+	code, _ := loginStatus(`{ "username_or_email": "throttle", "password": "secret-pass" }`)
+	assert.Equal(t, 429, code, "test login during backoff")
+	code, _ = loginStatus(`{ "username_or_email": "ghost", "password": "wrong-pass" }`)
+	assert.Equal(t, 429, code, "test unknown account during backoff")
+
+	code, _ = postJSON(fmt.Sprintf("/api/admin/users/%s/unlock", user.ID), adminToken, `{}`)
+	assert.Equal(t, 200, code, "test admin unlock")
+	code, _ = loginStatus(`{ "username_or_email": "throttle", "password": "secret-pass" }`)
+	assert.Equal(t, 200, code, "test login after unlock")
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	user := createUser(`{ "username": "legacy", "email": "legacy@gmail.com", "mobile_phone": "081273649540", "password": "secret-pass" }`)
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("secret-pass"), bcrypt.DefaultCost)
+	if _, err := db.Exec("UPDATE users SET hashed_password=? WHERE id=?", legacy, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotEmpty(t, loginUser(`{ "username_or_email": "legacy", "password": "secret-pass" }`), "test login with bcrypt hash")
+	var hashed string
+	if err := db.QueryRow("SELECT hashed_password FROM users WHERE id=?", user.ID).Scan(&hashed); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(hashed, "$argon2id$"), "test hash upgraded to argon2id")
+	assert.NotEmpty(t, loginUser(`{ "username_or_email": "legacy", "password": "secret-pass" }`), "test login with upgraded hash")
+}
+
 func TestGetUser(t *testing.T) {
-	user := createUser(`{ "username": "dady", "email": "dady@gmail.com", "mobile_phone": "08172637485", "password": "secret" }`)
-	token := loginUser(`{ "username_or_email": "dady", "password": "secret" }`)
+	user := createUser(`{ "username": "dady", "email": "dady@gmail.com", "mobile_phone": "08172637485", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "dady", "password": "secret-pass" }`)
 
 	cases := []struct {
 		description    string
@@ -188,105 +276,233 @@ func TestGetUser(t *testing.T) {
 }
 
 func TestUpdateUser(t *testing.T) {
-	user := createUser(`{ "username": "beny", "email": "beny@gmail.com", "mobile_phone": "081253647589", "password": "secret" }`)
-	token := loginUser(`{ "username_or_email": "beny", "password": "secret" }`)
+	createUser(`{ "username": "beny", "email": "beny@gmail.com", "mobile_phone": "081253647589", "password": "secret-pass" }`)
+	createUser(`{ "username": "benytaken", "email": "benytaken@gmail.com", "mobile_phone": "081253647590", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "beny", "password": "secret-pass" }`)
 
 	cases := []struct {
-		description    string
-		paramID        string
-		request        string
-		expectedStatus string
-		expectedCode   int
+		description  string
+		request      string
+		expectedCode int
 	}{
 		{
-			description:  "test with invalid id",
-			paramID:      "xxx",
-			request:      `{ "email": "beny2@gmail.com", "password": "secret" }`,
+			description:  "test with empty json",
+			request:      `{}`,
 			expectedCode: 400,
 		},
 		{
-			description:  "test with valid id but not registered",
-			paramID:      uuid.NewV4().String(),
-			request:      `{ "email": "beny2@gmail.com", "password": "secret" }`,
-			expectedCode: 404,
+			description:  "test with invalid email",
+			request:      `{ "email": "john" }`,
+			expectedCode: 400,
 		},
 		{
-			description:  "test with invalid json",
-			paramID:      user.ID.String(),
-			request:      `{}`,
+			description:  "test with empty username",
+			request:      `{ "username": "" }`,
 			expectedCode: 400,
 		},
 		{
-			description:  "test with empty email",
-			paramID:      user.ID.String(),
-			request:      `{ "email": "", "password": "secret" }`,
-			expectedCode: 400,
+			description:  "test with username of other user",
+			request:      `{ "username": "benytaken" }`,
+			expectedCode: 409,
 		},
 		{
-			description:  "test with invalid email",
-			paramID:      user.ID.String(),
-			request:      `{ "email": "john", "password": "secret" }`,
-			expectedCode: 400,
+			description:  "test with email of other user",
+			request:      `{ "email": "benytaken@gmail.com" }`,
+			expectedCode: 409,
 		},
 		{
-			description:  "test with empty password",
-			paramID:      user.ID.String(),
-			request:      `{ "email": "john@gmail.com", "password": "" }`,
-			expectedCode: 400,
+			description:  "test with mobile phone of other user",
+			request:      `{ "mobile_phone": "081253647590" }`,
+			expectedCode: 409,
 		},
 		{
-			description:  "test with valid json",
-			paramID:      user.ID.String(),
-			request:      `{ "email": "beny2@gmail.com", "password": "secret" }`,
+			description:  "test with email only",
+			request:      `{ "email": "beny2@gmail.com" }`,
+			expectedCode: 200,
+		},
+		{
+			description:  "test with username and mobile phone",
+			request:      `{ "username": "beny2", "mobile_phone": "081253647591" }`,
 			expectedCode: 200,
 		},
 	}
 
 	for _, test := range cases {
-		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/users/%s", test.paramID), bytes.NewBufferString(test.request))
-		req.Header.Add("Content-Type", "application/json")
-		req.Header.Add("Authorization", "Bearer "+token)
-		res, err := app.Test(req, -1)
+		code, _ := sendJSON("PATCH", "/api/users", token, test.request)
+		assert.Equal(t, test.expectedCode, code, test.description)
+	}
 
-		assert.NoError(t, err, test.description)
-		assert.Equal(t, test.expectedCode, res.StatusCode, test.description)
+	_, body := sendJSON("GET", "/api/users", token, "")
+	var resp struct {
+		Data model.User `json:"data"`
 	}
+	json.Unmarshal(body, &resp)
+	assert.Equal(t, "beny2", resp.Data.Username, "test username is updated")
+	assert.Equal(t, "beny2@gmail.com", resp.Data.Email, "test email is kept by later update")
+	assert.Equal(t, "081253647591", resp.Data.MobilePhone, "test mobile phone is updated")
+
+	code, _ := loginStatus(`{ "username_or_email": "beny2", "password": "secret-pass" }`)
+	assert.Equal(t, 200, code, "test password is kept by profile update")
 }
 
-func TestDeletetUser(t *testing.T) {
-	user := createUser(`{ "username": "dony", "email": "dony@gmail.com", "mobile_phone": "081253840698", "password": "secret" }`)
-	token := loginUser(`{ "username_or_email": "dony", "password": "secret" }`)
+func TestChangePassword(t *testing.T) {
+	user := createUser(`{ "username": "passchanger", "email": "passchanger@gmail.com", "mobile_phone": "081253647592", "password": "secret-pass" }`)
+	phone := loginUser(`{ "username_or_email": "passchanger", "password": "secret-pass", "device_name": "phone" }`)
+	laptop := loginUser(`{ "username_or_email": "passchanger", "password": "secret-pass", "device_name": "laptop" }`)
 
-	cases := []struct {
-		description    string
-		id             string
-		expectedStatus string
-		expectedCode   int
-	}{
-		{
-			description:  "test with invalid id",
-			id:           "xxx",
-			expectedCode: 400,
-		},
-		{
-			description:  "test with valid id but not registered",
-			id:           uuid.NewV4().String(),
-			expectedCode: 404,
-		},
-		{
-			description:  "test with valid id",
-			id:           user.ID.String(),
-			expectedCode: 200,
-		},
+	code, _ := sendJSON("PUT", "/api/users/password", laptop, `{ "current_password": "wrong-pass", "new_password": "new-secret-pass" }`)
+	assert.Equal(t, 403, code, "test change password with wrong current password")
+	code, _ = sendJSON("PUT", "/api/users/password", laptop, `{ "current_password": "secret-pass", "new_password": "" }`)
+	assert.Equal(t, 400, code, "test change password with empty new password")
+	code, _ = sendJSON("PUT", "/api/users/password", laptop, `{ "current_password": "secret-pass", "new_password": "new-secret-pass" }`)
+	assert.Equal(t, 200, code, "test change password")
+	var updatedBy string
+	var updated bool
+	if err := db.QueryRow("SELECT updated_by, updated_at IS NOT NULL FROM users WHERE id=?", user.ID).Scan(&updatedBy, &updated); err != nil {
+		t.Fatal(err)
 	}
+	assert.Equal(t, user.ID.String(), updatedBy, "test change password records who changed it")
+	assert.True(t, updated, "test change password records when it changed")
 
-	for _, test := range cases {
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/users/%s", test.id), nil)
-		req.Header.Add("Content-Type", "application/json")
-		req.Header.Add("Authorization", "Bearer "+token)
-		res, err := app.Test(req, -1)
+	code, _ = fetchSessions(phone)
+	assert.Equal(t, 401, code, "test other session is revoked")
+	code, _ = fetchSessions(laptop)
+	assert.Equal(t, 200, code, "test current session is kept")
 
-		assert.NoError(t, err, test.description)
-		assert.Equal(t, test.expectedCode, res.StatusCode, test.description)
+	code, _ = loginStatus(`{ "username_or_email": "passchanger", "password": "secret-pass" }`)
+	assert.Equal(t, 403, code, "test login with old password")
+	code, _ = loginStatus(`{ "username_or_email": "passchanger", "password": "new-secret-pass" }`)
+	assert.Equal(t, 200, code, "test login with new password")
+}
+
+func TestChangePasswordThrottle(t *testing.T) {
+	createUser(`{ "username": "passguesser", "email": "passguesser@gmail.com", "mobile_phone": "081253647593", "password": "secret-pass" }`)
+	phone := loginUser(`{ "username_or_email": "passguesser", "password": "secret-pass", "device_name": "phone" }`)
+	stolen := loginUser(`{ "username_or_email": "passguesser", "password": "secret-pass", "device_name": "laptop" }`)
+
+	for i := 0; i < 4; i++ {
+		code, _ := sendJSON("PUT", "/api/users/password", stolen, `{ "current_password": "wrong-pass", "new_password": "new-secret-pass" }`)
+		assert.Equal(t, 403, code, "test change password with wrong current password")
 	}
+	code, _ := sendJSON("PUT", "/api/users/password", stolen, `{ "current_password": "secret-pass", "new_password": "new-secret-pass" }`)
+	assert.Equal(t, 429, code, "test change password during backoff")
+	code, _ = fetchSessions(phone)
+	assert.Equal(t, 200, code, "test other session is kept")
+	code, _ = loginStatus(`{ "username_or_email": "passguesser", "password": "secret-pass" }`)
+	assert.Equal(t, 429, code, "test wrong current password counts toward login lockout")
+}
+
+func closeUser(token, request string) int {
+	_, stepUp := verifyPIN(token, "123456")
+	req, _ := http.NewRequest("DELETE", "/api/users", bytes.NewBufferString(request))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+	req.Header.Add("X-Step-Up-Token", stepUp)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "Fatal error close user"))
+	}
+	return res.StatusCode
+}
+
+func TestCloseUser(t *testing.T) {
+	user := createUser(`{ "username": "dony", "email": "dony@gmail.com", "mobile_phone": "081253840698", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "dony", "password": "secret-pass" }`)
+	assert.Equal(t, 200, setPIN(token, `{ "pin": "123456", "password": "secret-pass" }`))
+	assert.Equal(t, 200, topUpBalance(token, 10))
+
+	req, _ := http.NewRequest("DELETE", "/api/users", nil)
+	req.Header.Add("Authorization", "Bearer "+token)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test close without pin verification")
+	assert.Equal(t, 403, res.StatusCode, "test close without pin verification")
+
+	assert.Equal(t, 422, closeUser(token, ""), "test close with money left and no payout account")
+	assert.Equal(t, 200, closeUser(token, `{ "payout_account": "BCA 1234567890" }`), "test close with payout")
+
+	code, _ := loginStatus(`{ "username_or_email": "dony", "password": "secret-pass" }`)
+	assert.NotEqual(t, 200, code, "test login after close")
+	var username, email string
+	var deleted bool
+	if err := db.QueryRow("SELECT username, email, deleted_at IS NOT NULL FROM users WHERE id=?", user.ID).Scan(&username, &email, &deleted); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, deleted, "test user soft deleted")
+	assert.NotEqual(t, "dony", username, "test username anonymised")
+	assert.NotEqual(t, "dony@gmail.com", email, "test email anonymised")
+	var histories int
+	if err := db.QueryRow("SELECT COUNT(*) FROM balance_histories h JOIN balances b ON b.id=h.balance_id WHERE b.user_id=? AND h.category='withdrawal'", user.ID).Scan(&histories); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, histories, "test payout history retained")
+
+	created := createUser(`{ "username": "dony", "email": "dony@gmail.com", "mobile_phone": "081253840698", "password": "secret-pass" }`)
+	assert.NotEqual(t, user.ID, created.ID, "test username and email reusable after close")
+
+	usecase := _userUsecase.NewUserUsecase(_userRepository.NewUserRepository(db), _balanceRepository.NewBalanceRepository(db), _roleRepository.NewRoleRepository(db), nil, model.LoginPolicy{}, time.Second*3)
+	purged, err := usecase.Purge(context.Background(), time.Now().Add(-time.Hour))
+	assert.NoError(t, err, "test purge within retention")
+	assert.Len(t, purged, 0, "test purge within retention")
+	purged, err = usecase.Purge(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err, "test purge after retention")
+	assert.Len(t, purged, 1, "test purge after retention")
+	if err := db.QueryRow("SELECT COUNT(*) FROM balances WHERE user_id=?", user.ID).Scan(&histories); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, histories, "test balances purged")
+}
+
+func TestSearchUsers(t *testing.T) {
+	support := createUser(`{ "username": "searcher", "email": "searcher@gmail.com", "mobile_phone": "081273649570", "password": "secret-pass" }`)
+	first := createUser(`{ "username": "lookup_a", "email": "lookup_a@gmail.com", "mobile_phone": "081273649571", "password": "secret-pass" }`)
+	createUser(`{ "username": "lookupxb", "email": "lookupxb@gmail.com", "mobile_phone": "081273649572", "password": "secret-pass" }`)
+	grantRole(t, support.ID, "support")
+	token := loginUser(`{ "username_or_email": "searcher", "password": "secret-pass" }`)
+
+	search := func(query string) (int, model.SearchResult) {
+		code, body := sendJSON("GET", "/api/admin/users?"+query, token, "")
+		var resp struct {
+			Data model.SearchResult `json:"data"`
+		}
+		json.Unmarshal(body, &resp)
+		return code, resp.Data
+	}
+
+	code, res := search("q=lookup&sort=username")
+	assert.Equal(t, 200, code, "test search by username prefix")
+	assert.Equal(t, 2, res.Total, "test search by username prefix")
+	assert.Equal(t, "lookup_a", res.Users[0].Username, "test sorted by username")
+	code, res = search("q=lookup_&per_page=1")
+	assert.Equal(t, 200, code, "test search with wildcard character")
+	assert.Equal(t, 1, res.Total, "test underscore matched literally")
+	code, res = search("q=lookup&sort=-username&per_page=1&page=2")
+	assert.Equal(t, 200, code, "test search second page")
+	assert.Equal(t, 2, res.Total, "test search second page")
+	assert.Len(t, res.Users, 1, "test search second page")
+	assert.Equal(t, "lookup_a", res.Users[0].Username, "test search second page")
+	_, res = search("q=081273649572")
+	assert.Equal(t, 1, res.Total, "test search by mobile phone")
+	_, res = search("q=" + first.ID.String()[:8])
+	assert.Equal(t, first.ID, res.Users[0].ID, "test search by id prefix")
+	_, res = search("q=lookup&status=closed")
+	assert.Equal(t, 0, res.Total, "test filter by status")
+	_, res = search("q=lookup&created_from=2000-01-01&created_to=2000-12-31")
+	assert.Equal(t, 0, res.Total, "test filter by created date")
+	code, _ = search("sort=password")
+	assert.Equal(t, 400, code, "test sort by unknown column")
+
+	code, body := sendJSON("GET", fmt.Sprintf("/api/admin/users/%s", first.ID), token, "")
+	assert.Equal(t, 200, code, "test get user detail")
+	var resp struct {
+		Data model.Detail `json:"data"`
+	}
+	json.Unmarshal(body, &resp)
+	assert.Equal(t, first.ID, resp.Data.User.ID, "test get user detail")
+	assert.Equal(t, []string{"customer"}, resp.Data.Roles, "test detail includes roles")
+	assert.Len(t, resp.Data.Balances, 1, "test detail includes balance")
+	assert.Len(t, resp.Data.Histories, 1, "test detail includes recent history")
+
+	customer := loginUser(`{ "username_or_email": "lookup_a", "password": "secret-pass" }`)
+	code, _ = sendJSON("GET", "/api/admin/users", customer, "")
+	assert.Equal(t, 403, code, "test customer can not search users")
 }
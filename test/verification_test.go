@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+var linkTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// verifyPhone confirms the mobile phone with the code sent on registration
+func verifyPhone(token, mobilePhone string, t *testing.T) int {
+	code, _ := postJSON("/api/users/verify/phone", token, fmt.Sprintf(`{ "code": "%s" }`, lastCode(t, mobilePhone)))
+	return code
+}
+
+func TestVerification(t *testing.T) {
+	createUser(`{ "username": "verify", "email": "verify@gmail.com", "mobile_phone": "081273649520", "password": "secret-pass" }`)
+	token := loginUser(`{ "username_or_email": "verify", "password": "secret-pass" }`)
+
+	code, _ := postJSON("/api/users/verify/email/resend", token, `{}`)
+	assert.Equal(t, 429, code, "test resend email within cooldown")
+
+	req, _ := http.NewRequest("GET", "/api/users/verify/email?token=invalid", nil)
+	res, err := app.Test(req, -1)
+	assert.NoError(t, err, "test verify email with invalid link")
+	assert.Equal(t, 403, res.StatusCode, "test verify email with invalid link")
+
+	match := linkTokenPattern.FindStringSubmatch(lastMessage(t, "verify@gmail.com").Body)
+	if assert.Len(t, match, 2, "test email link sent on registration") {
+		req, _ = http.NewRequest("GET", "/api/users/verify/email?token="+match[1], nil)
+		res, err = app.Test(req, -1)
+		assert.NoError(t, err, "test verify email")
+		assert.Equal(t, 200, res.StatusCode, "test verify email")
+	}
+	code, _ = postJSON("/api/users/verify/email/resend", token, `{}`)
+	assert.Equal(t, 409, code, "test resend verified email")
+
+	code, _ = postJSON("/api/users/verify/phone", token, `{ "code": "0000000" }`)
+	assert.Equal(t, 403, code, "test verify phone with wrong code")
+	assert.Equal(t, 200, verifyPhone(token, "081273649520", t), "test verify phone")
+	code, _ = postJSON("/api/users/verify/phone/resend", token, `{}`)
+	assert.Equal(t, 409, code, "test resend verified phone")
+}
@@ -0,0 +1,227 @@
+package token
+
+import (
+	"crypto"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultKeyID is kid of the APP_SECRET key used when no JWT.KEYS configured, tokens
+// without kid header are verified with it too
+const defaultKeyID = "default"
+
+// ErrUnknownKey is returned when token refers to missing or retired key
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a signing key identified by kid. A key starts signing at ActiveFrom and is accepted for
+// verification until RetireAt, so the previous key keeps verifying tokens it signed while the next one
+// takes over. Key without private part is only used for verification
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	Private    crypto.PrivateKey
+	Public     crypto.PublicKey
+	ActiveFrom time.Time
+	RetireAt   *time.Time
+}
+
+// Retired reports whether the key is no longer accepted
+func (k Key) Retired(now time.Time) bool {
+	return k.RetireAt != nil && !now.Before(*k.RetireAt)
+}
+
+// CanSign reports whether the key may sign new token at the time
+func (k Key) CanSign(now time.Time) bool {
+	return k.Private != nil && !now.Before(k.ActiveFrom) && !k.Retired(now)
+}
+
+// KeySet is every known signing key
+type KeySet struct {
+	keys []Key
+}
+
+// NewKeySet creates key set, keys are ordered by ActiveFrom
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("key id is required")
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate key id %s", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ActiveFrom.Before(keys[j].ActiveFrom)
+	})
+	return &KeySet{keys: keys}, nil
+}
+
+// Signing returns the most recently activated key able to sign at the time
+func (s KeySet) Signing(now time.Time) (*Key, error) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].CanSign(now) {
+			return &s.keys[i], nil
+		}
+	}
+	return nil, errors.New("no active signing key")
+}
+
+// Verifying returns the key of the kid if it is not retired
+func (s KeySet) Verifying(kid string, now time.Time) (*Key, error) {
+	if kid == "" {
+		kid = defaultKeyID
+	}
+	for i := range s.keys {
+		if s.keys[i].ID == kid && !s.keys[i].Retired(now) {
+			return &s.keys[i], nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// Published returns asymmetric keys that are not retired, including keys scheduled to sign later
+// so verifiers learn about them before the rotation
+func (s KeySet) Published(now time.Time) []Key {
+	res := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		if _, ok := k.Method.(*jwt.SigningMethodHMAC); ok || k.Retired(now) {
+			continue
+		}
+		res = append(res, k)
+	}
+	return res
+}
+
+type keyConfig struct {
+	ID         string `mapstructure:"ID"`
+	Algorithm  string `mapstructure:"ALGORITHM"`
+	PrivateKey string `mapstructure:"PRIVATE_KEY"`
+	PublicKey  string `mapstructure:"PUBLIC_KEY"`
+	ActiveFrom string `mapstructure:"ACTIVE_FROM"`
+	RetireAt   string `mapstructure:"RETIRE_AT"`
+}
+
+func (c keyConfig) key() (*Key, error) {
+	k := &Key{ID: c.ID, Method: jwt.GetSigningMethod(c.Algorithm)}
+	switch k.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %s", c.ID, c.Algorithm)
+	}
+
+	if c.PrivateKey != "" {
+		pem, err := ioutil.ReadFile(c.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", c.ID)
+		}
+		if strings.HasPrefix(c.Algorithm, "RS") {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "key %s", c.ID)
+			}
+			k.Private, k.Public = private, &private.PublicKey
+		} else {
+			private, err := jwt.ParseECPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, errors.Wrapf(err, "key %s", c.ID)
+			}
+			k.Private, k.Public = private, &private.PublicKey
+		}
+	} else if c.PublicKey != "" {
+		pem, err := ioutil.ReadFile(c.PublicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", c.ID)
+		}
+		if strings.HasPrefix(c.Algorithm, "RS") {
+			k.Public, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		} else {
+			k.Public, err = jwt.ParseECPublicKeyFromPEM(pem)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", c.ID)
+		}
+	} else {
+		return nil, fmt.Errorf("key %s: private or public key file is required", c.ID)
+	}
+
+	if c.ActiveFrom != "" {
+		t, err := time.Parse(time.RFC3339, c.ActiveFrom)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", c.ID)
+		}
+		k.ActiveFrom = t
+	}
+	if c.RetireAt != "" {
+		t, err := time.Parse(time.RFC3339, c.RetireAt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key %s", c.ID)
+		}
+		k.RetireAt = &t
+	}
+	return k, nil
+}
+
+// LoadKeySet reads keys from JWT.KEYS, falls back to HS256 key of APP_SECRET when none configured
+func LoadKeySet() (*KeySet, error) {
+	var configs []keyConfig
+	if err := viper.UnmarshalKey("JWT.KEYS", &configs); err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return NewKeySet(Key{
+			ID:      defaultKeyID,
+			Method:  jwt.SigningMethodHS256,
+			Private: []byte(viper.GetString("APP_SECRET")),
+			Public:  []byte(viper.GetString("APP_SECRET")),
+		})
+	}
+
+	keys := make([]Key, 0, len(configs))
+	for _, c := range configs {
+		k, err := c.key()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return NewKeySet(keys...)
+}
+
+var keysOnce sync.Once
+var _keys *KeySet
+var _keysErr error
+
+// Keys returns key set loaded from config on first call
+func Keys() (*KeySet, error) {
+	keysOnce.Do(func() {
+		_keys, _keysErr = LoadKeySet()
+	})
+	return _keys, _keysErr
+}
+
+// Keyfunc resolves verification key of the token by its kid header, rejects token whose
+// algorithm differs from the key's
+func Keyfunc(t *jwt.Token) (interface{}, error) {
+	keys, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := t.Header["kid"].(string)
+	k, err := keys.Verifying(kid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+	return k.Public, nil
+}
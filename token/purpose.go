@@ -0,0 +1,38 @@
+package token
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ErrInvalidPurpose is returned when the token is valid but was signed for other purpose
+var ErrInvalidPurpose = errors.New("token purpose mismatch")
+
+// SignPurpose creates JWT bound to the purpose through "typ" claim, expiring after ttl. Such token carries no
+// "user_id" so it can never pass as access token
+func SignPurpose(purpose string, claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	c := jwt.MapClaims{}
+	for k, v := range claims {
+		c[k] = v
+	}
+	c["typ"] = purpose
+	c["exp"] = time.Now().Add(ttl).Unix()
+	return Sign(c)
+}
+
+// ParsePurpose verifies the token signed by SignPurpose for the same purpose and returns its claims
+func ParsePurpose(purpose, t string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(t, Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || !parsed.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if typ, _ := claims["typ"].(string); typ != purpose {
+		return nil, ErrInvalidPurpose
+	}
+	return claims, nil
+}
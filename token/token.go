@@ -0,0 +1,62 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/spf13/viper"
+	"time"
+)
+
+const (
+	defaultAccessTTL  = time.Minute * 15
+	defaultRefreshTTL = time.Hour * 24 * 30
+)
+
+// AccessTTL returns lifetime of access token, configured by JWT.ACCESS_TTL
+func AccessTTL() time.Duration {
+	if d := viper.GetDuration("JWT.ACCESS_TTL"); d > 0 {
+		return d
+	}
+	return defaultAccessTTL
+}
+
+// RefreshTTL returns lifetime of refresh token, configured by JWT.REFRESH_TTL
+func RefreshTTL() time.Duration {
+	if d := viper.GetDuration("JWT.REFRESH_TTL"); d > 0 {
+		return d
+	}
+	return defaultRefreshTTL
+}
+
+// Sign creates JWT of the given claims signed by the current signing key
+func Sign(claims jwt.MapClaims) (string, error) {
+	keys, err := Keys()
+	if err != nil {
+		return "", err
+	}
+	k, err := keys.Signing(time.Now())
+	if err != nil {
+		return "", err
+	}
+	t := jwt.NewWithClaims(k.Method, claims)
+	t.Header["kid"] = k.ID
+	return t.SignedString(k.Private)
+}
+
+// NewOpaque generates random url safe token, only its hash should be persisted
+func NewOpaque() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns hex encoded SHA-256 of the opaque token
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}